@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/superiden3/go_compress/internal/core"
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// Main function of the create mode, which bundles files and directories into an archive.
+func mainCreate(args []string) {
+	createFlags := flag.NewFlagSet("create", flag.ExitOnError)
+	alg := createFlags.String("algorithm", "zstd", "Compression algorithm for the files, or a pipeline of algorithms joined with '+' (files it does not make smaller are stored as is)")
+	checksum := createFlags.String("checksum", algorithms.ChecksumNames[algorithms.ChecksumType], "Checksum stored with the size of every file (crc32 or crc32c; none stores a crc32 anyway)")
+	createFlags.Usage = func() {
+		fmt.Println("Usage: go run main.go create [create options] <archive_file> <path> [path2] ...")
+		fmt.Println("Create options:")
+		createFlags.PrintDefaults()
+	}
+	createFlags.Parse(args)
+
+	if createFlags.NArg() < 2 {
+		createFlags.Usage()
+		return
+	}
+	stages, err := archiveStages(*alg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := configureChecksum(*checksum); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	// Write the archive, removing it if anything fails
+	output := createFlags.Arg(0)
+	if err := createArchive(output, createFlags.Args()[1:], stages); err != nil {
+		os.Remove(output)
+		fmt.Fprintf(os.Stderr, "Error: Failed to create archive \"%s\": %v\n", output, err)
+	}
+}
+
+// Write the files and directories at `paths` into a new archive at `output`.
+func createArchive(output string, paths []string, stages []int) error {
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(file)
+	archive, err := core.NewArchiveWriter(buffered)
+	if err != nil {
+		return err
+	}
+	archive.Output = info
+	for _, path := range paths {
+		if err := archive.AddPath(path, stages...); err != nil {
+			return err
+		}
+	}
+	for _, path := range archive.Skipped {
+		if skipped, err := os.Lstat(path); err == nil && os.SameFile(skipped, info) {
+			fmt.Fprintf(os.Stderr, "Skipped \"%s\": file is the archive; not dumped\n", path)
+		} else {
+			fmt.Fprintf(os.Stderr, "Skipped \"%s\": only regular files and directories can be archived\n", path)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Main function of the list mode, which prints the entries of an archive.
+func mainList(args []string) {
+	listFlags := flag.NewFlagSet("list", flag.ExitOnError)
+	listFlags.Usage = func() {
+		fmt.Println("Usage: go run main.go list <archive_file>")
+	}
+	listFlags.Parse(args)
+
+	if listFlags.NArg() != 1 {
+		listFlags.Usage()
+		return
+	}
+	archive, err := core.OpenArchive(listFlags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to open archive \"%s\": %v\n", listFlags.Arg(0), err)
+		return
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.Entries() {
+		name := entry.Path
+		if entry.Mode.IsDir() {
+			name += "/"
+		}
+		fmt.Printf("%s %12d %12d %-16s %s %s\n", entry.Mode, entry.Size, entry.CompressedSize, entry.Algorithm(), entry.ModTime.Format("2006-01-02 15:04"), name)
+	}
+}
+
+// Main function of the extract mode, which writes the entries of an archive (or those matching globs) to a directory.
+func mainExtract(args []string) {
+	extractFlags := flag.NewFlagSet("extract", flag.ExitOnError)
+	dir := extractFlags.String("dir", ".", "Directory to extract the entries into")
+	extractFlags.Usage = func() {
+		fmt.Println("Usage: go run main.go extract [extract options] <archive_file> [glob] [glob2] ...")
+		fmt.Println("Extracts the entries whose path, or the path of a directory they are in, matches a glob (e.g. 'docs/*.md'), or all of them.")
+		fmt.Println("Extract options:")
+		extractFlags.PrintDefaults()
+	}
+	extractFlags.Parse(args)
+
+	if extractFlags.NArg() < 1 {
+		extractFlags.Usage()
+		return
+	}
+	archive, err := core.OpenArchive(extractFlags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to open archive \"%s\": %v\n", extractFlags.Arg(0), err)
+		return
+	}
+	defer archive.Close()
+
+	entries, err := archive.Extract(*dir, extractFlags.Args()[1:]...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to extract archive \"%s\": %v\n", extractFlags.Arg(0), err)
+		return
+	}
+	fmt.Printf("Extracted %d entries to \"%s\"\n", len(entries), *dir)
+}
+
+// Stages of the algorithm or pipeline spec selected for the files of an archive
+func archiveStages(spec string) ([]int, error) {
+	if core.IsPipelineSpec(spec) {
+		pipeline, err := core.NewPipeline(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipeline '%s': %w", spec, err)
+		}
+		return pipeline.Stages, nil
+	}
+	alg_int := algorithms.GetAlgorithmID(spec)
+	if alg_int < 0 {
+		return nil, fmt.Errorf("unknown algorithm '%s'", spec)
+	}
+	return []int{alg_int}, nil
+}
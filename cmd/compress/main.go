@@ -13,20 +13,37 @@ import (
 // Printing usage info
 func usage() {
 	fmt.Println("Usage: go run main.go [options] <input_file> <output_file> [input_file2] [output_file2] ...")
+	fmt.Println("       go run main.go train [train options] <dictionary_file> <sample_file> [sample_file2] ...")
+	fmt.Println("       go run main.go create [create options] <archive_file> <path> [path2] ...")
+	fmt.Println("       go run main.go list <archive_file>")
+	fmt.Println("       go run main.go extract [extract options] <archive_file> [glob] [glob2] ...")
 	fmt.Println("Options:")
 	flag.PrintDefaults()
 }
 
 // Main compressing function for `main` to use.
-func mainCompress(alg_int int, wg *sync.WaitGroup) {
+func mainCompress(alg_int int, filter_int int, pipeline *core.Pipeline, raw bool, seekable bool, wg *sync.WaitGroup) {
 	// Checking for invalid arguments
-	if alg_int < 0 || alg_int >= len(algorithms.Algorithms)  {
+	if pipeline == nil && alg_int < 0 || alg_int >= len(algorithms.Algorithms)  {
 		fmt.Fprintf(os.Stderr, "Error: Unknown algorithm number %d\n", alg_int)
 		return
 	}
 
-	// Create a new compressor with the selected algorithm
-	compressor, err := core.NewFileToFileCompressor(alg_int) // Create a new compressor with the selected algorithm
+	// Create a new compressor with the selected pipeline or algorithm (behind the selected filter, if any)
+	var compressor core.FileToFileCompressor
+	var err error
+	switch {
+	case seekable:
+		compressor, err = core.NewSeekableCompressor(seekableStages(alg_int, filter_int, pipeline)...)
+	case pipeline != nil:
+		compressor = pipeline
+	case filter_int >= 0:
+		compressor, err = core.NewFilteredCompressor(filter_int, alg_int)
+	case raw:
+		compressor, err = core.NewRawFileToFileCompressor(alg_int)
+	default:
+		compressor, err = core.NewFileToFileCompressor(alg_int)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to create compressor: %v\n", err)
 		return
@@ -65,15 +82,28 @@ func mainCompress(alg_int int, wg *sync.WaitGroup) {
 }
 
 // Main decompressing function for `main` to use.
-func mainDecompress(alg_int int, wg *sync.WaitGroup) {
-	// Checking for invalid arguments
-	if alg_int < 0 || alg_int >= len(algorithms.Algorithms)  {
+func mainDecompress(alg_int int, filter_int int, pipeline *core.Pipeline, raw bool, wg *sync.WaitGroup) {
+	// Checking for invalid arguments (a negative algorithm means it is detected from the files)
+	if alg_int >= len(algorithms.Algorithms)  {
 		fmt.Fprintf(os.Stderr, "Error: Unknown algorithm number %d\n", alg_int)
 		return
 	}
 
-	// Create a new decompressor with the selected algorithm
-	decompressor, err := core.NewFileToFileDecompressor(alg_int)
+	// Create a new decompressor with the selected pipeline or algorithm (undoing the selected filter, if any)
+	var decompressor core.FileToFileDecompressor
+	var err error
+	switch {
+	case pipeline != nil:
+		decompressor = pipeline // The files must record the same stages
+	case alg_int < 0:
+		decompressor = core.NewContainerDecompressor() // The algorithm is read from the files
+	case filter_int >= 0:
+		decompressor, err = core.NewFilteredDecompressor(filter_int, alg_int)
+	case raw:
+		decompressor, err = core.NewRawFileToFileDecompressor(alg_int)
+	default:
+		decompressor, err = core.NewFileToFileDecompressor(alg_int)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to create decompressor: %v\n", err)
 		return
@@ -111,6 +141,18 @@ func mainDecompress(alg_int int, wg *sync.WaitGroup) {
 	wg.Wait()
 }
 
+// Stages of the selected pipeline or algorithm (behind the selected filter, if any) for the seekable format
+func seekableStages(alg_int int, filter_int int, pipeline *core.Pipeline) []int {
+	switch {
+	case pipeline != nil:
+		return pipeline.Stages
+	case filter_int >= 0:
+		return []int{filter_int, alg_int}
+	default:
+		return []int{alg_int}
+	}
+}
+
 // Enable verbose logging
 func verbosify(makeVerbose bool) {
 	algorithms.RleVerbose = makeVerbose
@@ -121,13 +163,181 @@ func quietify(makeQuiet bool) {
 	algorithms.RleQuiet = makeQuiet
 }
 
+// Configure the format written by the rle algorithm
+func configureRle(varintCounts bool, symbolWidth int) {
+	algorithms.RleVarintCounts = varintCounts
+	algorithms.RleSymbolWidth = symbolWidth
+}
+
+// Configure the LZSS window size and minimum match length
+func configureLzss(windowSize int, minMatch int) {
+	algorithms.LzssWindowSize = windowSize
+	algorithms.LzssMinMatch = minMatch
+}
+
+// Configure the compression level of the gzip, zlib and deflate algorithms
+func configureDeflate(level int) {
+	algorithms.DeflateLevel = level
+}
+
+// Configure the largest code width of the lzw algorithm
+func configureLzw(maxBits int) {
+	algorithms.LzwMaxBits = maxBits
+}
+
+// Configure the block size of the bwt algorithm
+func configureBwt(blockSize int) {
+	algorithms.BwtBlockSize = blockSize
+}
+
+// Configure the context model order of the range algorithm
+func configureRange(order int) {
+	algorithms.RangeOrder = order
+}
+
+// Configure the table log of the fse algorithm
+func configureFse(tableLog int) {
+	algorithms.FseTableLog = tableLog
+}
+
+// Configure the frames written by the lz4 algorithm
+func configureLz4(blockChecksum bool) {
+	algorithms.Lz4BlockChecksum = blockChecksum
+}
+
+// Configure the format written and read by the snappy algorithm
+func configureSnappy(raw bool) {
+	algorithms.SnappyRaw = raw
+}
+
+// Configure the delta and xor filters
+func configureFilters(deltaWidth int, deltaStride int, xorStride int) {
+	algorithms.DeltaWidth = deltaWidth
+	algorithms.DeltaStride = deltaStride
+	algorithms.XorStride = xorStride
+}
+
+// Load the preset dictionary of the lzss, zlib, lz4 and zstd algorithms from a file
+func configureDictionary(path string) error {
+	if path == "" {
+		return nil
+	}
+	dictionary, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	algorithms.Dictionary = dictionary
+	return nil
+}
+
+// Configure the model order and the memory limit of the ppm algorithm
+func configurePpm(order int, memoryMB int) {
+	algorithms.PpmOrder = order
+	algorithms.PpmMemoryMB = memoryMB
+}
+
+// Configure the largest output decoders produce from counts stored in the data
+func configureMaxDecodedSize(size int) {
+	algorithms.MaxDecodedSize = size
+}
+
+// Select the checksum stored with the compressed data
+func configureChecksum(name string) error {
+	checksumType := algorithms.GetChecksumType(name)
+	if checksumType < 0 {
+		return fmt.Errorf("unknown checksum '%s'", name)
+	}
+	algorithms.ChecksumType = checksumType
+	return nil
+}
+
+// Configure the block size of the seekable format
+func configureSeekable(blockSize int) {
+	core.SeekableBlockSize = blockSize
+}
+
+// Choose whether the name, mode and mtime of the files are recorded when compressing and restored when decompressing, like gzip's -N and -n
+func configureMetadata(name bool, noName bool, decompress bool) error {
+	if name && noName {
+		return fmt.Errorf("-name and -no-name cannot be used together")
+	}
+	if decompress {
+		core.RestoreFileMetadata = name // Not restored by default
+	} else {
+		core.SaveFileMetadata = !noName // Recorded by default
+	}
+	return nil
+}
+
+// Check that -name can be honored: of the formats written bare, only gzip has room for the name and mtime of a file
+func checkNameFlag(name bool, alg_int int, pipeline *core.Pipeline, filter_int int, raw bool, seekable bool, decompress bool) error {
+	switch {
+	case !name:
+		return nil
+	case decompress && raw:
+		return fmt.Errorf("-name cannot restore anything with -raw, whose files have no header")
+	case decompress:
+		return nil // Containers and gzip files are restored; other files are refused when they are read
+	case seekable:
+		return fmt.Errorf("-name does not work with -seekable, whose files record no name, mode or mtime")
+	case pipeline != nil || filter_int >= 0 || alg_int == algorithms.GzipAlgorithm:
+		return nil
+	case raw || algorithms.HasSignature(alg_int):
+		return fmt.Errorf("-name does not work with the %s format, which has no room for the name, mode and mtime of a file (only containers and gzip do)", algorithms.Algorithms[alg_int])
+	}
+	return nil
+}
+
 func main() {
+	// The train and archive modes have their own arguments
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "train":
+			mainTrain(os.Args[2:])
+			return
+		case "create":
+			mainCreate(os.Args[2:])
+			return
+		case "list":
+			mainList(os.Args[2:])
+			return
+		case "extract":
+			mainExtract(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command-line arguments
 	print_algs := flag.Bool("print-algorithms", false, "Print available compression algorithms and exit")
-	alg := flag.String("algorithm", "rle", "Compression algorithm to use (default: rle)")
+	alg := flag.String("algorithm", "rle", "Compression algorithm to use (default: rle), or a pipeline of algorithms joined with '+' (e.g. delta+rle+huffman)")
 	decompress := flag.Bool("decompress", false, "Decompress the input file instead of compressing it")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
 	quiet := flag.Bool("quiet", false, "Disable logging (overrides verbose)")
+	rleVarint := flag.Bool("rle-varint", false, "Store run lengths as varints in the rle algorithm, so runs longer than 255 bytes are not split")
+	rleWidth := flag.Int("rle-width", algorithms.RleSymbolWidth, "Symbol width in bytes for the rle algorithm (e.g. 2, 4 or 8 for 16, 32 or 64-bit data)")
+	lzssWindow := flag.Int("lzss-window", algorithms.LzssWindowSize, "Window size in bytes for the lzss algorithm")
+	lzssMinMatch := flag.Int("lzss-min-match", algorithms.LzssMinMatch, "Minimum match length for the lzss algorithm")
+	level := flag.Int("level", algorithms.DeflateLevel, "Compression level for the gzip, zlib and deflate algorithms (-2 to 9, -1 is the default)")
+	lzwBits := flag.Int("lzw-bits", algorithms.LzwMaxBits, "Largest code width in bits for the lzw algorithm (9 to 16)")
+	bwtBlockSize := flag.Int("bwt-block-size", algorithms.BwtBlockSize, "Block size in bytes for the bwt algorithm")
+	rangeOrder := flag.Int("range-order", algorithms.RangeOrder, "Context model order for the range algorithm (0 to 2)")
+	fseTableLog := flag.Int("fse-table-log", algorithms.FseTableLog, "Table log for the fse algorithm (5 to 16, the table has 2^log states)")
+	lz4BlockChecksum := flag.Bool("lz4-block-checksum", false, "Add a checksum to every block written by the lz4 algorithm")
+	snappyRaw := flag.Bool("snappy-raw", false, "Use the raw block format instead of the framing format for the snappy algorithm")
+	filter := flag.String("filter", "", "Filter to run the data through before compressing it (delta, xor, bcj-x86, bcj-arm64 or bcj-armthumb); decompress with the same filter")
+	deltaWidth := flag.Int("delta-width", algorithms.DeltaWidth, "Width in bytes of the little-endian values of the delta filter (1, 2, 4 or 8)")
+	deltaStride := flag.Int("delta-stride", algorithms.DeltaStride, "Distance in values between the values subtracted by the delta filter")
+	xorStride := flag.Int("xor-stride", algorithms.XorStride, "Distance in bytes between the bytes xored by the xor filter")
+	ppmOrder := flag.Int("ppm-order", algorithms.PpmOrder, "Longest context in bytes for the ppm algorithm (1 to 16)")
+	ppmMemory := flag.Int("ppm-memory", algorithms.PpmMemoryMB, "Memory limit in MB of the ppm model; the model starts over once it is full")
+	maxDecodedSize := flag.Int("max-decoded-size", algorithms.MaxDecodedSize, "Largest output in bytes decoded from counts stored in the data (e.g. rle varint runs or the length of bitrle data); data that decodes to more is rejected as corrupt")
+	checksum := flag.String("checksum", algorithms.ChecksumNames[algorithms.ChecksumType], "Checksum stored with the length of the data and verified when decompressing (crc32, crc32c or none)")
+	name := flag.Bool("name", false, "When compressing, record the name, mode and mtime of the input file (the default); when decompressing, restore them, writing the output under the recorded name in the directory of the output file")
+	noName := flag.Bool("no-name", false, "When compressing, do not record the name, mode and mtime of the input file; when decompressing, do not restore them (the default)")
+	raw := flag.Bool("raw", false, "Write and read the bare output of the algorithm, without the header that lets -decompress detect it (formats with a signature, such as gzip, are written bare anyway)")
+	seekable := flag.Bool("seekable", false, "Compress blocks of -block-size bytes independently and add an index, so ranges can be read without decompressing the whole file (decompressing detects it)")
+	blockSize := flag.Int("block-size", core.SeekableBlockSize, "Size in bytes of the uncompressed blocks of the seekable format")
+	dictionary := flag.String("dict", "", "Preset dictionary file for the lzss, zlib, lz4 and zstd algorithms (see the train mode); decompress with the same dictionary")
 	flag.Usage = usage
 	flag.Parse()
 
@@ -143,9 +353,25 @@ func main() {
 		return
 	}
 
-	// Validate the selected algorithm
+	// Without -algorithm (or -filter), decompressing detects the algorithm from the files
+	detect := *decompress && !*raw && *filter == ""
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "algorithm" {
+			detect = false
+		}
+	})
+
+	// Validate the selected pipeline or algorithm (alg_int stays -1 if it is detected)
 	alg_int := -1
-	for i := 0; i < len(algorithms.Algorithms); i++ {
+	var pipeline *core.Pipeline
+	if !detect && core.IsPipelineSpec(*alg) {
+		var err error
+		if pipeline, err = core.NewPipeline(*alg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid pipeline '%s': %v\n", *alg, err)
+			return
+		}
+	}
+	for i := 0; !detect && pipeline == nil && i < len(algorithms.Algorithms); i++ {
 		if algorithms.Algorithms[i] == *alg {
 			alg_int = i
 			break
@@ -156,6 +382,27 @@ func main() {
 		}
 	}
 
+	// Validate the selected filter
+	filter_int := -1
+	if *filter != "" {
+		filter_int = algorithms.GetAlgorithmID(*filter)
+		if filter_int < 0 || !algorithms.IsFilter(filter_int) {
+			fmt.Fprintf(os.Stderr, "Error: Unknown filter '%s'\n", *filter)
+			return
+		}
+		if pipeline != nil { // The filter becomes the first stage of the pipeline
+			pipeline.Stages = append([]int{filter_int}, pipeline.Stages...)
+		}
+	}
+	if *raw && (pipeline != nil || filter_int >= 0) {
+		fmt.Fprintf(os.Stderr, "Error: -raw only works with a single algorithm, not with pipelines or filters\n")
+		return
+	}
+	if *raw && *seekable {
+		fmt.Fprintf(os.Stderr, "Error: -raw and -seekable cannot be used together\n")
+		return
+	}
+
 	// Enable quiet logging if requested (overrides verbose)
 	if *quiet {
 		quietify(true)
@@ -164,14 +411,49 @@ func main() {
 		verbosify(*verbose)
 	}
 
+	// Apply algorithm options
+	configureRle(*rleVarint, *rleWidth)
+	configureLzss(*lzssWindow, *lzssMinMatch)
+	configureDeflate(*level)
+	configureLzw(*lzwBits)
+	configureBwt(*bwtBlockSize)
+	configureRange(*rangeOrder)
+	configureFse(*fseTableLog)
+	configureLz4(*lz4BlockChecksum)
+	configureSnappy(*snappyRaw)
+	configureFilters(*deltaWidth, *deltaStride, *xorStride)
+	configurePpm(*ppmOrder, *ppmMemory)
+	configureMaxDecodedSize(*maxDecodedSize)
+	configureSeekable(*blockSize)
+	if err := configureChecksum(*checksum); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := configureMetadata(*name, *noName, *decompress); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := checkNameFlag(*name, alg_int, pipeline, filter_int, *raw, *seekable, *decompress); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if err := configureDictionary(*dictionary); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	if *dictionary != "" && pipeline == nil && alg_int >= 0 && !algorithms.SupportsDictionary(alg_int) {
+		fmt.Fprintf(os.Stderr, "Error: The %s algorithm does not support dictionaries\n", *alg)
+		return
+	}
+
 	// Create a new WaitGroup to manage goroutines
 	wg := &sync.WaitGroup{}
 
 	if !*decompress {
 		// Compress the files
-		mainCompress(alg_int, wg)
+		mainCompress(alg_int, filter_int, pipeline, *raw, *seekable, wg)
 	} else {
 		// Decompress the files
-		mainDecompress(alg_int, wg)
+		mainDecompress(alg_int, filter_int, pipeline, *raw, wg)
 	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// Main function of the train mode, which builds a preset dictionary from sample files.
+func mainTrain(args []string) {
+	trainFlags := flag.NewFlagSet("train", flag.ExitOnError)
+	size := trainFlags.Int("dict-size", algorithms.DefaultDictionarySize, "Largest size in bytes of the dictionary")
+	lines := trainFlags.Bool("lines", false, "Use every line of the sample files as a sample (e.g. for JSON Lines files) instead of every file")
+	verbose := trainFlags.Bool("verbose", false, "Enable verbose logging")
+	trainFlags.Usage = func() {
+		fmt.Println("Usage: go run main.go train [train options] <dictionary_file> <sample_file> [sample_file2] ...")
+		fmt.Println("Train options:")
+		trainFlags.PrintDefaults()
+	}
+	trainFlags.Parse(args)
+
+	if trainFlags.NArg() < 2 {
+		trainFlags.Usage()
+		return
+	}
+	verbosify(*verbose)
+
+	// Read the samples
+	var samples [][]byte
+	for _, path := range trainFlags.Args()[1:] {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to read sample file \"%s\": %v\n", path, err)
+			return
+		}
+		if !*lines {
+			samples = append(samples, content)
+			continue
+		}
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			if len(line) > 0 {
+				samples = append(samples, line)
+			}
+		}
+	}
+
+	dictionary, err := algorithms.TrainDictionary(samples, *size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to train dictionary: %v\n", err)
+		return
+	}
+	output := trainFlags.Arg(0)
+	if err := os.WriteFile(output, dictionary, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to write dictionary: %v\n", err)
+		return
+	}
+	fmt.Printf("Wrote a %d-byte dictionary with ID %08x, trained on %d samples, to \"%s\"\n", len(dictionary), algorithms.DictionaryID(dictionary), len(samples), output)
+}
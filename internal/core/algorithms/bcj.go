@@ -0,0 +1,212 @@
+package algorithms
+
+import "encoding/binary"
+
+// BCJ (branch/call/jump) filters rewrite the targets of relative branch instructions in machine code as absolute
+// addresses. Calls to the same function from different places then become identical bytes, which the LZ algorithms
+// can match. The conversions are the same as the BCJ filters of xz, so `start` is the address of the first byte
+// (xz's start offset, usually 0).
+
+// Architectures of the BCJ filters
+const (
+	BcjX86      = iota // x86 and x86-64 CALL and JMP (E8/E9 with a 32-bit displacement)
+	BcjARM64           // ARM64 BL and ADRP
+	BcjARMThumb        // ARM Thumb BL (two 16-bit halves)
+)
+
+// IsBcjFilter reports whether the algorithm is one of the BCJ filters.
+func IsBcjFilter(alg int) bool {
+	return alg == BCJX86Algorithm || alg == BCJARM64Algorithm || alg == BCJARMThumbAlgorithm
+}
+
+// BcjFilter converts the branch targets in `data` for `arch` (BcjX86, BcjARM64 or BcjARMThumb) from relative to absolute.
+func BcjFilter(data []byte, arch int, start uint32) []byte {
+	return bcjConvert(data, arch, start, true)
+}
+
+// BcjUnfilter undoes BcjFilter with the same architecture and start address.
+func BcjUnfilter(data []byte, arch int, start uint32) []byte {
+	return bcjConvert(data, arch, start, false)
+}
+
+// bcjConvert runs the filter of `arch` over a copy of `data`, in the direction given by `encode`.
+func bcjConvert(data []byte, arch int, start uint32, encode bool) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+	switch arch {
+	case BcjX86:
+		bcjX86(out, start, encode)
+	case BcjARM64:
+		bcjARM64(out, start, encode)
+	case BcjARMThumb:
+		bcjARMThumb(out, start, encode)
+	}
+	return out
+}
+
+// bcjX86Byte reports whether `b` is 0x00 or 0xFF, the top byte of a displacement that stays within 16 MB.
+func bcjX86Byte(b byte) bool {
+	return b == 0x00 || b == 0xFF
+}
+
+// bcjX86 converts the displacements of E8 (CALL) and E9 (JMP) opcodes in place. The mask of recent E8/E9 bytes that
+// were not converted keeps the filter from converting bytes that are more likely part of another instruction.
+func bcjX86(data []byte, start uint32, encode bool) {
+	allowed := [8]bool{true, true, true, false, true, false, false, false}
+	maskToBit := [8]uint32{0, 1, 2, 2, 3, 3, 3, 3}
+
+	prevMask := uint32(0)
+	prevPos := start - 5
+	for i := 0; i+5 <= len(data); {
+		if data[i] != 0xE8 && data[i] != 0xE9 {
+			i++
+			continue
+		}
+
+		pos := start + uint32(i)
+		if offset := pos - prevPos; offset > 5 {
+			prevMask = 0
+		} else {
+			for k := uint32(0); k < offset; k++ {
+				prevMask = prevMask & 0x77 << 1
+			}
+		}
+		prevPos = pos
+
+		b := data[i+4]
+		if !bcjX86Byte(b) || !allowed[prevMask>>1&7] || prevMask>>1 >= 0x10 {
+			i++
+			prevMask |= 1
+			if bcjX86Byte(b) {
+				prevMask |= 0x10
+			}
+			continue
+		}
+
+		src := binary.LittleEndian.Uint32(data[i+1:])
+		var dest uint32
+		for {
+			if encode {
+				dest = src + pos + 5
+			} else {
+				dest = src - (pos + 5)
+			}
+			if prevMask == 0 {
+				break
+			}
+			bit := maskToBit[prevMask>>1]
+			if !bcjX86Byte(byte(dest >> (24 - bit*8))) {
+				break
+			}
+			src = dest ^ (1<<(32-bit*8) - 1)
+		}
+
+		// The top byte is rebuilt from bit 24, so it stays 0x00 or 0xFF
+		binary.LittleEndian.PutUint32(data[i+1:], dest&0x00FFFFFF|(0-(dest>>24&1))<<24)
+		i += 5
+		prevMask = 0
+	}
+}
+
+// bcjARM64 converts the targets of BL instructions and the pages of ADRP instructions in place.
+func bcjARM64(data []byte, start uint32, encode bool) {
+	for i := 0; i+4 <= len(data); i += 4 {
+		pc := start + uint32(i)
+		instr := binary.LittleEndian.Uint32(data[i:])
+
+		if instr>>26 == 0x25 { // BL, with a 26-bit offset in words
+			pc >>= 2
+			if !encode {
+				pc = -pc
+			}
+			binary.LittleEndian.PutUint32(data[i:], 0x94000000|(instr+pc)&0x03FFFFFF)
+		} else if instr&0x9F000000 == 0x90000000 { // ADRP, with a 21-bit offset in 4 KB pages
+			src := instr>>29&3 | instr>>3&0x001FFFFC
+			if (src+0x00020000)&0x001C0000 != 0 { // Only offsets within +-512 MB are converted
+				continue
+			}
+			pc >>= 12
+			if !encode {
+				pc = -pc
+			}
+			dest := src + pc
+			instr &= 0x9000001F
+			instr |= (dest & 3) << 29
+			instr |= (dest & 0x0003FFFC) << 3
+			instr |= -(dest & 0x00020000) & 0x00E00000
+			binary.LittleEndian.PutUint32(data[i:], instr)
+		}
+	}
+}
+
+// bcjARMThumb converts the targets of Thumb BL instruction pairs in place.
+func bcjARMThumb(data []byte, start uint32, encode bool) {
+	for i := 0; i+4 <= len(data); i += 2 {
+		if data[i+1]&0xF8 != 0xF0 || data[i+3]&0xF8 != 0xF8 {
+			continue
+		}
+		src := (uint32(data[i+1])&7<<19 | uint32(data[i])<<11 | uint32(data[i+3])&7<<8 | uint32(data[i+2])) << 1
+
+		var dest uint32
+		if encode {
+			dest = start + uint32(i) + 4 + src
+		} else {
+			dest = src - (start + uint32(i) + 4)
+		}
+		dest >>= 1
+		data[i+1] = 0xF0 | byte(dest>>19&7)
+		data[i] = byte(dest >> 11)
+		data[i+3] = 0xF8 | byte(dest>>8&7)
+		data[i+2] = byte(dest)
+		i += 2
+	}
+}
+
+// --- // BCJ Compressor Interface
+
+// BCJCompressor implements core.GeneralCompressor and core.GeneralDecompressor with a BCJ filter.
+// The output has the same size as the input, without a header, and assumes the code starts at address 0.
+type BCJCompressor struct {
+	Arch int // BcjX86, BcjARM64 or BcjARMThumb
+}
+
+// Compress implements core.Compressor.
+func (b *BCJCompressor) Compress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	return BcjFilter(data, b.Arch, 0), nil
+}
+
+// Decompress implements core.Decompressor.
+func (b *BCJCompressor) Decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	return BcjUnfilter(data, b.Arch, 0), nil
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (b *BCJCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BcjCompressFile", inputFilePath, outputFilePath, b.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (b *BCJCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BcjDecompressFile", inputFilePath, outputFilePath, b.Decompress)
+}
+
+// Factory function for creating an x86 BCJ filter instance.
+func NewBCJX86Compressor() *BCJCompressor {
+	return &BCJCompressor{Arch: BcjX86}
+}
+
+// Factory function for creating an ARM64 BCJ filter instance.
+func NewBCJARM64Compressor() *BCJCompressor {
+	return &BCJCompressor{Arch: BcjARM64}
+}
+
+// Factory function for creating an ARM Thumb BCJ filter instance.
+func NewBCJARMThumbCompressor() *BCJCompressor {
+	return &BCJCompressor{Arch: BcjARMThumb}
+}
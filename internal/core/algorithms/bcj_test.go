@@ -0,0 +1,147 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/bcj are 16 KB of machine code: x86.bin and arm64.bin from the text section of a small Go program
+// built for amd64 and arm64, armthumb.bin made of random Thumb halfwords with BL pairs between them. The matching
+// testdata/xz/bcj_*.xz files were made with `xz --x86 --lzma2` (and --x86=start=4096, --arm64, --armthumb).
+
+func TestBcjConversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		arch     int
+		start    uint32
+		input    []byte
+		expected []byte
+	} {
+		{name: "x86 call", arch: BcjX86, input: []byte{0xE8, 0, 0, 0, 0}, expected: []byte{0xE8, 5, 0, 0, 0}},
+		{name: "x86 call with a start address", arch: BcjX86, start: 0x1000, input: []byte{0xE8, 0, 0, 0, 0}, expected: []byte{0xE8, 0x05, 0x10, 0, 0}},
+		{name: "x86 backward jump", arch: BcjX86, input: []byte{0x90, 0x90, 0xE9, 0xFB, 0xFF, 0xFF, 0xFF}, expected: []byte{0x90, 0x90, 0xE9, 0x02, 0, 0, 0}},
+		{name: "x86 far displacement", arch: BcjX86, input: []byte{0xE8, 0, 0, 0, 0x12}, expected: []byte{0xE8, 0, 0, 0, 0x12}},
+		{name: "x86 short tail", arch: BcjX86, input: []byte{0x90, 0xE8, 0, 0, 0}, expected: []byte{0x90, 0xE8, 0, 0, 0}},
+		{name: "ARM64 BL", arch: BcjARM64, input: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x01, 0, 0, 0x94}, expected: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x03, 0, 0, 0x94}},
+		{name: "ARM64 ADRP", arch: BcjARM64, start: 0x3000, input: []byte{0x00, 0, 0, 0x90}, expected: []byte{0x00, 0, 0, 0xF0}},
+		{name: "ARM Thumb BL", arch: BcjARMThumb, input: []byte{0x00, 0xF0, 0x01, 0xF8}, expected: []byte{0x00, 0xF0, 0x03, 0xF8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BcjFilter(tt.input, tt.arch, tt.start)
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("BcjFilter(% X) = % X, want % X", tt.input, got, tt.expected)
+			}
+			if restored := BcjUnfilter(got, tt.arch, tt.start); !bytes.Equal(restored, tt.input) {
+				t.Errorf("BcjUnfilter(% X) = % X, want % X", got, restored, tt.input)
+			}
+		})
+	}
+}
+
+func TestXzDecodeFilterChains(t *testing.T) {
+	samples := make([]byte, 4*8000) // The input of delta.xz, made with `xz --delta=dist=4 --lzma2`
+	for i := 0; i < 8000; i++ {
+		binary.LittleEndian.PutUint32(samples[4*i:], uint32(100000+i*i%5000))
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	} {
+		{file: "bcj_x86.xz", expected: "x86.bin"},
+		{file: "bcj_x86_start.xz", expected: "x86.bin"},
+		{file: "bcj_arm64.xz", expected: "arm64.bin"},
+		{file: "bcj_armthumb.xz", expected: "armthumb.bin"},
+		{file: "delta.xz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "xz", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			expected := samples
+			if tt.expected != "" {
+				if expected, err = os.ReadFile(filepath.Join("testdata", "bcj", tt.expected)); err != nil {
+					t.Fatalf("failed to read fixture: %v", err)
+				}
+			}
+
+			got, err := XzDecode(data)
+			if err != nil {
+				t.Fatalf("XzDecode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("XzDecode returned %d bytes that differ from the %d expected", len(got), len(expected))
+			}
+		})
+	}
+}
+
+func TestBcjRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(4))
+	noise := make([]byte, 50001)
+	random.Read(noise)
+
+	compressors := []struct {
+		name    string
+		fixture string
+		bcj     *BCJCompressor
+	} {
+		{name: "x86", fixture: "x86.bin", bcj: NewBCJX86Compressor()},
+		{name: "ARM64", fixture: "arm64.bin", bcj: NewBCJARM64Compressor()},
+		{name: "ARM Thumb", fixture: "armthumb.bin", bcj: NewBCJARMThumbCompressor()},
+	}
+
+	for _, tt := range compressors {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := os.ReadFile(filepath.Join("testdata", "bcj", tt.fixture))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			for _, input := range [][]byte{nil, {0xE8}, code, noise} {
+				filtered, err := tt.bcj.Compress(input)
+				if err != nil {
+					t.Fatalf("Compress returned unexpected error: %v", err)
+				}
+				if len(filtered) != len(input) {
+					t.Errorf("Compress changed the size from %d to %d bytes", len(input), len(filtered))
+				}
+				got, err := tt.bcj.Decompress(filtered)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, input) {
+					t.Errorf("Decompress(Compress(x)) returned different data for %d bytes", len(input))
+				}
+			}
+		})
+	}
+}
+
+func TestBcjHelpsLz(t *testing.T) {
+	for arch, file := range map[int]string{BcjX86: "x86.bin", BcjARM64: "arm64.bin"} {
+		code, err := os.ReadFile(filepath.Join("testdata", "bcj", file))
+		if err != nil {
+			t.Fatalf("failed to read fixture: %v", err)
+		}
+		plain, err := Xz(code)
+		if err != nil {
+			t.Fatalf("Xz returned unexpected error: %v", err)
+		}
+		filtered, err := Xz(BcjFilter(code, arch, 0))
+		if err != nil {
+			t.Fatalf("Xz returned unexpected error: %v", err)
+		}
+		if len(filtered) >= len(plain) {
+			t.Errorf("%s: Xz gave %d bytes with the BCJ filter and %d without, expected fewer with it", file, len(filtered), len(plain))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package algorithms
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Error returned when a bit reader runs past the end of its input
+var errBitReaderEOF = errors.New("unexpected end of bit stream")
+
+// --- // Bit Writer (MSB-first)
+
+// bitWriter packs bits into bytes, most significant bit first.
+type bitWriter struct {
+	buffer bytes.Buffer
+	acc    uint64 // Pending bits, right-aligned
+	nbits  uint   // Number of pending bits in acc
+}
+
+// writeBits writes the lowest `n` bits of `value` (n <= 32), most significant bit first.
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	w.acc = w.acc<<n | uint64(value)&(1<<n-1)
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.nbits -= 8
+		w.buffer.WriteByte(byte(w.acc >> w.nbits))
+	}
+}
+
+// writeBit writes a single bit.
+func (w *bitWriter) writeBit(bit bool) {
+	if bit {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// bytes flushes the pending bits (padding with zeros) and returns the written data.
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buffer.WriteByte(byte(w.acc << (8 - w.nbits)))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buffer.Bytes()
+}
+
+// --- // Bit Reader (MSB-first)
+
+// bitReader reads bits written by bitWriter.
+type bitReader struct {
+	data []byte
+	pos  int  // Index of the current byte
+	bit  uint // Number of bits already consumed from the current byte
+}
+
+// newBitReader creates a bitReader over `data`.
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+// readBit reads a single bit.
+func (r *bitReader) readBit() (uint32, error) {
+	if r.pos >= len(r.data) {
+		return 0, errBitReaderEOF
+	}
+	b := uint32(r.data[r.pos]>>(7-r.bit)) & 1
+	r.bit++
+	if r.bit == 8 {
+		r.bit = 0
+		r.pos++
+	}
+	return b, nil
+}
+
+// readBits reads `n` bits (n <= 32), most significant bit first.
+func (r *bitReader) readBits(n uint) (uint32, error) {
+	var value uint32
+	for i := uint(0); i < n; i++ {
+		b, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		value = value<<1 | b
+	}
+	return value, nil
+}
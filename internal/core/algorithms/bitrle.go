@@ -0,0 +1,188 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// --- // Elias-Gamma Codes
+
+// writeEliasGamma writes n (n >= 1) as floor(log2 n) zero bits followed by n in binary.
+func writeEliasGamma(w *bitWriter, n uint64) {
+	width := uint(bits.Len64(n))
+	for i := uint(1); i < width; i++ {
+		w.writeBit(false)
+	}
+	for width > 32 {
+		width -= 32
+		w.writeBits(uint32(n>>width), 32)
+	}
+	w.writeBits(uint32(n), width)
+}
+
+// readEliasGamma reads a number written by writeEliasGamma.
+func readEliasGamma(r *bitReader) (uint64, error) {
+	zeros := uint(0)
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+		zeros++
+		if zeros > 63 {
+			return 0, fmt.Errorf("Elias-gamma code is too long")
+		}
+	}
+
+	n := uint64(1)
+	for i := uint(0); i < zeros; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<1 | uint64(bit)
+	}
+	return n, nil
+}
+
+// --- // Bit-Level RLE Encoding
+
+// BitRle encodes data as alternating runs of 0 and 1 bits (most significant bit of each byte first), returning a byte slice.
+// The output starts with the uncompressed length in bytes (as a uvarint), followed by a bit stream holding the value of
+// the first bit and then the Elias-gamma code of every run length.
+func BitRle(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	buffer.Write(header[:binary.PutUvarint(header[:], uint64(len(data)))])
+
+	w := &bitWriter{}
+	current := data[0]>>7 == 1
+	w.writeBit(current)
+
+	run := uint64(0)
+	for _, b := range data {
+		// Whole bytes of the current bit value extend the run at once
+		if (current && b == 0xFF) || (!current && b == 0x00) {
+			run += 8
+			continue
+		}
+		for i := 7; i >= 0; i-- {
+			bit := (b>>i)&1 == 1
+			if bit != current {
+				writeEliasGamma(w, run)
+				current, run = bit, 0
+			}
+			run++
+		}
+	}
+	writeEliasGamma(w, run)
+
+	buffer.Write(w.bytes())
+	return buffer.Bytes(), nil
+}
+
+// --- // Bit-Level RLE Decoding
+
+// BitRleDecode decodes data produced by BitRle.
+func BitRleDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("malformed bit RLE data: invalid length header")
+	}
+	if size > uint64(MaxDecodedSize) {
+		return nil, fmt.Errorf("malformed bit RLE data: length %d exceeds the decoded size limit of %d bytes", size, MaxDecodedSize)
+	}
+	r := newBitReader(data[n:])
+
+	first, err := r.readBit()
+	if err != nil {
+		return nil, fmt.Errorf("malformed bit RLE data: %w", err)
+	}
+	current := first == 1
+
+	var buffer bytes.Buffer
+	totalBits := size * 8
+	acc, accBits := byte(0), 0 // Partial output byte
+	for written := uint64(0); written < totalBits; {
+		run, err := readEliasGamma(r)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bit RLE data: %w", err)
+		}
+		if run > totalBits-written {
+			return nil, fmt.Errorf("malformed bit RLE data: run of %d bits runs past the end of the data", run)
+		}
+		written += run
+
+		fill := byte(0x00)
+		if current {
+			fill = 0xFF
+		}
+		for ; run > 0 && accBits > 0; run-- { // Finish the partial byte
+			acc = acc<<1 | fill&1
+			accBits++
+			if accBits == 8 {
+				buffer.WriteByte(acc)
+				acc, accBits = 0, 0
+			}
+		}
+		for ; run >= 8; run -= 8 { // Whole bytes
+			buffer.WriteByte(fill)
+		}
+		for ; run > 0; run-- { // Start a new partial byte
+			acc = acc<<1 | fill&1
+			accBits++
+		}
+
+		current = !current
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// --- // Bit-Level RLE Compressor Interface
+
+// BitRLECompressor implements core.GeneralCompressor and core.GeneralDecompressor with bit-level run-length encoding.
+type BitRLECompressor struct{}
+
+// Compress implements core.Compressor.
+func (b *BitRLECompressor) Compress(data []byte) ([]byte, error) {
+	return BitRle(data)
+}
+
+// Decompress implements core.Decompressor.
+func (b *BitRLECompressor) Decompress(data []byte) ([]byte, error) {
+	return BitRleDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (b *BitRLECompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BitRleCompressFile", inputFilePath, outputFilePath, b.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (b *BitRLECompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BitRleDecompressFile", inputFilePath, outputFilePath, b.Decompress)
+}
+
+// Factory function for creating a bit-level RLE compressor instance.
+func NewBitRLECompressor() *BitRLECompressor {
+	return &BitRLECompressor{}
+}
+
+// Factory function for creating a bit-level RLE decompressor instance.
+func NewBitRLEDecompressor() *BitRLECompressor {
+	return &BitRLECompressor{}
+}
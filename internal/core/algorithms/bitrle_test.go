@@ -0,0 +1,128 @@
+package algorithms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBitRle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	} {
+		{
+			name:     "Empty input",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			// First bit 0, then runs 4 (00100) and 4 (00100)
+			name:     "Nibbles",
+			input:    []byte{0x0F},
+			expected: []byte{1, 0b00010000, 0b10000000},
+		},
+		{
+			// First bit 1, then one run of 16 (000010000)
+			name:     "All ones",
+			input:    []byte{0xFF, 0xFF},
+			expected: []byte{2, 0b10000100, 0b00000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BitRle(tt.input)
+			if err != nil {
+				t.Fatalf("BitRle returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("BitRle(%08b) = %08b, want %08b", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBitRleRoundTrip(t *testing.T) {
+	sparse := make([]byte, 1<<20)
+	sparse[1000] = 0x10
+	sparse[500000] = 0xF0
+	sparse[len(sparse)-1] = 0x01
+
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Single byte",
+			input: []byte{0xA5},
+		},
+		{
+			name:  "Alternating bits",
+			input: bytes.Repeat([]byte{0x55}, 100),
+		},
+		{
+			name:  "Monochrome mask",
+			input: []byte{0x00, 0x00, 0x3F, 0xFF, 0xFF, 0xC0, 0x00, 0x00, 0x07, 0xFF},
+		},
+		{
+			name:  "Sparse bitset",
+			input: sparse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := BitRle(tt.input)
+			if err != nil {
+				t.Fatalf("BitRle returned unexpected error: %v", err)
+			}
+
+			got, err := BitRleDecode(compressed)
+			if err != nil {
+				t.Fatalf("BitRleDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("BitRleDecode(BitRle(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+
+	compressed, _ := BitRle(sparse)
+	if len(compressed) > 32 {
+		t.Errorf("BitRle of a sparse bitset is %d bytes, expected at most 32", len(compressed))
+	}
+}
+
+func TestBitRleDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Missing bit stream",
+			input: []byte{1},
+		},
+		{
+			name:  "Run past the end",
+			input: []byte{1, 0b00000100, 0b10000000}, // One byte, but a run of 16 bits
+		},
+		{
+			name:  "Truncated runs",
+			input: []byte{2, 0b00010000},
+		},
+		{
+			name:  "Length beyond the decoded size limit",
+			input: []byte{0x80, 0x80, 0x80, 0x80, 0x40, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00}, // 2^34 bytes, one run of 2^37 bits
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BitRleDecode(tt.input); err == nil {
+				t.Errorf("BitRleDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Block size used by NewBWTCompressor (and therefore by the factories in core)
+var BwtBlockSize = 900000
+
+const (
+	bwtMaxBlockSize = 1 << 24 // Largest accepted block size
+	bwtRunA         = 0       // Zero-run digit worth 1 << position
+	bwtRunB         = 1       // Zero-run digit worth 2 << position
+	bwtEndOfBlock   = 257     // Symbol ending a block
+	bwtAlphabetSize = 258     // RUNA, RUNB, move-to-front values 1 to 255 (shifted by one) and the end of block
+)
+
+// --- // Burrows-Wheeler Transform
+
+// BwtTransform sorts all rotations of data and returns their last column, plus the index of the original rotation.
+func BwtTransform(data []byte) ([]byte, int) {
+	n := len(data)
+	if n == 0 {
+		return nil, 0
+	}
+
+	// Sort the cyclic rotations by prefix doubling, using a counting sort in every round
+	order := make([]int, n)
+	rank := make([]int, n)
+	counts := make([]int, n+256) // Large enough for both the 256 byte values and the n equivalence classes
+	for _, b := range data {
+		counts[b]++
+	}
+	for i := 1; i < 256; i++ {
+		counts[i] += counts[i-1]
+	}
+	for i := n - 1; i >= 0; i-- {
+		counts[data[i]]--
+		order[counts[data[i]]] = i
+	}
+	classes := 1
+	rank[order[0]] = 0
+	for i := 1; i < n; i++ {
+		if data[order[i]] != data[order[i-1]] {
+			classes++
+		}
+		rank[order[i]] = classes - 1
+	}
+
+	shifted := make([]int, n)
+	newRank := make([]int, n)
+	for k := 1; k < n && classes < n; k <<= 1 {
+		// Rotations sorted by their second half, i.e. by the rotation starting k later
+		for i := 0; i < n; i++ {
+			shifted[i] = order[i] - k
+			if shifted[i] < 0 {
+				shifted[i] += n
+			}
+		}
+		// Stable counting sort by the first half
+		for i := 0; i < classes; i++ {
+			counts[i] = 0
+		}
+		for i := 0; i < n; i++ {
+			counts[rank[shifted[i]]]++
+		}
+		for i := 1; i < classes; i++ {
+			counts[i] += counts[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			counts[rank[shifted[i]]]--
+			order[counts[rank[shifted[i]]]] = shifted[i]
+		}
+		// Recompute the equivalence classes
+		newRank[order[0]] = 0
+		classes = 1
+		for i := 1; i < n; i++ {
+			current := [2]int{rank[order[i]], rank[(order[i]+k)%n]}
+			previous := [2]int{rank[order[i-1]], rank[(order[i-1]+k)%n]}
+			if current != previous {
+				classes++
+			}
+			newRank[order[i]] = classes - 1
+		}
+		rank, newRank = newRank, rank
+	}
+
+	last := make([]byte, n)
+	primary := 0
+	for i, start := range order {
+		if start == 0 {
+			primary = i
+			last[i] = data[n-1]
+		} else {
+			last[i] = data[start-1]
+		}
+	}
+	return last, primary
+}
+
+// BwtInverse undoes BwtTransform.
+func BwtInverse(last []byte, primary int) ([]byte, error) {
+	n := len(last)
+	if n == 0 {
+		return nil, nil
+	}
+	if primary < 0 || primary >= n {
+		return nil, fmt.Errorf("malformed BWT data: primary index %d out of range", primary)
+	}
+
+	// next[i] is the row whose rotation starts one byte after the rotation of row i
+	var counts [256]int
+	for _, b := range last {
+		counts[b]++
+	}
+	var starts [256]int
+	for i := 1; i < 256; i++ {
+		starts[i] = starts[i-1] + counts[i-1]
+	}
+	next := make([]int, n)
+	for i, b := range last {
+		next[starts[b]] = i
+		starts[b]++
+	}
+
+	output := make([]byte, n)
+	row := next[primary]
+	for i := 0; i < n; i++ {
+		output[i] = last[row]
+		row = next[row]
+	}
+	return output, nil
+}
+
+// --- // Move-To-Front
+
+// MtfEncode replaces every byte with its position in a list of recently used bytes, so repeated bytes turn into zeros.
+func MtfEncode(data []byte) []byte {
+	var list [256]byte
+	for i := range list {
+		list[i] = byte(i)
+	}
+
+	output := make([]byte, len(data))
+	for i, b := range data {
+		j := 0
+		for list[j] != b {
+			j++
+		}
+		output[i] = byte(j)
+		copy(list[1:j+1], list[:j])
+		list[0] = b
+	}
+	return output
+}
+
+// MtfDecode undoes MtfEncode.
+func MtfDecode(data []byte) []byte {
+	var list [256]byte
+	for i := range list {
+		list[i] = byte(i)
+	}
+
+	output := make([]byte, len(data))
+	for i, j := range data {
+		b := list[j]
+		output[i] = b
+		copy(list[1:int(j)+1], list[:j])
+		list[0] = b
+	}
+	return output
+}
+
+// --- // Zero-Run Encoding
+
+// bwtZeroRunEncode turns move-to-front output into symbols: runs of zeros become bijective base-2 RUNA/RUNB digits
+// (like `Rle` stores a count instead of repeating a byte), other values are shifted up by one, and an end of block is appended.
+func bwtZeroRunEncode(data []byte) []int {
+	symbols := make([]int, 0, len(data)+1)
+	run := 0
+
+	flushRun := func() {
+		if run == 0 {
+			return
+		}
+		run--
+		for {
+			if run&1 == 1 {
+				symbols = append(symbols, bwtRunB)
+			} else {
+				symbols = append(symbols, bwtRunA)
+			}
+			if run < 2 {
+				break
+			}
+			run = (run - 2) >> 1
+		}
+		run = 0
+	}
+
+	for _, b := range data {
+		if b == 0 {
+			run++
+			continue
+		}
+		flushRun()
+		symbols = append(symbols, int(b)+1)
+	}
+	flushRun()
+
+	return append(symbols, bwtEndOfBlock)
+}
+
+// --- // BWT Encoding
+
+// Bwt compresses data in independent blocks of `blockSize` bytes with the Burrows-Wheeler transform, move-to-front,
+// zero-run encoding and Huffman coding, in the spirit of bzip2.
+// Every block starts with its length and primary index (as uvarints) and the packed 4-bit code lengths of its Huffman table.
+func Bwt(data []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 || blockSize > bwtMaxBlockSize {
+		return nil, fmt.Errorf("invalid BWT block size %d (must be between 1 and %d)", blockSize, bwtMaxBlockSize)
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+	for start := 0; start < len(data); start += blockSize {
+		end := start + blockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		block := data[start:end]
+
+		last, primary := BwtTransform(block)
+		symbols := bwtZeroRunEncode(MtfEncode(last))
+		verbosePrintf("Bwt: block at %v: len(block): %v, symbols: %v\n", start, len(block), len(symbols))
+
+		freqs := make([]int, bwtAlphabetSize)
+		for _, symbol := range symbols {
+			freqs[symbol]++
+		}
+		lengths := huffmanCodeLengths(freqs, huffmanMaxCodeLength)
+		codes := huffmanCanonicalCodes(lengths)
+
+		buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(len(block)))])
+		buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(primary))])
+		for i := 0; i < bwtAlphabetSize; i += 2 {
+			buffer.WriteByte(lengths[i]<<4 | lengths[i+1])
+		}
+
+		w := &bitWriter{}
+		for _, symbol := range symbols {
+			w.writeBits(codes[symbol], uint(lengths[symbol]))
+		}
+		buffer.Write(w.bytes())
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// --- // BWT Decoding
+
+// BwtDecode decodes data produced by Bwt.
+func BwtDecode(data []byte) ([]byte, error) {
+	var output bytes.Buffer
+
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > bwtMaxBlockSize {
+			return nil, fmt.Errorf("malformed BWT data: invalid block length")
+		}
+		data = data[n:]
+		primary, n := binary.Uvarint(data)
+		if n <= 0 || (size > 0 && primary >= size) {
+			return nil, fmt.Errorf("malformed BWT data: invalid primary index")
+		}
+		data = data[n:]
+
+		if len(data) < bwtAlphabetSize/2 {
+			return nil, fmt.Errorf("malformed BWT data: truncated code length table")
+		}
+		lengths := make([]uint8, bwtAlphabetSize)
+		for i := 0; i < bwtAlphabetSize; i += 2 {
+			lengths[i] = data[i/2] >> 4
+			lengths[i+1] = data[i/2] & 0x0F
+		}
+		data = data[bwtAlphabetSize/2:]
+
+		decoder, err := newHuffmanDecoder(lengths)
+		if err != nil {
+			return nil, err
+		}
+
+		// Undo the Huffman coding and the zero-run encoding
+		mtf := make([]byte, 0, size)
+		r := newBitReader(data)
+		run, weight := 0, 1
+		for {
+			symbol, err := decoder.decode(r)
+			if err != nil {
+				return nil, fmt.Errorf("malformed BWT data: %w", err)
+			}
+
+			if symbol == bwtRunA || symbol == bwtRunB {
+				run += weight << symbol // RUNA adds the weight, RUNB adds twice the weight
+				weight <<= 1
+				if run > int(size) {
+					return nil, fmt.Errorf("malformed BWT data: zero run longer than the block")
+				}
+				continue
+			}
+			for ; run > 0; run-- {
+				mtf = append(mtf, 0)
+			}
+			weight = 1
+
+			if symbol == bwtEndOfBlock {
+				break
+			}
+			if len(mtf) >= int(size) {
+				return nil, fmt.Errorf("malformed BWT data: block longer than its length")
+			}
+			mtf = append(mtf, byte(symbol-1))
+		}
+		if len(mtf) != int(size) {
+			return nil, fmt.Errorf("malformed BWT data: block has %d bytes, expected %d", len(mtf), size)
+		}
+
+		// The next block starts at the next whole byte
+		consumed := r.pos
+		if r.bit > 0 {
+			consumed++
+		}
+		data = data[consumed:]
+
+		block, err := BwtInverse(MtfDecode(mtf), int(primary))
+		if err != nil {
+			return nil, err
+		}
+		output.Write(block)
+	}
+
+	if output.Len() == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	return output.Bytes(), nil
+}
+
+// --- // BWT Compressor Interface
+
+// BWTCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the block-sorting pipeline.
+type BWTCompressor struct {
+	BlockSize int // Number of bytes sorted together
+}
+
+// Compress implements core.Compressor.
+func (b *BWTCompressor) Compress(data []byte) ([]byte, error) {
+	return Bwt(data, b.BlockSize)
+}
+
+// Decompress implements core.Decompressor.
+func (b *BWTCompressor) Decompress(data []byte) ([]byte, error) {
+	return BwtDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (b *BWTCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BwtCompressFile", inputFilePath, outputFilePath, b.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (b *BWTCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("BwtDecompressFile", inputFilePath, outputFilePath, b.Decompress)
+}
+
+// Factory function for creating a BWT compressor instance using BwtBlockSize.
+func NewBWTCompressor() *BWTCompressor {
+	return NewBWTCompressorWithBlockSize(BwtBlockSize)
+}
+
+// Factory function for creating a BWT compressor instance with a custom block size.
+func NewBWTCompressorWithBlockSize(blockSize int) *BWTCompressor {
+	return &BWTCompressor{BlockSize: blockSize}
+}
+
+// Factory function for creating a BWT decompressor instance.
+func NewBWTDecompressor() *BWTCompressor {
+	return NewBWTCompressor()
+}
@@ -0,0 +1,138 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBwtTransform(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expected        string
+		expectedPrimary int // -1 when several rotations are equal, so any of them may be the primary one
+	} {
+		{
+			name:            "banana",
+			input:           "banana",
+			expected:        "nnbaaa",
+			expectedPrimary: 3,
+		},
+		{
+			name:            "Periodic input",
+			input:           "abab",
+			expected:        "bbaa",
+			expectedPrimary: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, primary := BwtTransform([]byte(tt.input))
+			if string(got) != tt.expected || (tt.expectedPrimary >= 0 && primary != tt.expectedPrimary) {
+				t.Errorf("BwtTransform(%q) = %q, %d, want %q, %d", tt.input, got, primary, tt.expected, tt.expectedPrimary)
+			}
+
+			original, err := BwtInverse(got, primary)
+			if err != nil {
+				t.Fatalf("BwtInverse returned unexpected error: %v", err)
+			}
+			if string(original) != tt.input {
+				t.Errorf("BwtInverse(%q, %d) = %q, want %q", got, primary, original, tt.input)
+			}
+		})
+	}
+}
+
+func TestMtf(t *testing.T) {
+	input := []byte("bananaaa")
+	encoded := MtfEncode(input)
+	expected := []byte{'b', 'b', 'n', 1, 1, 1, 0, 0}
+	if !bytes.Equal(encoded, expected) {
+		t.Errorf("MtfEncode(%q) = %v, want %v", input, encoded, expected)
+	}
+	if decoded := MtfDecode(encoded); !bytes.Equal(decoded, input) {
+		t.Errorf("MtfDecode(%v) = %q, want %q", encoded, decoded, input)
+	}
+}
+
+func TestBwtRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := make([]byte, 5000)
+	random.Read(noise)
+
+	tests := []struct {
+		name      string
+		input     []byte
+		blockSize int
+	} {
+		{
+			name:      "Empty input",
+			input:     nil,
+			blockSize: 900000,
+		},
+		{
+			name:      "Single character",
+			input:     []byte("A"),
+			blockSize: 900000,
+		},
+		{
+			name:      "Long zero run",
+			input:     bytes.Repeat([]byte{'A'}, 100000),
+			blockSize: 900000,
+		},
+		{
+			name:      "Text in several blocks",
+			input:     bytes.Repeat([]byte("how much wood would a woodchuck chuck "), 200),
+			blockSize: 1000,
+		},
+		{
+			name:      "Random bytes",
+			input:     noise,
+			blockSize: 900000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Bwt(tt.input, tt.blockSize)
+			if err != nil {
+				t.Fatalf("Bwt returned unexpected error: %v", err)
+			}
+
+			got, err := BwtDecode(compressed)
+			if err != nil {
+				t.Fatalf("BwtDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("BwtDecode(Bwt(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestBwtDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Primary index out of range",
+			input: []byte{3, 5},
+		},
+		{
+			name:  "Truncated code length table",
+			input: []byte{3, 1, 0x11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BwtDecode(tt.input); err == nil {
+				t.Errorf("BwtDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const ( // Checksums stored with the uncompressed length (see Integrity)
+	ChecksumNone   = 0 // No length or checksum is stored
+	ChecksumCRC32  = 1 // CRC-32 (IEEE), as used by gzip, zlib's crc32() and xz
+	ChecksumCRC32C = 2 // CRC-32C (Castagnoli), as used by snappy and iSCSI; faster on CPUs with SSE 4.2 or ARMv8 CRC instructions
+)
+
+var ChecksumNames = []string{"none", "crc32", "crc32c"} // Names of the checksums, aligned with their constants
+
+// Checksum stored by NewRLECompressor and by the containers of core (and therefore by the file-to-file compressors)
+var ChecksumType = ChecksumCRC32
+
+// ErrChecksumMismatch is returned when decompressed data does not have the checksum stored with it, which means it is corrupt.
+// Use errors.Is(err, &ErrChecksumMismatch{}) to check for it, whatever the checksums are.
+type ErrChecksumMismatch struct {
+	Expected uint32 // Checksum stored with the data
+	Actual   uint32 // Checksum of the decompressed data
+}
+
+// Format the ErrChecksumMismatch error message.
+func (e *ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("checksum mismatch: data is corrupt (stored checksum %08x, decompressed data has %08x)", e.Expected, e.Actual)
+}
+
+// Is makes errors.Is match any ErrChecksumMismatch.
+func (e *ErrChecksumMismatch) Is(target error) bool {
+	_, ok := target.(*ErrChecksumMismatch)
+	return ok
+}
+
+// ErrLengthMismatch is returned when decompressed data does not have the length stored with it, which means it is corrupt.
+// Use errors.Is(err, &ErrLengthMismatch{}) to check for it, whatever the lengths are.
+type ErrLengthMismatch struct {
+	Expected uint64 // Length stored with the data
+	Actual   uint64 // Length of the decompressed data
+}
+
+// Format the ErrLengthMismatch error message.
+func (e *ErrLengthMismatch) Error() string {
+	return fmt.Sprintf("length mismatch: data is corrupt (stored length %d, decompressed data has %d bytes)", e.Expected, e.Actual)
+}
+
+// Is makes errors.Is match any ErrLengthMismatch.
+func (e *ErrLengthMismatch) Is(target error) bool {
+	_, ok := target.(*ErrLengthMismatch)
+	return ok
+}
+
+// GetChecksumType returns the checksum constant for a name in ChecksumNames, or -1.
+func GetChecksumType(name string) int {
+	for i, checksum := range ChecksumNames {
+		if checksum == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// checksum computes the checksum of the given type.
+func checksum(data []byte, checksumType int) uint32 {
+	if checksumType == ChecksumCRC32C {
+		return crc32.Checksum(data, crc32cTable)
+	}
+	return crc32.ChecksumIEEE(data)
+}
+
+// --- // Integrity Records
+
+// Integrity is the uncompressed length and checksum stored with compressed data, so decompressing can tell corrupt data
+// from good data. It is stored as the uvarint length, the checksum type (1 byte) and the checksum (4 bytes, little-endian).
+type Integrity struct {
+	Length       uint64 // Length of the uncompressed data
+	ChecksumType int    // ChecksumCRC32 or ChecksumCRC32C
+	Checksum     uint32 // Checksum of the uncompressed data
+}
+
+// NewIntegrity computes the Integrity of uncompressed data with a checksum of the given type.
+func NewIntegrity(data []byte, checksumType int) (Integrity, error) {
+	if checksumType != ChecksumCRC32 && checksumType != ChecksumCRC32C {
+		return Integrity{}, fmt.Errorf("invalid checksum type %d (must be %d for crc32 or %d for crc32c)", checksumType, ChecksumCRC32, ChecksumCRC32C)
+	}
+	return Integrity{Length: uint64(len(data)), ChecksumType: checksumType, Checksum: checksum(data, checksumType)}, nil
+}
+
+// Append appends the stored form of the Integrity to `dst`.
+func (i Integrity) Append(dst []byte) []byte {
+	dst = binary.AppendUvarint(dst, i.Length)
+	dst = append(dst, byte(i.ChecksumType))
+	return binary.LittleEndian.AppendUint32(dst, i.Checksum)
+}
+
+// ParseIntegrity reads an Integrity from the start of `data`, returning it and the number of bytes it took.
+func ParseIntegrity(data []byte) (Integrity, int, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || len(data) < n+5 {
+		return Integrity{}, 0, fmt.Errorf("malformed integrity record: truncated length or checksum")
+	}
+	checksumType := int(data[n])
+	if checksumType != ChecksumCRC32 && checksumType != ChecksumCRC32C {
+		return Integrity{}, 0, fmt.Errorf("malformed integrity record: unknown checksum type %d", checksumType)
+	}
+	return Integrity{Length: length, ChecksumType: checksumType, Checksum: binary.LittleEndian.Uint32(data[n+1:])}, n + 5, nil
+}
+
+// Verify returns an ErrLengthMismatch or an ErrChecksumMismatch unless the decompressed data matches the Integrity.
+func (i Integrity) Verify(data []byte) error {
+	if uint64(len(data)) != i.Length {
+		return &ErrLengthMismatch{Expected: i.Length, Actual: uint64(len(data))}
+	}
+	if sum := checksum(data, i.ChecksumType); sum != i.Checksum {
+		return &ErrChecksumMismatch{Expected: i.Checksum, Actual: sum}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package algorithms
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIntegrity(t *testing.T) {
+	tests := []struct {
+		name         string
+		checksumType int
+		input        []byte
+		checksum     uint32
+	} {
+		{name: "crc32", checksumType: ChecksumCRC32, input: []byte("123456789"), checksum: 0xCBF43926},
+		{name: "crc32c", checksumType: ChecksumCRC32C, input: []byte("123456789"), checksum: 0xE3069283},
+		{name: "empty", checksumType: ChecksumCRC32, input: nil, checksum: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integrity, err := NewIntegrity(tt.input, tt.checksumType)
+			if err != nil {
+				t.Fatalf("NewIntegrity returned unexpected error: %v", err)
+			}
+			if integrity.Checksum != tt.checksum || integrity.Length != uint64(len(tt.input)) {
+				t.Errorf("NewIntegrity = %+v, want length %d and checksum %08x", integrity, len(tt.input), tt.checksum)
+			}
+
+			stored := integrity.Append([]byte{0xAA})
+			got, n, err := ParseIntegrity(stored[1:])
+			if err != nil {
+				t.Fatalf("ParseIntegrity returned unexpected error: %v", err)
+			}
+			if got != integrity || n != len(stored)-1 {
+				t.Errorf("ParseIntegrity = %+v, %d, want %+v, %d", got, n, integrity, len(stored)-1)
+			}
+			if err := got.Verify(tt.input); err != nil {
+				t.Errorf("Verify returned unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIntegrityMismatch(t *testing.T) {
+	integrity, _ := NewIntegrity([]byte("hello, world"), ChecksumCRC32C)
+
+	err := integrity.Verify([]byte("hello, world!"))
+	var length *ErrLengthMismatch
+	if !errors.As(err, &length) || length.Expected != 12 || length.Actual != 13 {
+		t.Errorf("Verify of longer data returned %v, want an ErrLengthMismatch of 12 and 13 bytes", err)
+	}
+	err = integrity.Verify([]byte("hello, World"))
+	if !errors.Is(err, &ErrChecksumMismatch{}) || errors.Is(err, &ErrLengthMismatch{}) {
+		t.Errorf("Verify of changed data returned %v, want only an ErrChecksumMismatch", err)
+	}
+
+	// Wrapped errors are still found
+	if wrapped := fmt.Errorf("stage: %w", err); !errors.Is(wrapped, &ErrChecksumMismatch{}) {
+		t.Errorf("errors.Is does not find a wrapped ErrChecksumMismatch")
+	}
+}
+
+func TestIntegrityInvalid(t *testing.T) {
+	if _, err := NewIntegrity([]byte("data"), ChecksumNone); err == nil {
+		t.Errorf("NewIntegrity with no checksum expected an error")
+	}
+	malformed := [][]byte{
+		nil,
+		{4, ChecksumCRC32, 1, 2, 3},
+		{4, 9, 1, 2, 3, 4},
+		{0x80},
+	}
+	for _, input := range malformed {
+		if _, _, err := ParseIntegrity(input); err == nil {
+			t.Errorf("ParseIntegrity(%v) expected an error", input)
+		}
+	}
+	if GetChecksumType("crc32c") != ChecksumCRC32C || GetChecksumType("md5") != -1 {
+		t.Errorf("GetChecksumType does not map the names to the constants")
+	}
+}
@@ -0,0 +1,270 @@
+package algorithms
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Compression level used by NewGzipCompressor, NewZlibCompressor and NewDeflateCompressor (and therefore by the factories in core).
+// It follows compress/flate: -2 (Huffman only), -1 (default), 0 (no compression) and 1 (fastest) to 9 (best).
+var DeflateLevel = flate.DefaultCompression
+
+// Container a DEFLATE stream is wrapped in
+type deflateFormat int
+
+const (
+	deflateFormatRaw  deflateFormat = iota // Raw DEFLATE (RFC 1951)
+	deflateFormatZlib                      // zlib (RFC 1950)
+	deflateFormatGzip                      // gzip (RFC 1952)
+)
+
+const zlibFlagDict = 0x20 // Bit of the second header byte that marks a preset dictionary
+
+// Names of the formats, for logging and errors
+var deflateFormatNames = []string{"Deflate", "Zlib", "Gzip"}
+
+// --- // Encoding
+
+// deflateEncode compresses data into the given format at the given level, with a preset dictionary if it is not empty.
+// Only zlib can record the dictionary (as its Adler-32), so the other formats do not take one. The gzip format records
+// the fields of `header` (such as the name and mtime of the file) unless it is nil.
+func deflateEncode(data []byte, format deflateFormat, level int, dictionary []byte, header *gzip.Header) ([]byte, error) {
+	if len(dictionary) > 0 && format != deflateFormatZlib {
+		return nil, fmt.Errorf("the %s format cannot record a dictionary, use zlib instead", deflateFormatNames[format])
+	}
+
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+
+	switch format {
+	case deflateFormatGzip:
+		var gzipWriter *gzip.Writer
+		if gzipWriter, err = gzip.NewWriterLevel(&buffer, level); err == nil && header != nil {
+			gzipWriter.Header = *header
+		}
+		writer = gzipWriter
+	case deflateFormatZlib:
+		writer, err = zlib.NewWriterLevelDict(&buffer, level, dictionary)
+	default:
+		writer, err = flate.NewWriter(&buffer, level)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s writer: %w", deflateFormatNames[format], err)
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+
+	verbosePrintf("%sEncode: len(data): %v, len(compressed): %v\n", deflateFormatNames[format], len(data), buffer.Len())
+	return buffer.Bytes(), nil
+}
+
+// Gzip compresses data into the gzip format, readable by the standard `gunzip` tool.
+func Gzip(data []byte, level int) ([]byte, error) {
+	return deflateEncode(data, deflateFormatGzip, level, nil, nil)
+}
+
+// GzipWithHeader compresses data like Gzip, recording the fields of `header` (such as the name and mtime of the file,
+// which `gzip -d -N` restores) in the gzip header.
+func GzipWithHeader(data []byte, level int, header gzip.Header) ([]byte, error) {
+	return deflateEncode(data, deflateFormatGzip, level, nil, &header)
+}
+
+// Zlib compresses data into the zlib format.
+func Zlib(data []byte, level int) ([]byte, error) {
+	return deflateEncode(data, deflateFormatZlib, level, nil, nil)
+}
+
+// ZlibWithDictionary compresses data into the zlib format with a preset dictionary, whose Adler-32 goes in the header.
+func ZlibWithDictionary(data []byte, level int, dictionary []byte) ([]byte, error) {
+	return deflateEncode(data, deflateFormatZlib, level, dictionary, nil)
+}
+
+// Deflate compresses data into a raw DEFLATE stream.
+func Deflate(data []byte, level int) ([]byte, error) {
+	return deflateEncode(data, deflateFormatRaw, level, nil, nil)
+}
+
+// --- // Decoding
+
+// deflateDecode decompresses data in the given format, with the preset dictionary recorded in zlib data. For the gzip
+// format, the header of the first member is stored in `header` unless it is nil.
+func deflateDecode(data []byte, format deflateFormat, dictionary []byte, header *gzip.Header) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	if format == deflateFormatZlib && len(data) >= 6 && data[1]&zlibFlagDict != 0 {
+		// Check the dictionary here, since compress/zlib does not tell which one the data needs
+		recorded := binary.BigEndian.Uint32(data[2:])
+		if len(dictionary) == 0 {
+			return nil, &ErrDictionaryMismatch{Expected: recorded}
+		}
+		if id := adler32.Checksum(dictionary); id != recorded {
+			return nil, &ErrDictionaryMismatch{Expected: recorded, Given: id}
+		}
+	}
+
+	var reader io.ReadCloser
+	var err error
+
+	switch format {
+	case deflateFormatGzip:
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(bytes.NewReader(data)); err == nil && header != nil {
+			*header = gzipReader.Header
+		}
+		reader = gzipReader
+	case deflateFormatZlib:
+		reader, err = zlib.NewReaderDict(bytes.NewReader(data), dictionary)
+	default:
+		reader = flate.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("malformed %s data: %w", deflateFormatNames[format], err)
+	}
+	defer reader.Close()
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("malformed %s data: %w", deflateFormatNames[format], err)
+	}
+	return output, nil
+}
+
+// GzipDecode decompresses gzip data, including files with several members like the ones `cat a.gz b.gz` produces.
+func GzipDecode(data []byte) ([]byte, error) {
+	return deflateDecode(data, deflateFormatGzip, nil, nil)
+}
+
+// GzipDecodeWithHeader decompresses gzip data like GzipDecode, also returning the header of the first member.
+func GzipDecodeWithHeader(data []byte) ([]byte, gzip.Header, error) {
+	var header gzip.Header
+	output, err := deflateDecode(data, deflateFormatGzip, nil, &header)
+	return output, header, err
+}
+
+// ZlibDecode decompresses zlib data.
+func ZlibDecode(data []byte) ([]byte, error) {
+	return deflateDecode(data, deflateFormatZlib, nil, nil)
+}
+
+// ZlibDecodeWithDictionary decompresses zlib data that may have been compressed with `dictionary`.
+// It returns an ErrDictionaryMismatch if the data was compressed with another dictionary.
+func ZlibDecodeWithDictionary(data []byte, dictionary []byte) ([]byte, error) {
+	return deflateDecode(data, deflateFormatZlib, dictionary, nil)
+}
+
+// DeflateDecode decompresses a raw DEFLATE stream.
+func DeflateDecode(data []byte) ([]byte, error) {
+	return deflateDecode(data, deflateFormatRaw, nil, nil)
+}
+
+// --- // DEFLATE Compressor Interface
+
+// DeflateCompressor implements core.GeneralCompressor and core.GeneralDecompressor for gzip, zlib and raw DEFLATE.
+type DeflateCompressor struct {
+	Level      int    // Compression level, see DeflateLevel
+	Dictionary []byte // Preset dictionary, nil for none; only the zlib format supports one
+	SaveName   bool   // Whether CompressFileToFile records the name and mtime of the input file; only the gzip format supports it
+	format     deflateFormat
+}
+
+// Compress implements core.Compressor.
+func (d *DeflateCompressor) Compress(data []byte) ([]byte, error) {
+	return deflateEncode(data, d.format, d.Level, d.Dictionary, nil)
+}
+
+// Decompress implements core.Decompressor.
+func (d *DeflateCompressor) Decompress(data []byte) ([]byte, error) {
+	return deflateDecode(data, d.format, d.Dictionary, nil)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor. With SaveName, the gzip header records the name and mtime
+// of the input file, like `gzip -N` (a name that Latin-1, the encoding of the header, cannot hold is left out).
+func (d *DeflateCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	if !d.SaveName || d.format != deflateFormatGzip {
+		return TransformFile(deflateFormatNames[d.format]+"CompressFile", inputFilePath, outputFilePath, d.Compress)
+	}
+	info, err := os.Stat(inputFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	header := gzip.Header{Name: filepath.Base(inputFilePath), ModTime: info.ModTime()}
+	for _, r := range header.Name {
+		if r > 0xFF {
+			header.Name = ""
+			break
+		}
+	}
+	return TransformFile(deflateFormatNames[d.format]+"CompressFile", inputFilePath, outputFilePath, func(data []byte) ([]byte, error) {
+		return deflateEncode(data, d.format, d.Level, nil, &header)
+	})
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (d *DeflateCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile(deflateFormatNames[d.format]+"DecompressFile", inputFilePath, outputFilePath, d.Decompress)
+}
+
+// Factory function for creating a gzip compressor instance using DeflateLevel.
+func NewGzipCompressor() *DeflateCompressor {
+	return NewGzipCompressorWithLevel(DeflateLevel)
+}
+
+// Factory function for creating a gzip compressor instance with a custom compression level.
+func NewGzipCompressorWithLevel(level int) *DeflateCompressor {
+	return &DeflateCompressor{Level: level, format: deflateFormatGzip}
+}
+
+// Factory function for creating a gzip decompressor instance.
+func NewGzipDecompressor() *DeflateCompressor {
+	return NewGzipCompressor()
+}
+
+// Factory function for creating a zlib compressor instance using DeflateLevel and Dictionary.
+func NewZlibCompressor() *DeflateCompressor {
+	return NewZlibCompressorWithDictionary(Dictionary)
+}
+
+// Factory function for creating a zlib compressor instance with a custom compression level.
+func NewZlibCompressorWithLevel(level int) *DeflateCompressor {
+	return &DeflateCompressor{Level: level, format: deflateFormatZlib}
+}
+
+// Factory function for creating a zlib compressor (or decompressor) instance with a preset dictionary, using DeflateLevel.
+func NewZlibCompressorWithDictionary(dictionary []byte) *DeflateCompressor {
+	return &DeflateCompressor{Level: DeflateLevel, Dictionary: dictionary, format: deflateFormatZlib}
+}
+
+// Factory function for creating a zlib decompressor instance.
+func NewZlibDecompressor() *DeflateCompressor {
+	return NewZlibCompressor()
+}
+
+// Factory function for creating a raw DEFLATE compressor instance using DeflateLevel.
+func NewDeflateCompressor() *DeflateCompressor {
+	return NewDeflateCompressorWithLevel(DeflateLevel)
+}
+
+// Factory function for creating a raw DEFLATE compressor instance with a custom compression level.
+func NewDeflateCompressorWithLevel(level int) *DeflateCompressor {
+	return &DeflateCompressor{Level: level, format: deflateFormatRaw}
+}
+
+// Factory function for creating a raw DEFLATE decompressor instance.
+func NewDeflateDecompressor() *DeflateCompressor {
+	return NewDeflateCompressor()
+}
@@ -0,0 +1,125 @@
+package algorithms
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeflateRoundTrip(t *testing.T) {
+	formats := []struct {
+		name       string
+		compressor *DeflateCompressor
+	} {
+		{name: "gzip", compressor: NewGzipCompressorWithLevel(flate.BestCompression)},
+		{name: "zlib", compressor: NewZlibCompressorWithLevel(flate.BestSpeed)},
+		{name: "deflate", compressor: NewDeflateCompressorWithLevel(flate.DefaultCompression)},
+		{name: "deflate (huffman only)", compressor: NewDeflateCompressorWithLevel(flate.HuffmanOnly)},
+	}
+	inputs := [][]byte{
+		[]byte("A"),
+		[]byte("hello hello hello hello"),
+		bytes.Repeat([]byte{0}, 100000),
+	}
+
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			for _, input := range inputs {
+				compressed, err := format.compressor.Compress(input)
+				if err != nil {
+					t.Fatalf("Compress returned unexpected error: %v", err)
+				}
+
+				got, err := format.compressor.Decompress(compressed)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+
+				if !bytes.Equal(got, input) {
+					t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(input))
+				}
+			}
+		})
+	}
+}
+
+func TestGzipDecodeStockGzip(t *testing.T) {
+	// Output of `printf 'hello, gzip\n' | gzip -n -9`
+	stock := []byte{
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xcb, 0x48, 0xcd, 0xc9, 0xc9, 0xd7,
+		0x51, 0x48, 0xaf, 0xca, 0x2c, 0xe0, 0x02, 0x00, 0x86, 0x1f, 0x82, 0xa4, 0x0c, 0x00, 0x00, 0x00,
+	}
+
+	got, err := GzipDecode(stock)
+	if err != nil {
+		t.Fatalf("GzipDecode returned unexpected error: %v", err)
+	}
+	if string(got) != "hello, gzip\n" {
+		t.Errorf("GzipDecode = %q, want %q", got, "hello, gzip\n")
+	}
+
+	// Concatenated members decode as one stream, the same way gunzip handles them
+	got, err = GzipDecode(append(append([]byte(nil), stock...), stock...))
+	if err != nil {
+		t.Fatalf("GzipDecode returned unexpected error: %v", err)
+	}
+	if string(got) != "hello, gzip\nhello, gzip\n" {
+		t.Errorf("GzipDecode = %q, want the text twice", got)
+	}
+}
+
+func TestGzipHeader(t *testing.T) {
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	compressed, err := GzipWithHeader([]byte("hello, gzip\n"), DeflateLevel, gzip.Header{Name: "hello.txt", ModTime: mtime})
+	if err != nil {
+		t.Fatalf("GzipWithHeader returned unexpected error: %v", err)
+	}
+	if compressed[3]&0x08 == 0 { // FNAME
+		t.Errorf("GzipWithHeader did not set the FNAME flag")
+	}
+	got, header, err := GzipDecodeWithHeader(compressed)
+	if err != nil {
+		t.Fatalf("GzipDecodeWithHeader returned unexpected error: %v", err)
+	}
+	if string(got) != "hello, gzip\n" || header.Name != "hello.txt" || !header.ModTime.Equal(mtime) {
+		t.Errorf("GzipDecodeWithHeader = %q, %q, %v, want the text, the name and %v", got, header.Name, header.ModTime, mtime)
+	}
+
+	// The file-to-file compressor records the input file with SaveName
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputPath, []byte("hello, gzip\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+	if err := os.Chtimes(inputPath, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes returned unexpected error: %v", err)
+	}
+	for _, saveName := range []bool{true, false} {
+		compressor := NewGzipCompressor()
+		compressor.SaveName = saveName
+		if err := compressor.CompressFileToFile(inputPath, inputPath+".gz"); err != nil {
+			t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+		}
+		compressed, _ := os.ReadFile(inputPath + ".gz")
+		_, header, err := GzipDecodeWithHeader(compressed)
+		if err != nil {
+			t.Fatalf("GzipDecodeWithHeader returned unexpected error: %v", err)
+		}
+		if saveName && (header.Name != "input.txt" || !header.ModTime.Equal(mtime)) {
+			t.Errorf("CompressFileToFile recorded %q, %v, want %q, %v", header.Name, header.ModTime, "input.txt", mtime)
+		}
+		if !saveName && (header.Name != "" || !header.ModTime.IsZero()) {
+			t.Errorf("CompressFileToFile without SaveName recorded %q, %v", header.Name, header.ModTime)
+		}
+	}
+}
+
+func TestDeflateInvalidLevel(t *testing.T) {
+	if _, err := Gzip([]byte("abc"), 42); err == nil {
+		t.Errorf("Gzip with level 42 expected an error")
+	}
+}
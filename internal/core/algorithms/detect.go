@@ -0,0 +1,46 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+var gzipMagic = []byte{0x1F, 0x8B} // Start of a gzip member (RFC 1952)
+
+// --- // Format Detection
+
+// DetectAlgorithm returns the algorithm whose standard format `data` is in, judging by the signature at its start, or -1.
+// Only formats that start with a signature are detected: gzip, zlib, lzw (.Z), lz4, snappy (framing format), xz and zstd.
+// The zlib header is only two bytes with a checksum, so it is checked last.
+func DetectAlgorithm(data []byte) int {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return GzipAlgorithm
+	case len(data) >= 2 && data[0] == lzwMagic0 && data[1] == lzwMagic1:
+		return LZWAlgorithm
+	case len(data) >= 4 && binary.LittleEndian.Uint32(data) == lz4FrameMagic:
+		return LZ4Algorithm
+	case len(data) >= 4 && binary.LittleEndian.Uint32(data) == zstdMagic:
+		return ZstdAlgorithm
+	case bytes.HasPrefix(data, snappyStreamID):
+		return SnappyAlgorithm
+	case bytes.HasPrefix(data, xzHeaderMagic):
+		return XZAlgorithm
+	case len(data) >= 2 && data[0]&0x0F == 8 && data[0]>>4 <= 7 && binary.BigEndian.Uint16(data)%31 == 0:
+		return ZlibAlgorithm // DEFLATE with a window of at most 32 KB, and a valid header checksum
+	}
+	return -1
+}
+
+// HasSignature reports whether the standard output of the algorithm starts with a signature that DetectAlgorithm
+// recognizes, with the current options (the raw snappy format, see SnappyRaw, has none).
+func HasSignature(algorithm int) bool {
+	switch algorithm {
+	case GzipAlgorithm, ZlibAlgorithm, LZWAlgorithm, LZ4Algorithm, XZAlgorithm, ZstdAlgorithm:
+		return true
+	case SnappyAlgorithm:
+		return !SnappyRaw
+	default:
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package algorithms
+
+import "testing"
+
+func TestDetectAlgorithm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  int
+	} {
+		{name: "gzip", input: []byte{0x1F, 0x8B, 0x08, 0x00}, want: GzipAlgorithm},
+		{name: "zlib", input: []byte{0x78, 0x9C, 0x03, 0x00}, want: ZlibAlgorithm},
+		{name: "lzw", input: []byte{0x1F, 0x9D, 0x90}, want: LZWAlgorithm},
+		{name: "lz4", input: []byte{0x04, 0x22, 0x4D, 0x18, 0x64}, want: LZ4Algorithm},
+		{name: "zstd", input: []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00}, want: ZstdAlgorithm},
+		{name: "snappy", input: snappyStreamID, want: SnappyAlgorithm},
+		{name: "xz", input: xzHeaderMagic, want: XZAlgorithm},
+		{name: "zlib header with a bad checksum", input: []byte{0x78, 0x9D}, want: -1},
+		{name: "text", input: []byte("hello, world"), want: -1},
+		{name: "one byte", input: []byte{0x1F}, want: -1},
+		{name: "empty", input: nil, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectAlgorithm(tt.input); got != tt.want {
+				t.Errorf("DetectAlgorithm(% x) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasSignature(t *testing.T) {
+	input := []byte("hello, hello, hello, world")
+	tests := []struct {
+		name      string
+		algorithm int
+		compress  func([]byte) ([]byte, error)
+	} {
+		{name: "gzip", algorithm: GzipAlgorithm, compress: func(data []byte) ([]byte, error) { return Gzip(data, 6) }},
+		{name: "zlib", algorithm: ZlibAlgorithm, compress: func(data []byte) ([]byte, error) { return Zlib(data, 6) }},
+		{name: "lzw", algorithm: LZWAlgorithm, compress: func(data []byte) ([]byte, error) { return Lzw(data, 16) }},
+		{name: "lz4", algorithm: LZ4Algorithm, compress: func(data []byte) ([]byte, error) { return Lz4(data, false) }},
+		{name: "snappy", algorithm: SnappyAlgorithm, compress: Snappy},
+		{name: "xz", algorithm: XZAlgorithm, compress: Xz},
+		{name: "zstd", algorithm: ZstdAlgorithm, compress: Zstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !HasSignature(tt.algorithm) {
+				t.Fatalf("HasSignature(%d) = false, want true", tt.algorithm)
+			}
+			compressed, err := tt.compress(input)
+			if err != nil {
+				t.Fatalf("compressing returned unexpected error: %v", err)
+			}
+			if got := DetectAlgorithm(compressed); got != tt.algorithm {
+				t.Errorf("DetectAlgorithm of the output = %d, want %d", got, tt.algorithm)
+			}
+		})
+	}
+
+	for _, algorithm := range []int{RLEAlgorithm, HuffmanAlgorithm, DeflateAlgorithm} {
+		if HasSignature(algorithm) {
+			t.Errorf("HasSignature(%d) = true, want false", algorithm)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Preset dictionary used by the constructors of the algorithms that support one (see SupportsDictionary), and therefore
+// by the factories in core; nil for none. Data compressed with a dictionary needs the same dictionary to decompress.
+var Dictionary []byte
+
+const (
+	DefaultDictionarySize = 32 << 10 // Dictionary size used by the train mode unless told otherwise
+	dictionaryDmerSize    = 8        // Length of the substrings TrainDictionary counts
+	dictionarySegmentSize = 64       // Length of the pieces of the samples TrainDictionary puts in the dictionary
+	dictionaryMinSize     = dictionarySegmentSize
+	dictionaryIDReserved  = 0x8000     // IDs below this are reserved by zstd for registered dictionaries
+	dictionaryIDMask      = 0x7FFFFFFF // IDs from 2^31 up are reserved by zstd as well
+)
+
+// ErrDictionaryMismatch is returned when data is decompressed without the dictionary it was compressed with.
+type ErrDictionaryMismatch struct {
+	Expected uint32 // ID of the dictionary recorded in the data
+	Given    uint32 // ID of the dictionary given for decompressing, 0 if there was none
+}
+
+// Format the ErrDictionaryMismatch error message.
+func (e *ErrDictionaryMismatch) Error() string {
+	if e.Given == 0 {
+		return fmt.Sprintf("data was compressed with dictionary %08x, but no dictionary was given", e.Expected)
+	}
+	return fmt.Sprintf("data was compressed with dictionary %08x, but dictionary %08x was given", e.Expected, e.Given)
+}
+
+// SupportsDictionary reports whether the algorithm can use a preset dictionary.
+func SupportsDictionary(alg int) bool {
+	return alg == LZSSAlgorithm || alg == ZlibAlgorithm || alg == LZ4Algorithm || alg == ZstdAlgorithm
+}
+
+// DictionaryID returns the ID that lzss, lz4 and zstd record in data compressed with `dictionary` (zlib records the
+// Adler-32 of the dictionary instead, as its format requires): the ID in the header of a dictionary in the zstd
+// format, such as the ones TrainDictionary makes, or else a hash that is never 0 and stays out of the ranges zstd
+// reserves. Zstd records no ID for a dictionary that is not in its format.
+func DictionaryID(dictionary []byte) uint32 {
+	if len(dictionary) >= 8 && binary.LittleEndian.Uint32(dictionary) == zstdDictionaryMagic {
+		if id := binary.LittleEndian.Uint32(dictionary[4:]); id != 0 {
+			return id
+		}
+	}
+	return xxHash32(dictionary, 0)&dictionaryIDMask | dictionaryIDReserved
+}
+
+// checkDictionary returns an ErrDictionaryMismatch unless `dictionary` has the ID recorded in the data.
+func checkDictionary(recorded uint32, dictionary []byte) error {
+	if len(dictionary) == 0 {
+		return &ErrDictionaryMismatch{Expected: recorded}
+	}
+	if id := DictionaryID(dictionary); id != recorded {
+		return &ErrDictionaryMismatch{Expected: recorded, Given: id}
+	}
+	return nil
+}
+
+// dictionaryTail returns the last `size` bytes of the dictionary, the part that matches can reach.
+func dictionaryTail(dictionary []byte, size int) []byte {
+	if len(dictionary) > size {
+		return dictionary[len(dictionary)-size:]
+	}
+	return dictionary
+}
+
+// --- // Dictionary Training
+
+// dictionarySegment is a piece of a sample picked for the dictionary.
+type dictionarySegment struct {
+	start int
+	score int
+}
+
+// TrainDictionary builds a dictionary of at most `size` bytes from sample data, such as many small records of the
+// same kind. It counts in how many samples every 8-byte substring shows up, splits the samples into one part per
+// 64-byte segment of the dictionary, and picks the segment of each part whose substrings are the most common
+// (in the spirit of zstd's COVER trainer). The best segments go last, where the offsets to them are the shortest.
+// The dictionary is in the zstd format, so the reference zstd tool reads it and its ID too.
+func TrainDictionary(samples [][]byte, size int) ([]byte, error) {
+	headerSize := len(zstdDictionaryHeader(nil, 0))
+	if size < headerSize+dictionaryMinSize {
+		return nil, fmt.Errorf("invalid dictionary size %d (must be at least %d)", size, headerSize+dictionaryMinSize)
+	}
+	size -= headerSize
+
+	// Number of samples each substring shows up in, with the last sample that counted it
+	type dmerCount struct {
+		samples int
+		last    int
+	}
+	counts := make(map[uint64]dmerCount)
+	var corpus []byte
+	for s, sample := range samples {
+		for i := 0; i+dictionaryDmerSize <= len(sample); i++ {
+			key := binary.LittleEndian.Uint64(sample[i:])
+			if count, ok := counts[key]; !ok || count.last != s {
+				counts[key] = dmerCount{samples: count.samples + 1, last: s}
+			}
+		}
+		corpus = append(corpus, sample...)
+	}
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("not enough sample data to train a dictionary (%d bytes)", len(corpus))
+	}
+
+	parts := size / dictionarySegmentSize
+	if limit := len(corpus) / dictionarySegmentSize; parts > limit {
+		parts = limit
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	partSize := len(corpus) / parts
+
+	var segments []dictionarySegment
+	scores := make([]int, partSize)
+	for p := 0; p < parts; p++ {
+		part := corpus[p*partSize : (p+1)*partSize]
+		for i := range scores {
+			scores[i] = 0
+			if i+dictionaryDmerSize <= len(part) {
+				scores[i] = counts[binary.LittleEndian.Uint64(part[i:])].samples
+			}
+		}
+
+		// Slide a segment over the part, keeping the sum of the scores of the substrings that start in it
+		length := dictionarySegmentSize
+		if length > len(part) {
+			length = len(part)
+		}
+		window := length - dictionaryDmerSize + 1
+		if window < 1 {
+			window = 1
+		}
+		best, bestScore, score := 0, 0, 0
+		for i := 0; i < len(part); i++ {
+			score += scores[i]
+			if i >= window {
+				score -= scores[i-window]
+			}
+			if start := i - window + 1; start >= 0 && start+length <= len(part) && score > bestScore {
+				best, bestScore = start, score
+			}
+		}
+		if bestScore == 0 {
+			continue
+		}
+
+		// Substrings already in the dictionary are not worth picking again
+		for i := best; i+dictionaryDmerSize <= best+length; i++ {
+			delete(counts, binary.LittleEndian.Uint64(part[i:]))
+		}
+		segments = append(segments, dictionarySegment{start: p*partSize + best, score: bestScore})
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].score < segments[j].score })
+	var dictionary []byte
+	for _, segment := range segments {
+		end := segment.start + dictionarySegmentSize
+		if end > len(corpus) {
+			end = len(corpus)
+		}
+		dictionary = append(dictionary, corpus[segment.start:end]...)
+	}
+	dictionary = dictionaryTail(dictionary, size)
+	if len(dictionary) == 0 {
+		return nil, fmt.Errorf("the samples have no substrings worth putting in a dictionary")
+	}
+
+	dictionary = append(zstdDictionaryHeader(dictionary, DictionaryID(dictionary)), dictionary...)
+
+	verbosePrintf("TrainDictionary: %v samples, %v bytes, dictionary: %v bytes, ID: %08x\n", len(samples), len(corpus), len(dictionary), DictionaryID(dictionary))
+	return dictionary, nil
+}
@@ -0,0 +1,185 @@
+package algorithms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/dictionary are a dictionary trained on records like record.json (by the train mode, in the zstd
+// format), and record.json compressed with it by `lz4 -D records.dict` and `zstd -D records.dict` (which records its ID).
+
+// dictionaryRecord returns a small JSON record, similar to the ones the dictionary fixture was trained on.
+func dictionaryRecord(i int) []byte {
+	return []byte(fmt.Sprintf(`{"id":%d,"user":"user%04d","email":"user%04d@example.com","active":%v,"roles":["reader","writer"],"created":"2024-%02d-%02dT10:%02d:00Z","score":%d}`,
+		i, i*7%9999, i*7%9999, i%3 == 0, i%12+1, i%28+1, i%60, i*37%1000))
+}
+
+// dictionaryCodecs are the algorithms that support a preset dictionary.
+var dictionaryCodecs = []struct {
+	name       string
+	compress   func(data []byte, dictionary []byte) ([]byte, error)
+	decompress func(data []byte, dictionary []byte) ([]byte, error)
+} {
+	{name: "LZSS", compress: func(data, dictionary []byte) ([]byte, error) { return LzssWithDictionary(data, 4096, 3, dictionary) }, decompress: LzssDecodeWithDictionary},
+	{name: "Zlib", compress: func(data, dictionary []byte) ([]byte, error) { return ZlibWithDictionary(data, 9, dictionary) }, decompress: ZlibDecodeWithDictionary},
+	{name: "LZ4", compress: func(data, dictionary []byte) ([]byte, error) { return Lz4WithDictionary(data, false, dictionary) }, decompress: Lz4DecodeWithDictionary},
+	{name: "Zstd", compress: ZstdWithDictionary, decompress: ZstdDecodeWithDictionary},
+}
+
+func TestTrainDictionary(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 2000; i++ {
+		samples = append(samples, dictionaryRecord(i))
+	}
+	dictionary, err := TrainDictionary(samples, 4096)
+	if err != nil {
+		t.Fatalf("TrainDictionary returned unexpected error: %v", err)
+	}
+	if len(dictionary) == 0 || len(dictionary) > 4096 {
+		t.Fatalf("TrainDictionary returned %d bytes, want between 1 and 4096", len(dictionary))
+	}
+	if !bytes.Contains(dictionary, []byte(`"roles":["reader","writer"]`)) {
+		t.Errorf("TrainDictionary left out the part every sample has in common")
+	}
+	preset, err := zstdParseDictionary(dictionary)
+	if err != nil {
+		t.Fatalf("TrainDictionary returned a dictionary zstd cannot read: %v", err)
+	}
+	if preset.id != DictionaryID(dictionary) || preset.id != DictionaryID(preset.content) {
+		t.Errorf("dictionary header has ID %08x, want %08x", preset.id, DictionaryID(preset.content))
+	}
+
+	if _, err := TrainDictionary(samples, 10); err == nil {
+		t.Errorf("TrainDictionary with a 10-byte dictionary expected an error")
+	}
+	if _, err := TrainDictionary([][]byte{[]byte("tiny")}, 4096); err == nil {
+		t.Errorf("TrainDictionary with 4 bytes of samples expected an error")
+	}
+}
+
+func TestDictionaryRoundTrip(t *testing.T) {
+	dictionary, err := os.ReadFile(filepath.Join("testdata", "dictionary", "records.dict"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	record := dictionaryRecord(7000)
+
+	for _, tt := range dictionaryCodecs {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range [][]byte{nil, []byte("x"), record, bytes.Repeat(record, 100)} {
+				compressed, err := tt.compress(input, dictionary)
+				if err != nil {
+					t.Fatalf("compressing returned unexpected error: %v", err)
+				}
+				got, err := tt.decompress(compressed, dictionary)
+				if err != nil {
+					t.Fatalf("decompressing returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, input) {
+					t.Errorf("round trip returned %d bytes, want %d", len(got), len(input))
+				}
+			}
+
+			// A record on its own has little to match, but most of it is in the dictionary
+			plain, err := tt.compress(record, nil)
+			if err != nil {
+				t.Fatalf("compressing returned unexpected error: %v", err)
+			}
+			withDictionary, _ := tt.compress(record, dictionary)
+			if len(withDictionary)*2 > len(plain) {
+				t.Errorf("%d bytes with the dictionary and %d without, expected at most half", len(withDictionary), len(plain))
+			}
+		})
+	}
+}
+
+func TestDictionaryMismatch(t *testing.T) {
+	dictionary, err := os.ReadFile(filepath.Join("testdata", "dictionary", "records.dict"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	other := append([]byte("another dictionary "), dictionary...)
+
+	for _, tt := range dictionaryCodecs {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := tt.compress(dictionaryRecord(1), dictionary)
+			if err != nil {
+				t.Fatalf("compressing returned unexpected error: %v", err)
+			}
+			for _, wrong := range [][]byte{nil, other} {
+				_, err := tt.decompress(compressed, wrong)
+				var mismatch *ErrDictionaryMismatch
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("decompressing with a %d-byte dictionary returned %v, want an ErrDictionaryMismatch", len(wrong), err)
+				}
+				if (mismatch.Given == 0) != (wrong == nil) {
+					t.Errorf("ErrDictionaryMismatch.Given = %08x for a %d-byte dictionary", mismatch.Given, len(wrong))
+				}
+			}
+		})
+	}
+
+	// Zstd records no ID for raw content, like the reference tool, and uses the dictionary it is given
+	raw := []byte(`{"id":1,"user":"user0007","email":"user0007@example.com"}`)
+	compressed, _ := ZstdWithDictionary(dictionaryRecord(1), raw)
+	if compressed[4]&0x03 != 0 {
+		t.Errorf("ZstdWithDictionary recorded an ID for a raw content dictionary")
+	}
+	if got, err := ZstdDecodeWithDictionary(compressed, raw); err != nil || !bytes.Equal(got, dictionaryRecord(1)) {
+		t.Errorf("ZstdDecodeWithDictionary with raw content returned %q, %v", got, err)
+	}
+
+	// The plain decoders fail the same way
+	compressed, _ = Lz4WithDictionary(dictionaryRecord(1), false, dictionary)
+	if _, err := Lz4Decode(compressed); !errors.As(err, new(*ErrDictionaryMismatch)) {
+		t.Errorf("Lz4Decode returned %v, want an ErrDictionaryMismatch", err)
+	}
+}
+
+func TestDictionaryReferenceFrames(t *testing.T) {
+	dir := filepath.Join("testdata", "dictionary")
+	dictionary, err := os.ReadFile(filepath.Join(dir, "records.dict"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join(dir, "record.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		file   string
+		decode func(data []byte, dictionary []byte) ([]byte, error)
+	} {
+		{file: "record.lz4", decode: Lz4DecodeWithDictionary},
+		{file: "record.zst", decode: ZstdDecodeWithDictionary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			got, err := tt.decode(data, dictionary)
+			if err != nil {
+				t.Fatalf("decoding returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("decoding returned %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestDictionaryOnlyInZlib(t *testing.T) {
+	if _, err := deflateEncode([]byte("abc"), deflateFormatGzip, DeflateLevel, []byte("dictionary"), nil); err == nil {
+		t.Errorf("gzip with a dictionary expected an error")
+	}
+	if _, err := deflateEncode([]byte("abc"), deflateFormatRaw, DeflateLevel, []byte("dictionary"), nil); err == nil {
+		t.Errorf("raw DEFLATE with a dictionary expected an error")
+	}
+}
@@ -0,0 +1,40 @@
+package algorithms
+
+import (
+	"fmt"
+	"os"
+)
+
+// --- // Shared File To File Helper
+
+// TransformFile reads `inputFilePath`, runs its content through `transform` and writes the result to `outputFilePath`.
+// `name` is only used for logging, the same way RleCompressFile and RleDecompressFile log their progress.
+func TransformFile(name string, inputFilePath string, outputFilePath string, transform func([]byte) ([]byte, error)) error {
+	// Read the input file content.
+	inputData, err := os.ReadFile(inputFilePath)
+	generalPrintf("%s: Reading from \"%v\" and writing to \"%v\"\n", name, inputFilePath, outputFilePath)
+	if err != nil {
+		generalPrintf("%s: err: %v\n", name, err)
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	// Transform the data.
+	outputData, err := transform(inputData)
+	verbosePrintf("%s: len(inputData): %v, len(outputData): %v\n", name, len(inputData), len(outputData))
+	if err != nil {
+		generalPrintf("%s: err: %v\n", name, err)
+		return err
+	}
+
+	// Write the transformed data to the output file.
+	return WriteFile(name, outputFilePath, outputData)
+}
+
+// WriteFile writes the output of a transformation to `outputFilePath`, logging errors under `name` like TransformFile.
+func WriteFile(name string, outputFilePath string, outputData []byte) error {
+	if err := os.WriteFile(outputFilePath, outputData, 0644); err != nil {
+		generalPrintf("%s: err: %v\n", name, err)
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
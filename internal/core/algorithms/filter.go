@@ -0,0 +1,269 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Filters do not compress anything themselves: they rewrite the data into a form (of the same size) that compresses
+// better, and are meant to run in front of another algorithm. Delta and xor turn slowly changing values, such as
+// counters, timestamps or samples, into runs of small numbers that rle, huffman and the LZ algorithms handle well.
+
+// Options for the delta and xor filters, used by NewDeltaCompressor and NewXORCompressor (and therefore by the factories in core)
+var DeltaWidth = 1  // Width in bytes of the little-endian values subtracted by the delta filter (1, 2, 4 or 8)
+var DeltaStride = 1 // Distance in values between the value and the one subtracted from it (e.g. 2 for interleaved stereo samples)
+var XorStride = 1   // Distance in bytes between the byte and the one it is xored with (e.g. 8 to xor every float64 with the previous one)
+
+// IsFilter reports whether the algorithm is a filter, which can run in front of another algorithm.
+func IsFilter(alg int) bool {
+	return alg == DeltaAlgorithm || alg == XORAlgorithm || IsBcjFilter(alg)
+}
+
+// --- // Delta Filter
+
+// checkDeltaOptions validates the value width and the stride of the delta filter.
+func checkDeltaOptions(width int, stride int) error {
+	if width != 1 && width != 2 && width != 4 && width != 8 {
+		return fmt.Errorf("invalid delta width %d (must be 1, 2, 4 or 8)", width)
+	}
+	if stride < 1 {
+		return fmt.Errorf("invalid delta stride %d (must be at least 1)", stride)
+	}
+	return nil
+}
+
+// loadDeltaValue reads a little-endian value of `width` bytes.
+func loadDeltaValue(data []byte, width int) uint64 {
+	v := uint64(0)
+	for i := width - 1; i >= 0; i-- {
+		v = v<<8 | uint64(data[i])
+	}
+	return v
+}
+
+// storeDeltaValue writes the low `width` bytes of `v` in little-endian order.
+func storeDeltaValue(data []byte, width int, v uint64) {
+	for i := 0; i < width; i++ {
+		data[i] = byte(v >> (8 * i))
+	}
+}
+
+// DeltaFilter replaces every little-endian value of `width` bytes with its difference to the value `stride` values
+// before it (modulo 2^(8*width)). The first `stride` values and the bytes after the last whole value are kept as they are.
+func DeltaFilter(data []byte, width int, stride int) ([]byte, error) {
+	if err := checkDeltaOptions(width, stride); err != nil {
+		return nil, err
+	}
+
+	if stride > len(data) {
+		stride = len(data) // Larger strides leave every value unchanged
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	distance := width * stride
+	for i := len(data) - len(data)%width - width; i >= distance; i -= width { // Backwards, so every value is subtracted from the original
+		storeDeltaValue(out[i:], width, loadDeltaValue(data[i:], width)-loadDeltaValue(data[i-distance:], width))
+	}
+	return out, nil
+}
+
+// DeltaUnfilter undoes DeltaFilter with the same width and stride.
+func DeltaUnfilter(data []byte, width int, stride int) ([]byte, error) {
+	if err := checkDeltaOptions(width, stride); err != nil {
+		return nil, err
+	}
+
+	if stride > len(data) {
+		stride = len(data) // Larger strides leave every value unchanged
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	distance := width * stride
+	for i := distance; i+width <= len(data); i += width { // Forwards, adding the values restored before
+		storeDeltaValue(out[i:], width, loadDeltaValue(out[i:], width)+loadDeltaValue(out[i-distance:], width))
+	}
+	return out, nil
+}
+
+// Delta runs data through DeltaFilter, storing the width and stride in front of it so DeltaDecode can undo it.
+func Delta(data []byte, width int, stride int) ([]byte, error) {
+	if err := checkDeltaOptions(width, stride); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	filtered, err := DeltaFilter(data, width, stride)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{byte(width)}, binary.AppendUvarint(nil, uint64(stride))...)
+	verbosePrintf("Delta: width: %v, stride: %v\n", width, stride)
+	return append(out, filtered...), nil
+}
+
+// DeltaDecode undoes Delta, reading the width and stride from the start of the data.
+func DeltaDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	width := int(data[0])
+	stride, n := binary.Uvarint(data[1:])
+	if n <= 0 || stride == 0 {
+		return nil, fmt.Errorf("malformed delta data: invalid stride")
+	}
+	if stride > uint64(len(data)) {
+		stride = uint64(len(data)) // Larger strides leave every value unchanged
+	}
+	if err := checkDeltaOptions(width, int(stride)); err != nil {
+		return nil, fmt.Errorf("malformed delta data: %w", err)
+	}
+	verbosePrintf("DeltaDecode: width: %v, stride: %v\n", width, stride)
+	return DeltaUnfilter(data[1+n:], width, int(stride))
+}
+
+// --- // XOR Filter
+
+// XorFilter replaces every byte with its xor with the byte `stride` bytes before it, so values that only change in
+// their low bits (like floating point samples, with a stride of their width) turn into mostly zero bytes.
+func XorFilter(data []byte, stride int) ([]byte, error) {
+	if stride < 1 {
+		return nil, fmt.Errorf("invalid xor stride %d (must be at least 1)", stride)
+	}
+
+	out := make([]byte, len(data))
+	copy(out, data)
+	for i := stride; i < len(data); i++ {
+		out[i] ^= data[i-stride]
+	}
+	return out, nil
+}
+
+// XorUnfilter undoes XorFilter with the same stride.
+func XorUnfilter(data []byte, stride int) ([]byte, error) {
+	if stride < 1 {
+		return nil, fmt.Errorf("invalid xor stride %d (must be at least 1)", stride)
+	}
+
+	out := make([]byte, len(data))
+	copy(out, data)
+	for i := stride; i < len(data); i++ {
+		out[i] ^= out[i-stride]
+	}
+	return out, nil
+}
+
+// Xor runs data through XorFilter, storing the stride in front of it so XorDecode can undo it.
+func Xor(data []byte, stride int) ([]byte, error) {
+	if stride < 1 {
+		return nil, fmt.Errorf("invalid xor stride %d (must be at least 1)", stride)
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	filtered, err := XorFilter(data, stride)
+	if err != nil {
+		return nil, err
+	}
+
+	verbosePrintf("Xor: stride: %v\n", stride)
+	return append(binary.AppendUvarint(nil, uint64(stride)), filtered...), nil
+}
+
+// XorDecode undoes Xor, reading the stride from the start of the data.
+func XorDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	stride, n := binary.Uvarint(data)
+	if n <= 0 || stride == 0 {
+		return nil, fmt.Errorf("malformed xor data: invalid stride")
+	}
+	if stride > uint64(len(data)) {
+		stride = uint64(len(data)) // Larger strides leave every byte unchanged
+	}
+	verbosePrintf("XorDecode: stride: %v\n", stride)
+	return XorUnfilter(data[n:], int(stride))
+}
+
+// --- // Filter Compressor Interfaces
+
+// DeltaCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the delta filter.
+type DeltaCompressor struct {
+	Width  int // Width in bytes of the values (1, 2, 4 or 8)
+	Stride int // Distance in values between the value and the one subtracted from it
+}
+
+// Compress implements core.Compressor.
+func (d *DeltaCompressor) Compress(data []byte) ([]byte, error) {
+	return Delta(data, d.Width, d.Stride)
+}
+
+// Decompress implements core.Decompressor.
+func (d *DeltaCompressor) Decompress(data []byte) ([]byte, error) {
+	return DeltaDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (d *DeltaCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("DeltaCompressFile", inputFilePath, outputFilePath, d.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (d *DeltaCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("DeltaDecompressFile", inputFilePath, outputFilePath, d.Decompress)
+}
+
+// Factory function for creating a delta filter instance using DeltaWidth and DeltaStride.
+func NewDeltaCompressor() *DeltaCompressor {
+	return &DeltaCompressor{Width: DeltaWidth, Stride: DeltaStride}
+}
+
+// Factory function for creating a delta filter instance with the given width and stride.
+func NewDeltaCompressorWithOptions(width int, stride int) *DeltaCompressor {
+	return &DeltaCompressor{Width: width, Stride: stride}
+}
+
+// Factory function for undoing the delta filter; the width and stride are read from the data.
+func NewDeltaDecompressor() *DeltaCompressor {
+	return NewDeltaCompressor()
+}
+
+// XORCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the xor filter.
+type XORCompressor struct {
+	Stride int // Distance in bytes between the byte and the one it is xored with
+}
+
+// Compress implements core.Compressor.
+func (x *XORCompressor) Compress(data []byte) ([]byte, error) {
+	return Xor(data, x.Stride)
+}
+
+// Decompress implements core.Decompressor.
+func (x *XORCompressor) Decompress(data []byte) ([]byte, error) {
+	return XorDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (x *XORCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("XorCompressFile", inputFilePath, outputFilePath, x.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (x *XORCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("XorDecompressFile", inputFilePath, outputFilePath, x.Decompress)
+}
+
+// Factory function for creating an xor filter instance using XorStride.
+func NewXORCompressor() *XORCompressor {
+	return &XORCompressor{Stride: XorStride}
+}
+
+// Factory function for undoing the xor filter; the stride is read from the data.
+func NewXORDecompressor() *XORCompressor {
+	return NewXORCompressor()
+}
@@ -0,0 +1,180 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDeltaFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		width    int
+		stride   int
+		expected []byte
+	} {
+		{name: "Bytes", input: []byte{1, 2, 3, 5, 8}, width: 1, stride: 1, expected: []byte{1, 1, 1, 2, 3}},
+		{name: "Wrapping bytes", input: []byte{250, 4, 0}, width: 1, stride: 1, expected: []byte{250, 10, 252}},
+		{name: "Stride", input: []byte{10, 20, 11, 22, 12, 24}, width: 1, stride: 2, expected: []byte{10, 20, 1, 2, 1, 2}},
+		{name: "16-bit values with a carry", input: []byte{0xFF, 0x00, 0x00, 0x01, 0x01, 0x01}, width: 2, stride: 1, expected: []byte{0xFF, 0x00, 0x01, 0x00, 0x01, 0x00}},
+		{name: "Partial value at the end", input: []byte{1, 0, 0, 0, 3, 0, 0, 0, 7, 9}, width: 4, stride: 1, expected: []byte{1, 0, 0, 0, 2, 0, 0, 0, 7, 9}},
+		{name: "Stride longer than the data", input: []byte{1, 2, 3}, width: 1, stride: 10, expected: []byte{1, 2, 3}},
+		{name: "Empty input", input: []byte{}, width: 8, stride: 1, expected: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeltaFilter(tt.input, tt.width, tt.stride)
+			if err != nil {
+				t.Fatalf("DeltaFilter returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("DeltaFilter(%v, %d, %d) = %v, want %v", tt.input, tt.width, tt.stride, got, tt.expected)
+			}
+
+			restored, err := DeltaUnfilter(got, tt.width, tt.stride)
+			if err != nil {
+				t.Fatalf("DeltaUnfilter returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(restored, tt.input) {
+				t.Errorf("DeltaUnfilter(%v, %d, %d) = %v, want %v", got, tt.width, tt.stride, restored, tt.input)
+			}
+		})
+	}
+}
+
+func TestXorFilter(t *testing.T) {
+	input := []byte{0x0F, 0x0F, 0xF0, 0x12, 0x34}
+	expected := []byte{0x0F, 0x0F, 0xFF, 0x1D, 0xC4}
+	got, err := XorFilter(input, 2)
+	if err != nil {
+		t.Fatalf("XorFilter returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("XorFilter(%v, 2) = %v, want %v", input, got, expected)
+	}
+	restored, err := XorUnfilter(got, 2)
+	if err != nil {
+		t.Fatalf("XorUnfilter returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(restored, input) {
+		t.Errorf("XorUnfilter(%v, 2) = %v, want %v", got, restored, input)
+	}
+}
+
+func TestFilterRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	noise := make([]byte, 10001)
+	random.Read(noise)
+
+	tests := []struct {
+		name       string
+		compressor interface {
+			Compress(data []byte) ([]byte, error)
+			Decompress(data []byte) ([]byte, error)
+		}
+	} {
+		{name: "Delta bytes", compressor: NewDeltaCompressorWithOptions(1, 1)},
+		{name: "Delta 16-bit stereo", compressor: NewDeltaCompressorWithOptions(2, 2)},
+		{name: "Delta 32-bit", compressor: NewDeltaCompressorWithOptions(4, 1)},
+		{name: "Delta 64-bit, stride 3", compressor: NewDeltaCompressorWithOptions(8, 3)},
+		{name: "Xor bytes", compressor: &XORCompressor{Stride: 1}},
+		{name: "Xor 64-bit", compressor: &XORCompressor{Stride: 8}},
+		{name: "Xor long stride", compressor: &XORCompressor{Stride: 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range [][]byte{nil, []byte("x"), noise} {
+				filtered, err := tt.compressor.Compress(input)
+				if err != nil {
+					t.Fatalf("Compress returned unexpected error: %v", err)
+				}
+				got, err := tt.compressor.Decompress(filtered)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, input) {
+					t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(input))
+				}
+			}
+		})
+	}
+}
+
+func TestFiltersHelpRle(t *testing.T) {
+	// A slowly increasing 32-bit counter: rle finds no runs, but its deltas are all the same
+	counter := make([]byte, 4*10000)
+	for i := 0; i < 10000; i++ {
+		binary.LittleEndian.PutUint32(counter[4*i:], uint32(1000000+7*i))
+	}
+	// Float64 samples of a slow signal: xor with the previous sample leaves mostly zero bytes
+	samples := make([]byte, 8*10000)
+	for i := 0; i < 10000; i++ {
+		binary.LittleEndian.PutUint64(samples[8*i:], math.Float64bits(float64(i/16)*0.5))
+	}
+
+	tests := []struct {
+		name   string
+		input  []byte
+		filter func([]byte) ([]byte, error)
+		width  int
+	} {
+		{name: "Delta on a counter", input: counter, filter: NewDeltaCompressorWithOptions(4, 1).Compress, width: 4},
+		{name: "Xor on samples", input: samples, filter: (&XORCompressor{Stride: 8}).Compress, width: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := RleSymbols(tt.input, tt.width)
+			if err != nil {
+				t.Fatalf("RleSymbols returned unexpected error: %v", err)
+			}
+			filtered, err := tt.filter(tt.input)
+			if err != nil {
+				t.Fatalf("filter returned unexpected error: %v", err)
+			}
+			withFilter, err := RleSymbols(filtered, tt.width)
+			if err != nil {
+				t.Fatalf("RleSymbols returned unexpected error: %v", err)
+			}
+			if len(withFilter)*4 > len(plain) {
+				t.Errorf("RleSymbols gave %d bytes with the filter and %d without, expected at least 4 times smaller", len(withFilter), len(plain))
+			}
+		})
+	}
+}
+
+func TestFilterInvalid(t *testing.T) {
+	if _, err := Delta([]byte("abc"), 3, 1); err == nil {
+		t.Errorf("Delta with width 3 expected an error")
+	}
+	if _, err := Delta([]byte("abc"), 1, 0); err == nil {
+		t.Errorf("Delta with stride 0 expected an error")
+	}
+	if _, err := Xor([]byte("abc"), 0); err == nil {
+		t.Errorf("Xor with stride 0 expected an error")
+	}
+
+	malformed := []struct {
+		name   string
+		decode func([]byte) ([]byte, error)
+		input  []byte
+	} {
+		{name: "Delta width", decode: DeltaDecode, input: []byte{3, 1, 'a'}},
+		{name: "Delta stride 0", decode: DeltaDecode, input: []byte{1, 0, 'a'}},
+		{name: "Delta missing stride", decode: DeltaDecode, input: []byte{1}},
+		{name: "Xor stride 0", decode: XorDecode, input: []byte{0, 'a'}},
+		{name: "Xor truncated stride", decode: XorDecode, input: []byte{0x80}},
+	}
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.decode(tt.input); err == nil {
+				t.Errorf("decoding %v expected an error", tt.input)
+			}
+		})
+	}
+}
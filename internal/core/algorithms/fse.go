@@ -0,0 +1,441 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Table log (the table has 2^log states) used by NewFSECompressor (and therefore by the factories in core)
+var FseTableLog = 11
+
+const (
+	fseMinTableLog = 5    // Smallest accepted table log
+	fseMaxTableLog = 16   // Largest accepted table log
+	fseMaxSymbols  = 4096 // Largest accepted alphabet size
+)
+
+// --- // Tables
+
+// fseTable holds the normalized counts of an alphabet and the derived encoding and decoding tables.
+type fseTable struct {
+	tableLog int
+	counts   []int // Normalized counts, summing to 1 << tableLog
+
+	// Decoding: for the state L+u, the symbol, the number of bits to read and the base of the next state
+	decodeSymbol []uint16
+	decodeBits   []uint8
+	decodeBase   []uint32
+
+	// Encoding: for symbol s and y in [counts[s], 2*counts[s]), the next state is encodeState[s][y-counts[s]]
+	encodeState [][]uint32
+}
+
+// fseNormalize scales `freqs` so they sum to 1 << tableLog, keeping every used symbol at 1 or more.
+func fseNormalize(freqs []int, tableLog int) []int {
+	total := 0
+	for _, freq := range freqs {
+		total += freq
+	}
+	size := 1 << tableLog
+
+	counts := make([]int, len(freqs))
+	sum := 0
+	for s, freq := range freqs {
+		if freq == 0 {
+			continue
+		}
+		counts[s] = int((uint64(freq)*uint64(size) + uint64(total)/2) / uint64(total))
+		if counts[s] < 1 {
+			counts[s] = 1
+		}
+		sum += counts[s]
+	}
+
+	// Give or take the difference, starting with the most frequent symbols
+	order := make([]int, 0, len(freqs))
+	for s := range freqs {
+		if counts[s] > 0 {
+			order = append(order, s)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	if sum < size {
+		counts[order[0]] += size - sum
+	}
+	for sum > size {
+		for _, s := range order {
+			if sum == size {
+				break
+			}
+			if counts[s] > 1 {
+				counts[s]--
+				sum--
+			}
+		}
+	}
+	return counts
+}
+
+// newFSETable builds the encoding and decoding tables for normalized counts.
+func newFSETable(counts []int, tableLog int) (*fseTable, error) {
+	size := 1 << tableLog
+	// A count of -1 is zstd's "less than one" probability: the symbol gets a single cell at the end of the table
+	weights := make([]int, len(counts))
+	sum := 0
+	for s, count := range counts {
+		if count < -1 {
+			return nil, fmt.Errorf("malformed FSE table: negative count")
+		}
+		weights[s] = count
+		if count == -1 {
+			weights[s] = 1
+		}
+		sum += weights[s]
+	}
+	if sum != size {
+		return nil, fmt.Errorf("malformed FSE table: counts sum to %d, want %d", sum, size)
+	}
+
+	// Spread the symbols over the table, the same way zstd does
+	spread := make([]uint16, size)
+	high := size - 1
+	for s, count := range counts {
+		if count == -1 {
+			spread[high] = uint16(s)
+			high--
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, count := range counts {
+		for i := 0; i < count; i++ {
+			spread[pos] = uint16(s)
+			pos = (pos + step) & (size - 1)
+			for pos > high { // Skip the cells of the "less than one" symbols
+				pos = (pos + step) & (size - 1)
+			}
+		}
+	}
+
+	t := &fseTable{
+		tableLog:     tableLog,
+		counts:       counts,
+		decodeSymbol: spread,
+		decodeBits:   make([]uint8, size),
+		decodeBase:   make([]uint32, size),
+		encodeState:  make([][]uint32, len(counts)),
+	}
+	next := make([]int, len(counts))
+	for s, weight := range weights {
+		next[s] = weight
+		t.encodeState[s] = make([]uint32, weight)
+	}
+	for u, s := range spread {
+		y := next[s] // In [count, 2*count), increasing with u
+		next[s]++
+		nbBits := tableLog - (bits.Len(uint(y)) - 1)
+		t.decodeBits[u] = uint8(nbBits)
+		t.decodeBase[u] = uint32(y<<nbBits - size)
+		t.encodeState[s][y-weights[s]] = uint32(size + u)
+	}
+	return t, nil
+}
+
+// singleSymbol returns the symbol that fills the whole table, if there is one.
+func (t *fseTable) singleSymbol() (uint16, bool) {
+	for s, count := range t.counts {
+		if count == 1<<t.tableLog {
+			return uint16(s), true
+		}
+	}
+	return 0, false
+}
+
+// writeCounts writes the table log and the normalized counts, with runs of unused symbols stored as a count.
+func (t *fseTable) writeCounts(buffer *bytes.Buffer) {
+	var varint [binary.MaxVarintLen64]byte
+	buffer.WriteByte(byte(t.tableLog))
+	buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(len(t.counts)))])
+	for s := 0; s < len(t.counts); s++ {
+		buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(t.counts[s]))])
+		if t.counts[s] == 0 {
+			run := 0
+			for s+1 < len(t.counts) && t.counts[s+1] == 0 {
+				run++
+				s++
+			}
+			buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(run))])
+		}
+	}
+}
+
+// readFSETable reads a table written by writeCounts, returning it and the number of bytes read.
+func readFSETable(data []byte) (*fseTable, int, error) {
+	if len(data) < 1 {
+		return nil, 0, fmt.Errorf("malformed FSE table: missing table log")
+	}
+	tableLog := int(data[0])
+	if tableLog < fseMinTableLog || tableLog > fseMaxTableLog {
+		return nil, 0, fmt.Errorf("malformed FSE table: unsupported table log %d", tableLog)
+	}
+	pos := 1
+
+	readUvarint := func() (uint64, error) {
+		value, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed FSE table: invalid varint")
+		}
+		pos += n
+		return value, nil
+	}
+
+	alphabet, err := readUvarint()
+	if err != nil {
+		return nil, 0, err
+	}
+	if alphabet == 0 || alphabet > fseMaxSymbols {
+		return nil, 0, fmt.Errorf("malformed FSE table: unsupported alphabet size %d", alphabet)
+	}
+	counts := make([]int, alphabet)
+	for s := 0; s < len(counts); s++ {
+		count, err := readUvarint()
+		if err != nil {
+			return nil, 0, err
+		}
+		if count > 1<<tableLog {
+			return nil, 0, fmt.Errorf("malformed FSE table: count %d is too large", count)
+		}
+		counts[s] = int(count)
+		if count == 0 {
+			run, err := readUvarint()
+			if err != nil {
+				return nil, 0, err
+			}
+			if run > uint64(len(counts)-s-1) {
+				return nil, 0, fmt.Errorf("malformed FSE table: run of unused symbols is too long")
+			}
+			s += int(run)
+		}
+	}
+
+	t, err := newFSETable(counts, tableLog)
+	if err != nil {
+		return nil, 0, err
+	}
+	return t, pos, nil
+}
+
+// --- // Symbol Stage
+
+// FseEncodeSymbols encodes symbols of any alphabet (up to 4096 symbols) with a table-based asymmetric numeral system coder.
+// Other algorithms can use it as their entropy coding stage; FseDecodeSymbols gives the symbols back.
+// The output holds the number of symbols (as a uvarint), the normalized symbol counts and the bit stream.
+func FseEncodeSymbols(symbols []uint16, tableLog int) ([]byte, error) {
+	if tableLog < fseMinTableLog || tableLog > fseMaxTableLog {
+		return nil, fmt.Errorf("invalid FSE table log %d (must be between %d and %d)", tableLog, fseMinTableLog, fseMaxTableLog)
+	}
+
+	var buffer bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+	buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(len(symbols)))])
+	if len(symbols) == 0 {
+		return buffer.Bytes(), nil
+	}
+
+	// Count the symbols and make sure the table can hold all of them
+	alphabet := 0
+	for _, symbol := range symbols {
+		if int(symbol) >= alphabet {
+			alphabet = int(symbol) + 1
+		}
+	}
+	if alphabet > fseMaxSymbols {
+		return nil, fmt.Errorf("FSE symbol %d is outside the supported alphabet of %d symbols", alphabet-1, fseMaxSymbols)
+	}
+	freqs := make([]int, alphabet)
+	used := 0
+	for _, symbol := range symbols {
+		if freqs[symbol] == 0 {
+			used++
+		}
+		freqs[symbol]++
+	}
+	for used > 1<<tableLog {
+		tableLog++
+	}
+
+	t, err := newFSETable(fseNormalize(freqs, tableLog), tableLog)
+	if err != nil {
+		return nil, err
+	}
+	t.writeCounts(&buffer)
+
+	// Encode backwards, remembering the bits of every step, so the decoder can run forwards
+	type chunk struct {
+		value uint32
+		nbits uint
+	}
+	chunks := make([]chunk, 0, len(symbols))
+	size := uint32(1) << tableLog
+	state := size
+	for i := len(symbols) - 1; i >= 0; i-- {
+		s := symbols[i]
+		count := uint32(t.counts[s])
+		nbits := uint(0)
+		for state>>nbits >= 2*count {
+			nbits++
+		}
+		chunks = append(chunks, chunk{value: state & (1<<nbits - 1), nbits: nbits})
+		state = t.encodeState[s][state>>nbits-count]
+	}
+
+	w := &bitWriter{}
+	w.writeBits(state-size, uint(tableLog))
+	for i := len(chunks) - 1; i >= 0; i-- {
+		w.writeBits(chunks[i].value, chunks[i].nbits)
+	}
+	buffer.Write(w.bytes())
+
+	return buffer.Bytes(), nil
+}
+
+// FseDecodeSymbols decodes symbols written by FseEncodeSymbols, returning them and the number of bytes read.
+func FseDecodeSymbols(data []byte) ([]uint16, int, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, 0, fmt.Errorf("malformed FSE data: invalid symbol count")
+	}
+	if count == 0 {
+		return nil, n, nil
+	}
+	pos := n
+
+	t, n, err := readFSETable(data[pos:])
+	if err != nil {
+		return nil, 0, err
+	}
+	pos += n
+
+	// Never trust the header for the allocation size: the count is only bounded by the decoded size limit, and the
+	// output grows as symbols are decoded, so a corrupt count runs out of bits before it runs out of memory
+	if count > uint64(MaxDecodedSize) {
+		return nil, 0, fmt.Errorf("malformed FSE data: symbol count %d exceeds the decoded size limit of %d", count, MaxDecodedSize)
+	}
+
+	r := newBitReader(data[pos:])
+	state, err := r.readBits(uint(t.tableLog))
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed FSE data: %w", err)
+	}
+
+	var symbols []uint16
+	if symbol, ok := t.singleSymbol(); ok {
+		// The symbol fills the whole table, so every state decodes it with zero bits and the stream is only the first state
+		symbols = make([]uint16, count)
+		for i := range symbols {
+			symbols[i] = symbol
+		}
+	} else {
+		capacity := count
+		if limit := uint64(len(data)-pos) * 8; capacity > limit {
+			capacity = limit
+		}
+		symbols = make([]uint16, 0, capacity)
+		for uint64(len(symbols)) < count {
+			symbols = append(symbols, t.decodeSymbol[state])
+			rest, err := r.readBits(uint(t.decodeBits[state]))
+			if err != nil {
+				return nil, 0, fmt.Errorf("malformed FSE data: %w", err)
+			}
+			state = t.decodeBase[state] + rest
+		}
+	}
+
+	consumed := r.pos
+	if r.bit > 0 {
+		consumed++
+	}
+	return symbols, pos + consumed, nil
+}
+
+// --- // FSE Encoding
+
+// Fse compresses data with the tANS coder, treating every byte as a symbol.
+func Fse(data []byte, tableLog int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	symbols := make([]uint16, len(data))
+	for i, b := range data {
+		symbols[i] = uint16(b)
+	}
+	return FseEncodeSymbols(symbols, tableLog)
+}
+
+// --- // FSE Decoding
+
+// FseDecode decodes data produced by Fse.
+func FseDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	symbols, _, err := FseDecodeSymbols(data)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, len(symbols))
+	for i, symbol := range symbols {
+		if symbol > 0xFF {
+			return nil, fmt.Errorf("malformed FSE data: symbol %d is not a byte", symbol)
+		}
+		output[i] = byte(symbol)
+	}
+	return output, nil
+}
+
+// --- // FSE Compressor Interface
+
+// FSECompressor implements core.GeneralCompressor and core.GeneralDecompressor with Finite State Entropy (tANS) coding.
+type FSECompressor struct {
+	TableLog int // The coding table has 2^TableLog states
+}
+
+// Compress implements core.Compressor.
+func (f *FSECompressor) Compress(data []byte) ([]byte, error) {
+	return Fse(data, f.TableLog)
+}
+
+// Decompress implements core.Decompressor.
+func (f *FSECompressor) Decompress(data []byte) ([]byte, error) {
+	return FseDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (f *FSECompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("FseCompressFile", inputFilePath, outputFilePath, f.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (f *FSECompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("FseDecompressFile", inputFilePath, outputFilePath, f.Decompress)
+}
+
+// Factory function for creating an FSE compressor instance using FseTableLog.
+func NewFSECompressor() *FSECompressor {
+	return NewFSECompressorWithTableLog(FseTableLog)
+}
+
+// Factory function for creating an FSE compressor instance with a custom table log.
+func NewFSECompressorWithTableLog(tableLog int) *FSECompressor {
+	return &FSECompressor{TableLog: tableLog}
+}
+
+// Factory function for creating an FSE decompressor instance.
+func NewFSEDecompressor() *FSECompressor {
+	return NewFSECompressor()
+}
@@ -0,0 +1,111 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestFseRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := make([]byte, 10000)
+	random.Read(noise)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		tableLog int
+	} {
+		{name: "Empty input", input: nil, tableLog: 11},
+		{name: "Single character", input: []byte("A"), tableLog: 11},
+		{name: "Single repeated character", input: bytes.Repeat([]byte{'A'}, 1000), tableLog: 11},
+		{name: "Long run of one symbol", input: make([]byte, 100000), tableLog: 11}, // Zero bits per symbol
+		{name: "Text", input: bytes.Repeat([]byte("peter piper picked a peck of pickled peppers "), 40), tableLog: 11},
+		{name: "Random bytes, small table", input: noise, tableLog: 5},
+		{name: "Random bytes, large table", input: noise, tableLog: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Fse(tt.input, tt.tableLog)
+			if err != nil {
+				t.Fatalf("Fse returned unexpected error: %v", err)
+			}
+
+			got, err := FseDecode(compressed)
+			if err != nil {
+				t.Fatalf("FseDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("FseDecode(Fse(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestFseSymbolStage(t *testing.T) {
+	// A larger alphabet than bytes, followed by other data the caller keeps for itself
+	random := rand.New(rand.NewSource(2))
+	symbols := make([]uint16, 5000)
+	for i := range symbols {
+		symbols[i] = uint16(random.ExpFloat64() * 40) % 1000
+	}
+
+	encoded, err := FseEncodeSymbols(symbols, 11)
+	if err != nil {
+		t.Fatalf("FseEncodeSymbols returned unexpected error: %v", err)
+	}
+	stream := append(append([]byte(nil), encoded...), "trailer"...)
+
+	got, n, err := FseDecodeSymbols(stream)
+	if err != nil {
+		t.Fatalf("FseDecodeSymbols returned unexpected error: %v", err)
+	}
+	if n != len(encoded) {
+		t.Errorf("FseDecodeSymbols read %d bytes, want %d", n, len(encoded))
+	}
+	if len(got) != len(symbols) {
+		t.Fatalf("FseDecodeSymbols returned %d symbols, want %d", len(got), len(symbols))
+	}
+	for i := range symbols {
+		if got[i] != symbols[i] {
+			t.Fatalf("symbol %d = %d, want %d", i, got[i], symbols[i])
+		}
+	}
+}
+
+func TestFseCompressesSkewedData(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	input := make([]byte, 100000)
+	for i := range input {
+		input[i] = byte(random.ExpFloat64() * 4)
+	}
+
+	compressed, err := Fse(input, 11)
+	if err != nil {
+		t.Fatalf("Fse returned unexpected error: %v", err)
+	}
+	huffman, err := Huffman(input)
+	if err != nil {
+		t.Fatalf("Huffman returned unexpected error: %v", err)
+	}
+	if len(compressed) > len(huffman) {
+		t.Errorf("Fse output is %d bytes, expected no more than Huffman's %d", len(compressed), len(huffman))
+	}
+}
+
+func TestFseInvalid(t *testing.T) {
+	if _, err := Fse([]byte("abc"), 4); err == nil {
+		t.Errorf("Fse with table log 4 expected an error")
+	}
+	if _, err := FseEncodeSymbols([]uint16{5000}, 11); err == nil {
+		t.Errorf("FseEncodeSymbols with symbol 5000 expected an error")
+	}
+	if _, err := FseDecode([]byte{0x80, 0x80, 0x80, 0x80, 0x08, 11, 1, 0x80, 0x10, 0}); err == nil { // 2^31 symbols
+		t.Errorf("FseDecode with a symbol count beyond the decoded size limit expected an error")
+	}
+	if _, err := FseDecode([]byte{3, 11, 2, 100, 0}); err == nil {
+		t.Errorf("FseDecode with counts not summing to the table size expected an error")
+	}
+}
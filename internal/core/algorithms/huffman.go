@@ -0,0 +1,315 @@
+package algorithms
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+)
+
+// Longest code length the canonical Huffman coder will produce
+const huffmanMaxCodeLength = 15
+
+// --- // Code Length Construction
+
+// Node of the Huffman tree, only used while computing code lengths
+type huffmanNode struct {
+	freq   int
+	symbol int // Symbol for leaves, -1 for internal nodes
+	left   *huffmanNode
+	right  *huffmanNode
+}
+
+// Min-heap of Huffman nodes, ordered by frequency (ties broken by symbol for determinism)
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].symbol < h[j].symbol
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
+
+// huffmanCodeLengths computes a code length for every symbol of `freqs` (0 for unused symbols), never exceeding `maxLength`.
+func huffmanCodeLengths(freqs []int, maxLength int) []uint8 {
+	lengths := make([]uint8, len(freqs))
+	scaled := append([]int(nil), freqs...)
+
+	for {
+		h := &huffmanHeap{}
+		for symbol, freq := range scaled {
+			if freq > 0 {
+				heap.Push(h, &huffmanNode{freq: freq, symbol: symbol})
+			}
+		}
+
+		switch h.Len() {
+		case 0:
+			return lengths
+		case 1: // A single symbol still needs a one-bit code
+			lengths[(*h)[0].symbol] = 1
+			return lengths
+		}
+
+		// Merge the two least frequent nodes until only the root is left
+		for h.Len() > 1 {
+			a := heap.Pop(h).(*huffmanNode)
+			b := heap.Pop(h).(*huffmanNode)
+			heap.Push(h, &huffmanNode{freq: a.freq + b.freq, symbol: -1, left: a, right: b})
+		}
+
+		// Walk the tree to find the depth of each leaf
+		longest := 0
+		var walk func(node *huffmanNode, depth int)
+		walk = func(node *huffmanNode, depth int) {
+			if node.left == nil {
+				lengths[node.symbol] = uint8(depth)
+				if depth > longest {
+					longest = depth
+				}
+				return
+			}
+			walk(node.left, depth+1)
+			walk(node.right, depth+1)
+		}
+		walk((*h)[0], 0)
+
+		if longest <= maxLength {
+			return lengths
+		}
+
+		// Flatten the distribution and try again
+		for i := range scaled {
+			if scaled[i] > 0 {
+				scaled[i] = (scaled[i] + 1) / 2
+			}
+		}
+	}
+}
+
+// huffmanCanonicalCodes assigns canonical codes to the given code lengths.
+func huffmanCanonicalCodes(lengths []uint8) []uint32 {
+	var counts [huffmanMaxCodeLength + 2]uint32
+	for _, length := range lengths {
+		counts[length]++
+	}
+	counts[0] = 0
+
+	var next [huffmanMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for length := 1; length <= huffmanMaxCodeLength+1; length++ {
+		code = (code + counts[length-1]) << 1
+		next[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length != 0 {
+			codes[symbol] = next[length]
+			next[length]++
+		}
+	}
+	return codes
+}
+
+// --- // Canonical Decoding
+
+// huffmanDecoder decodes symbols of a canonical Huffman code.
+type huffmanDecoder struct {
+	counts  [huffmanMaxCodeLength + 1]int // Number of codes of each length
+	symbols []int                         // Symbols ordered by (length, symbol)
+}
+
+// newHuffmanDecoder builds a decoder from a table of code lengths.
+func newHuffmanDecoder(lengths []uint8) (*huffmanDecoder, error) {
+	d := &huffmanDecoder{}
+	for symbol, length := range lengths {
+		if int(length) > huffmanMaxCodeLength {
+			return nil, fmt.Errorf("malformed Huffman table: code length %d for symbol %d", length, symbol)
+		}
+		d.counts[length]++
+	}
+	d.counts[0] = 0
+
+	// Reject over-subscribed tables, which cannot come from a real prefix code
+	left := 1
+	for length := 1; length <= huffmanMaxCodeLength; length++ {
+		left <<= 1
+		left -= d.counts[length]
+		if left < 0 {
+			return nil, fmt.Errorf("malformed Huffman table: over-subscribed code lengths")
+		}
+	}
+
+	for length := 1; length <= huffmanMaxCodeLength; length++ {
+		for symbol, l := range lengths {
+			if int(l) == length {
+				d.symbols = append(d.symbols, symbol)
+			}
+		}
+	}
+	return d, nil
+}
+
+// decode reads one symbol from `r`.
+func (d *huffmanDecoder) decode(r *bitReader) (int, error) {
+	code, first, index := 0, 0, 0
+	for length := 1; length <= huffmanMaxCodeLength; length++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		code |= int(bit)
+		count := d.counts[length]
+		if code-first < count {
+			return d.symbols[index+code-first], nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, fmt.Errorf("malformed Huffman data: invalid code")
+}
+
+// --- // Huffman Encoding
+
+// Huffman encodes data with a canonical Huffman code, returning a byte slice.
+// The output starts with the uncompressed length (as a uvarint), followed by the number of used symbols minus one,
+// a (symbol, code length) pair for each used symbol, and finally the packed codes.
+func Huffman(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	freqs := make([]int, 256)
+	for _, b := range data {
+		freqs[b]++
+	}
+	lengths := huffmanCodeLengths(freqs, huffmanMaxCodeLength)
+	codes := huffmanCanonicalCodes(lengths)
+
+	var buffer bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	buffer.Write(header[:binary.PutUvarint(header[:], uint64(len(data)))])
+
+	// Write the code length table
+	used := 0
+	for _, length := range lengths {
+		if length != 0 {
+			used++
+		}
+	}
+	buffer.WriteByte(byte(used - 1))
+	for symbol, length := range lengths {
+		if length != 0 {
+			buffer.WriteByte(byte(symbol))
+			buffer.WriteByte(length)
+		}
+	}
+	verbosePrintf("Huffman: used symbols: %v\n", used)
+
+	// Write the codes
+	w := &bitWriter{}
+	for _, b := range data {
+		w.writeBits(codes[b], uint(lengths[b]))
+	}
+	buffer.Write(w.bytes())
+
+	return buffer.Bytes(), nil
+}
+
+// --- // Huffman Decoding
+
+// HuffmanDecode decodes data produced by Huffman.
+func HuffmanDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("malformed Huffman data: invalid length header")
+	}
+	data = data[n:]
+
+	// Read the code length table
+	if len(data) < 1 {
+		return nil, fmt.Errorf("malformed Huffman data: missing code length table")
+	}
+	used := int(data[0]) + 1
+	data = data[1:]
+	if len(data) < used*2 {
+		return nil, fmt.Errorf("malformed Huffman data: truncated code length table")
+	}
+	lengths := make([]uint8, 256)
+	for i := 0; i < used; i++ {
+		lengths[data[i*2]] = data[i*2+1]
+	}
+	data = data[used*2:]
+
+	decoder, err := newHuffmanDecoder(lengths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never trust the header for the allocation size; each symbol needs at least one bit
+	if size > uint64(len(data))*8 {
+		return nil, fmt.Errorf("malformed Huffman data: length %d exceeds the encoded data", size)
+	}
+
+	output := make([]byte, 0, size)
+	r := newBitReader(data)
+	for uint64(len(output)) < size {
+		symbol, err := decoder.decode(r)
+		if err != nil {
+			return nil, fmt.Errorf("malformed Huffman data: %w", err)
+		}
+		output = append(output, byte(symbol))
+	}
+
+	return output, nil
+}
+
+// --- // Huffman Compressor Interface
+
+// HuffmanCompressor implements core.GeneralCompressor and core.GeneralDecompressor with canonical Huffman coding.
+type HuffmanCompressor struct{}
+
+// Compress implements core.Compressor.
+func (h *HuffmanCompressor) Compress(data []byte) ([]byte, error) {
+	return Huffman(data)
+}
+
+// Decompress implements core.Decompressor.
+func (h *HuffmanCompressor) Decompress(data []byte) ([]byte, error) {
+	return HuffmanDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (h *HuffmanCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("HuffmanCompressFile", inputFilePath, outputFilePath, h.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (h *HuffmanCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("HuffmanDecompressFile", inputFilePath, outputFilePath, h.Decompress)
+}
+
+// Factory function for creating a Huffman compressor instance.
+func NewHuffmanCompressor() *HuffmanCompressor {
+	return &HuffmanCompressor{}
+}
+
+// Factory function for creating a Huffman decompressor instance.
+func NewHuffmanDecompressor() *HuffmanCompressor {
+	return &HuffmanCompressor{}
+}
@@ -0,0 +1,114 @@
+package algorithms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHuffmanRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Empty input",
+			input: nil,
+		},
+		{
+			name:  "Single character",
+			input: []byte("A"),
+		},
+		{
+			name:  "Single repeated character",
+			input: bytes.Repeat([]byte{'A'}, 1000),
+		},
+		{
+			name:  "Text",
+			input: []byte("this is an example of a huffman tree"),
+		},
+		{
+			name:  "All byte values",
+			input: func() []byte {
+				data := make([]byte, 256*4)
+				for i := range data {
+					data[i] = byte(i)
+				}
+				return data
+			}(),
+		},
+		{
+			name:  "Skewed (length limiting)",
+			input: func() []byte {
+				// Fibonacci-like frequencies would give codes longer than huffmanMaxCodeLength
+				var data []byte
+				a, b := 1, 1
+				for symbol := 0; symbol < 24; symbol++ {
+					data = append(data, bytes.Repeat([]byte{byte(symbol)}, a)...)
+					a, b = b, a+b
+				}
+				return data
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Huffman(tt.input)
+			if err != nil {
+				t.Fatalf("Huffman returned unexpected error: %v", err)
+			}
+
+			got, err := HuffmanDecode(compressed)
+			if err != nil {
+				t.Fatalf("HuffmanDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("HuffmanDecode(Huffman(x)) = %v, want %v", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestHuffmanCompresses(t *testing.T) {
+	input := bytes.Repeat([]byte("aaaaaaabbbc"), 100)
+	compressed, err := Huffman(input)
+	if err != nil {
+		t.Fatalf("Huffman returned unexpected error: %v", err)
+	}
+	if len(compressed) >= len(input)/2 {
+		t.Errorf("Huffman output is %d bytes, expected less than %d", len(compressed), len(input)/2)
+	}
+}
+
+func TestHuffmanDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Missing table",
+			input: []byte{5},
+		},
+		{
+			name:  "Truncated table",
+			input: []byte{5, 1, 'A'},
+		},
+		{
+			name:  "Over-subscribed table",
+			input: []byte{1, 2, 'A', 1, 'B', 1, 'C', 1, 0},
+		},
+		{
+			name:  "Length exceeds data",
+			input: []byte{100, 0, 'A', 1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HuffmanDecode(tt.input); err == nil {
+				t.Errorf("HuffmanDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
@@ -2,11 +2,32 @@ package algorithms
 
 import "fmt"
 
-var Algorithms = []string{ "rle" } // List of names of available (implemented) compression algorithms
+var Algorithms = []string{ "rle", "huffman", "lzss", "gzip", "zlib", "deflate", "lzw", "bwt", "packbits", "bitrle", "range", "fse", "lz4", "snappy", "xz", "zstd", "delta", "xor", "bcj-x86", "bcj-arm64", "bcj-armthumb", "ppm" } // List of names of available (implemented) compression algorithms
 var ImplementedAlgorithms = len(Algorithms) // Number of implemented algorithms
 
 const ( // Constant integers for each algorithm; each one is aligned with its name in the Algorithms array
-	RLEAlgorithm = iota // Run-Length Encoding
+	RLEAlgorithm = iota  // Run-Length Encoding
+	HuffmanAlgorithm     // Canonical Huffman Coding
+	LZSSAlgorithm        // LZSS (sliding-window LZ77)
+	GzipAlgorithm        // gzip (DEFLATE in a gzip container)
+	ZlibAlgorithm        // zlib (DEFLATE in a zlib container)
+	DeflateAlgorithm     // Raw DEFLATE
+	LZWAlgorithm         // LZW (Unix compress, .Z)
+	BWTAlgorithm         // Burrows-Wheeler Transform (bzip2-style)
+	PackBitsAlgorithm    // PackBits (TIFF/Apple RLE)
+	BitRLEAlgorithm      // Bit-level Run-Length Encoding
+	RangeAlgorithm       // Adaptive Binary Range Coding
+	FSEAlgorithm         // Finite State Entropy (tANS)
+	LZ4Algorithm         // LZ4 (frame format)
+	SnappyAlgorithm      // Snappy (framing format)
+	XZAlgorithm          // xz (LZMA2)
+	ZstdAlgorithm        // Zstandard
+	DeltaAlgorithm       // Delta filter
+	XORAlgorithm         // XOR filter
+	BCJX86Algorithm      // BCJ x86 filter
+	BCJARM64Algorithm    // BCJ ARM64 filter
+	BCJARMThumbAlgorithm // BCJ ARM Thumb filter
+	PPMAlgorithm         // Prediction by Partial Matching
 )
 
 // Print the names of all available compression algorithms
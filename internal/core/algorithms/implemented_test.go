@@ -15,6 +15,111 @@ func TestOrderOfAlgorithms(t *testing.T) {
 			input: RLEAlgorithm,
 			expected: "rle",
 		},
+		{
+			name:  "Huffman Coding",
+			input: HuffmanAlgorithm,
+			expected: "huffman",
+		},
+		{
+			name:  "LZSS (sliding-window LZ77)",
+			input: LZSSAlgorithm,
+			expected: "lzss",
+		},
+		{
+			name:  "gzip (DEFLATE in a gzip container)",
+			input: GzipAlgorithm,
+			expected: "gzip",
+		},
+		{
+			name:  "zlib (DEFLATE in a zlib container)",
+			input: ZlibAlgorithm,
+			expected: "zlib",
+		},
+		{
+			name:  "Raw DEFLATE",
+			input: DeflateAlgorithm,
+			expected: "deflate",
+		},
+		{
+			name:  "LZW (Unix compress, .Z)",
+			input: LZWAlgorithm,
+			expected: "lzw",
+		},
+		{
+			name:  "Burrows-Wheeler Transform (bzip2-style)",
+			input: BWTAlgorithm,
+			expected: "bwt",
+		},
+		{
+			name:  "PackBits (TIFF/Apple RLE)",
+			input: PackBitsAlgorithm,
+			expected: "packbits",
+		},
+		{
+			name:  "Bit-level Run-Length Encoding",
+			input: BitRLEAlgorithm,
+			expected: "bitrle",
+		},
+		{
+			name:  "Adaptive Binary Range Coding",
+			input: RangeAlgorithm,
+			expected: "range",
+		},
+		{
+			name:  "Finite State Entropy (tANS)",
+			input: FSEAlgorithm,
+			expected: "fse",
+		},
+		{
+			name:  "LZ4 (frame format)",
+			input: LZ4Algorithm,
+			expected: "lz4",
+		},
+		{
+			name:  "Snappy (framing format)",
+			input: SnappyAlgorithm,
+			expected: "snappy",
+		},
+		{
+			name:  "xz (LZMA2)",
+			input: XZAlgorithm,
+			expected: "xz",
+		},
+		{
+			name:  "Zstandard",
+			input: ZstdAlgorithm,
+			expected: "zstd",
+		},
+		{
+			name:  "Delta filter",
+			input: DeltaAlgorithm,
+			expected: "delta",
+		},
+		{
+			name:  "XOR filter",
+			input: XORAlgorithm,
+			expected: "xor",
+		},
+		{
+			name:  "BCJ x86 filter",
+			input: BCJX86Algorithm,
+			expected: "bcj-x86",
+		},
+		{
+			name:  "BCJ ARM64 filter",
+			input: BCJARM64Algorithm,
+			expected: "bcj-arm64",
+		},
+		{
+			name:  "BCJ ARM Thumb filter",
+			input: BCJARMThumbAlgorithm,
+			expected: "bcj-armthumb",
+		},
+		{
+			name:  "Prediction by Partial Matching",
+			input: PPMAlgorithm,
+			expected: "ppm",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,477 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Options for LZ4 frames, used by NewLZ4Compressor (and therefore by the factories in core)
+
+var Lz4BlockChecksum = false // Whether every block is followed by its xxHash32 (the content checksum is always written)
+
+const (
+	lz4FrameMagic         = 0x184D2204 // Magic number of an LZ4 frame
+	lz4SkippableMagicMask = 0xFFFFFFF0 // Skippable frames use the magic numbers 0x184D2A50 to 0x184D2A5F
+	lz4SkippableMagic     = 0x184D2A50
+	lz4MinMatch           = 4          // Shortest match of the block format
+	lz4MaxOffset          = 65535      // Farthest match of the block format
+	lz4LastLiterals       = 5          // The last bytes of a block are always literals
+	lz4MatchFindLimit     = 12         // The last match must start at least this many bytes before the end of a block
+	lz4HashLog            = 16         // Size of the block compressor's hash table
+	lz4UncompressedBit    = 0x80000000 // Block size flag marking a block stored uncompressed
+	lz4DefaultBlockSizeID = 7          // 4 MB blocks
+
+	// Frame descriptor flags
+	lz4FlagVersion         = 0x40
+	lz4FlagVersionMask     = 0xC0
+	lz4FlagBlockIndep      = 0x20
+	lz4FlagBlockChecksum   = 0x10
+	lz4FlagContentSize     = 0x08
+	lz4FlagContentChecksum = 0x04
+	lz4FlagReserved        = 0x02
+	lz4FlagDictID          = 0x01
+)
+
+// Maximum block sizes for the block size IDs 4 to 7
+var lz4BlockSizes = map[byte]int{4: 64 << 10, 5: 256 << 10, 6: 1 << 20, 7: 4 << 20}
+
+// --- // LZ4 Block Encoding
+
+// lz4WriteLength writes the part of a length that did not fit in its token nibble.
+func lz4WriteLength(buffer *bytes.Buffer, length int) {
+	for ; length >= 255; length -= 255 {
+		buffer.WriteByte(255)
+	}
+	buffer.WriteByte(byte(length))
+}
+
+// lz4WriteSequence writes literals followed by a match (or only literals when matchLength is 0).
+func lz4WriteSequence(buffer *bytes.Buffer, literals []byte, offset int, matchLength int) {
+	token := byte(0)
+	if len(literals) >= 15 {
+		token = 15 << 4
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	if matchLength > 0 {
+		if matchLength-lz4MinMatch >= 15 {
+			token |= 15
+		} else {
+			token |= byte(matchLength - lz4MinMatch)
+		}
+	}
+
+	buffer.WriteByte(token)
+	if len(literals) >= 15 {
+		lz4WriteLength(buffer, len(literals)-15)
+	}
+	buffer.Write(literals)
+	if matchLength > 0 {
+		buffer.WriteByte(byte(offset))
+		buffer.WriteByte(byte(offset >> 8))
+		if matchLength-lz4MinMatch >= 15 {
+			lz4WriteLength(buffer, matchLength-lz4MinMatch-15)
+		}
+	}
+}
+
+// Lz4Block compresses data into a single raw LZ4 block (without a frame).
+func Lz4Block(data []byte) []byte {
+	return lz4CompressBlock(data, 0)
+}
+
+// lz4CompressBlock compresses data[start:] into a raw LZ4 block. The bytes before `start` (a dictionary) are not
+// part of the block, but matches may reach into them.
+func lz4CompressBlock(data []byte, start int) []byte {
+	var buffer bytes.Buffer
+	n := len(data)
+	anchor := start
+
+	if n-start >= lz4MatchFindLimit+1 {
+		table := make([]int32, 1<<lz4HashLog) // Position + 1 of the last occurrence of each hashed 4-byte sequence
+		for i := 0; i+4 <= start; i++ {
+			table[binary.LittleEndian.Uint32(data[i:])*2654435761>>(32-lz4HashLog)] = int32(i + 1)
+		}
+		matchLimit := n - lz4MatchFindLimit
+		for i := start; i < matchLimit; {
+			sequence := binary.LittleEndian.Uint32(data[i:])
+			h := (sequence * 2654435761) >> (32 - lz4HashLog)
+			ref := int(table[h]) - 1
+			table[h] = int32(i + 1)
+
+			if ref < 0 || i-ref > lz4MaxOffset || binary.LittleEndian.Uint32(data[ref:]) != sequence {
+				i++
+				continue
+			}
+
+			// Extend the match forwards, then backwards over pending literals
+			length := lz4MinMatch
+			for i+length < n-lz4LastLiterals && data[ref+length] == data[i+length] {
+				length++
+			}
+			for i > anchor && ref > 0 && data[i-1] == data[ref-1] {
+				i, ref, length = i-1, ref-1, length+1
+			}
+
+			lz4WriteSequence(&buffer, data[anchor:i], i-ref, length)
+			i += length
+			anchor = i
+		}
+	}
+
+	lz4WriteSequence(&buffer, data[anchor:], 0, 0) // Last literals
+	return buffer.Bytes()
+}
+
+// --- // LZ4 Block Decoding
+
+// lz4ReadLength reads the rest of a length whose token nibble was 15.
+func lz4ReadLength(src []byte, pos int) (int, int, error) {
+	length := 0
+	for {
+		if pos >= len(src) {
+			return 0, 0, fmt.Errorf("malformed LZ4 block: truncated length")
+		}
+		b := src[pos]
+		pos++
+		length += int(b)
+		if b != 255 {
+			return length, pos, nil
+		}
+	}
+}
+
+// lz4DecodeBlockInto decodes the raw LZ4 block `src`, appending at most `maxSize` bytes to `dst`.
+// Matches may reach back into the bytes already in `dst`, which is how linked blocks and dictionaries work.
+func lz4DecodeBlockInto(dst []byte, src []byte, maxSize int) ([]byte, error) {
+	limit := len(dst) + maxSize
+	for pos := 0; pos < len(src); {
+		token := src[pos]
+		pos++
+
+		// Literals
+		literals := int(token >> 4)
+		if literals == 15 {
+			extra, next, err := lz4ReadLength(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			literals += extra
+			pos = next
+		}
+		if literals > len(src)-pos {
+			return nil, fmt.Errorf("malformed LZ4 block: %d literals run past the end of the block", literals)
+		}
+		if literals > limit-len(dst) {
+			return nil, fmt.Errorf("malformed LZ4 block: decoded data exceeds %d bytes", maxSize)
+		}
+		dst = append(dst, src[pos:pos+literals]...)
+		pos += literals
+
+		if pos == len(src) { // The last sequence has no match
+			break
+		}
+
+		// Match
+		if pos+2 > len(src) {
+			return nil, fmt.Errorf("malformed LZ4 block: truncated match offset")
+		}
+		offset := int(src[pos]) | int(src[pos+1])<<8
+		pos += 2
+		length := int(token&15) + lz4MinMatch
+		if token&15 == 15 {
+			extra, next, err := lz4ReadLength(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			length += extra
+			pos = next
+		}
+		if offset == 0 || offset > len(dst) {
+			return nil, fmt.Errorf("malformed LZ4 block: invalid match offset %d", offset)
+		}
+		if length > limit-len(dst) {
+			return nil, fmt.Errorf("malformed LZ4 block: decoded data exceeds %d bytes", maxSize)
+		}
+		from := len(dst) - offset
+		for j := 0; j < length; j++ { // Byte by byte, since matches may overlap their own output
+			dst = append(dst, dst[from+j])
+		}
+	}
+	return dst, nil
+}
+
+// Lz4BlockDecode decodes a single raw LZ4 block that decompresses to at most `maxSize` bytes.
+func Lz4BlockDecode(data []byte, maxSize int) ([]byte, error) {
+	return lz4DecodeBlockInto(nil, data, maxSize)
+}
+
+// --- // LZ4 Frame Encoding
+
+// Lz4 compresses data into an LZ4 frame with independent 4 MB blocks, the content size and the content checksum,
+// readable by the reference `lz4` tool. With `blockChecksum`, every block is followed by its checksum too.
+func Lz4(data []byte, blockChecksum bool) ([]byte, error) {
+	return Lz4WithDictionary(data, blockChecksum, nil)
+}
+
+// Lz4WithDictionary compresses data like Lz4, but every block may also refer to the last 64 KB of `dictionary`.
+// The DictionaryID goes in the frame descriptor; `lz4 -d -D <dictionary>` reads the frame too.
+func Lz4WithDictionary(data []byte, blockChecksum bool, dictionary []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	var word [8]byte
+
+	// Frame descriptor
+	binary.LittleEndian.PutUint32(word[:], lz4FrameMagic)
+	buffer.Write(word[:4])
+	flags := byte(lz4FlagVersion | lz4FlagBlockIndep | lz4FlagContentSize | lz4FlagContentChecksum)
+	if blockChecksum {
+		flags |= lz4FlagBlockChecksum
+	}
+	if len(dictionary) > 0 {
+		flags |= lz4FlagDictID
+	}
+	descriptor := []byte{flags, lz4DefaultBlockSizeID << 4}
+	binary.LittleEndian.PutUint64(word[:], uint64(len(data)))
+	descriptor = append(descriptor, word[:8]...)
+	if len(dictionary) > 0 {
+		descriptor = binary.LittleEndian.AppendUint32(descriptor, DictionaryID(dictionary))
+	}
+	buffer.Write(descriptor)
+	buffer.WriteByte(byte(xxHash32(descriptor, 0) >> 8))
+
+	// Blocks, each with the reachable part of the dictionary in front
+	blockSize := lz4BlockSizes[lz4DefaultBlockSizeID]
+	prefix := dictionaryTail(dictionary, lz4MaxOffset)
+	for start := 0; start < len(data); start += blockSize {
+		end := start + blockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		block := data[start:end]
+		if len(prefix) > 0 {
+			block = append(append(make([]byte, 0, len(prefix)+len(block)), prefix...), block...)
+		}
+		block = lz4CompressBlock(block, len(prefix))
+		header := uint32(len(block))
+		if len(block) >= end-start { // Not worth it; store the block as it is
+			block = data[start:end]
+			header = uint32(len(block)) | lz4UncompressedBit
+		}
+		verbosePrintf("Lz4: block at %v: %v -> %v bytes\n", start, end-start, len(block))
+
+		binary.LittleEndian.PutUint32(word[:], header)
+		buffer.Write(word[:4])
+		buffer.Write(block)
+		if blockChecksum {
+			binary.LittleEndian.PutUint32(word[:], xxHash32(block, 0))
+			buffer.Write(word[:4])
+		}
+	}
+
+	// End mark and content checksum
+	binary.LittleEndian.PutUint32(word[:], 0)
+	buffer.Write(word[:4])
+	binary.LittleEndian.PutUint32(word[:], xxHash32(data, 0))
+	buffer.Write(word[:4])
+
+	return buffer.Bytes(), nil
+}
+
+// --- // LZ4 Frame Decoding
+
+// Lz4Decode decompresses one or more concatenated LZ4 frames, skipping skippable frames.
+// Block and content checksums are verified when the frame has them.
+func Lz4Decode(data []byte) ([]byte, error) {
+	return Lz4DecodeWithDictionary(data, nil)
+}
+
+// Lz4DecodeWithDictionary decompresses LZ4 frames like Lz4Decode, with `dictionary` in front of every frame.
+// It returns an ErrDictionaryMismatch if a frame records the ID of another dictionary.
+func Lz4DecodeWithDictionary(data []byte, dictionary []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var output []byte
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("malformed LZ4 data: truncated magic number")
+		}
+		magic := binary.LittleEndian.Uint32(data)
+
+		if magic&lz4SkippableMagicMask == lz4SkippableMagic {
+			if len(data) < 8 {
+				return nil, fmt.Errorf("malformed LZ4 data: truncated skippable frame")
+			}
+			size := uint64(binary.LittleEndian.Uint32(data[4:]))
+			if size > uint64(len(data)-8) {
+				return nil, fmt.Errorf("malformed LZ4 data: truncated skippable frame")
+			}
+			data = data[8+size:]
+			continue
+		}
+		if magic != lz4FrameMagic {
+			return nil, fmt.Errorf("malformed LZ4 data: unknown magic number 0x%08X", magic)
+		}
+
+		frame, rest, err := lz4DecodeFrame(data[4:], len(output), dictionary)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, frame...)
+		data = rest
+	}
+
+	return output, nil
+}
+
+// lz4DecodeFrame decodes the frame after its magic number, returning its content and the data after it.
+func lz4DecodeFrame(data []byte, offset int, dictionary []byte) ([]byte, []byte, error) {
+	if len(data) < 3 {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: truncated frame descriptor")
+	}
+	flags, bd := data[0], data[1]
+	if flags&lz4FlagVersionMask != lz4FlagVersion {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: unsupported frame version %d", flags>>6)
+	}
+	if flags&lz4FlagReserved != 0 || bd&0x8F != 0 {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: reserved frame descriptor bits are set")
+	}
+	blockSize, ok := lz4BlockSizes[bd>>4&0x07]
+	if !ok {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: invalid block size ID %d", bd>>4&0x07)
+	}
+
+	descriptorLength := 2
+	if flags&lz4FlagContentSize != 0 {
+		descriptorLength += 8
+	}
+	if flags&lz4FlagDictID != 0 {
+		descriptorLength += 4
+	}
+	if len(data) < descriptorLength+1 {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: truncated frame descriptor")
+	}
+	if byte(xxHash32(data[:descriptorLength], 0)>>8) != data[descriptorLength] {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: frame descriptor checksum mismatch")
+	}
+	if flags&lz4FlagDictID != 0 {
+		if err := checkDictionary(binary.LittleEndian.Uint32(data[descriptorLength-4:]), dictionary); err != nil {
+			return nil, nil, err
+		}
+	}
+	contentSize := int64(-1)
+	if flags&lz4FlagContentSize != 0 {
+		contentSize = int64(binary.LittleEndian.Uint64(data[2:]))
+	}
+	data = data[descriptorLength+1:]
+
+	// Blocks; for linked blocks, matches may reach into the previous 64 KB of the frame. The dictionary comes before
+	// the content (and before every independent block), so matches reach into it as well
+	prefix := dictionaryTail(dictionary, lz4MaxOffset)
+	content := append([]byte(nil), prefix...)
+	for {
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("malformed LZ4 data: truncated block size")
+		}
+		header := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if header == 0 { // End mark
+			break
+		}
+
+		size := int(header &^ lz4UncompressedBit)
+		if size > blockSize || size > len(data) {
+			return nil, nil, fmt.Errorf("malformed LZ4 data: invalid block size %d at offset %d", size, offset+len(content)-len(prefix))
+		}
+		block := data[:size]
+		data = data[size:]
+
+		if flags&lz4FlagBlockChecksum != 0 {
+			if len(data) < 4 {
+				return nil, nil, fmt.Errorf("malformed LZ4 data: truncated block checksum")
+			}
+			if binary.LittleEndian.Uint32(data) != xxHash32(block, 0) {
+				return nil, nil, fmt.Errorf("malformed LZ4 data: block checksum mismatch at offset %d", offset+len(content)-len(prefix))
+			}
+			data = data[4:]
+		}
+
+		if header&lz4UncompressedBit != 0 {
+			content = append(content, block...)
+			continue
+		}
+		var err error
+		if flags&lz4FlagBlockIndep != 0 {
+			var decoded []byte
+			if decoded, err = lz4DecodeBlockInto(append([]byte(nil), prefix...), block, blockSize); err == nil {
+				content = append(content, decoded[len(prefix):]...)
+			}
+		} else {
+			content, err = lz4DecodeBlockInto(content, block, blockSize)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	content = content[len(prefix):]
+
+	if contentSize >= 0 && int64(len(content)) != contentSize {
+		return nil, nil, fmt.Errorf("malformed LZ4 data: frame has %d bytes, its descriptor says %d", len(content), contentSize)
+	}
+	if flags&lz4FlagContentChecksum != 0 {
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("malformed LZ4 data: truncated content checksum")
+		}
+		if binary.LittleEndian.Uint32(data) != xxHash32(content, 0) {
+			return nil, nil, fmt.Errorf("malformed LZ4 data: content checksum mismatch")
+		}
+		data = data[4:]
+	}
+
+	return content, data, nil
+}
+
+// --- // LZ4 Compressor Interface
+
+// LZ4Compressor implements core.GeneralCompressor and core.GeneralDecompressor with the LZ4 frame format.
+type LZ4Compressor struct {
+	BlockChecksum bool   // Follow every block with its checksum
+	Dictionary    []byte // Preset dictionary, nil for none
+}
+
+// Compress implements core.Compressor.
+func (l *LZ4Compressor) Compress(data []byte) ([]byte, error) {
+	return Lz4WithDictionary(data, l.BlockChecksum, l.Dictionary)
+}
+
+// Decompress implements core.Decompressor.
+func (l *LZ4Compressor) Decompress(data []byte) ([]byte, error) {
+	return Lz4DecodeWithDictionary(data, l.Dictionary)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (l *LZ4Compressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("Lz4CompressFile", inputFilePath, outputFilePath, l.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (l *LZ4Compressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("Lz4DecompressFile", inputFilePath, outputFilePath, l.Decompress)
+}
+
+// Factory function for creating an LZ4 compressor instance using Lz4BlockChecksum and Dictionary.
+func NewLZ4Compressor() *LZ4Compressor {
+	return NewLZ4CompressorWithDictionary(Dictionary)
+}
+
+// Factory function for creating an LZ4 compressor (or decompressor) instance with a preset dictionary, using Lz4BlockChecksum.
+func NewLZ4CompressorWithDictionary(dictionary []byte) *LZ4Compressor {
+	return &LZ4Compressor{BlockChecksum: Lz4BlockChecksum, Dictionary: dictionary}
+}
+
+// Factory function for creating an LZ4 decompressor instance.
+func NewLZ4Decompressor() *LZ4Compressor {
+	return NewLZ4Compressor()
+}
@@ -0,0 +1,151 @@
+package algorithms
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// lz4FixtureContent returns the text the files in testdata/lz4 were made from (with the reference `lz4` tool v1.9.4):
+//
+//	default.lz4:             lz4 -1
+//	linked_checksums.lz4:    lz4 -9 -B4 -BD -BX --content-size
+//	no_content_checksum.lz4: lz4 -B4 --no-frame-crc
+//	empty.lz4:               lz4, from an empty input
+func lz4FixtureContent() []byte {
+	var buffer bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&buffer, "line %d: the quick brown fox jumps over %d lazy dogs\n", i, i*i%97)
+	}
+	return buffer.Bytes()
+}
+
+func TestXxHash32(t *testing.T) {
+	tests := []struct {
+		input    string
+		seed     uint32
+		expected uint32
+	} {
+		{input: "", seed: 0, expected: 0x02CC5D05},
+		{input: "a", seed: 0, expected: 0x550D7456},
+		{input: "abc", seed: 0, expected: 0x32D153FF},
+		{input: "Nobody inspects the spammish repetition", seed: 0, expected: 0xE2293B2F},
+	}
+
+	for _, tt := range tests {
+		if got := xxHash32([]byte(tt.input), tt.seed); got != tt.expected {
+			t.Errorf("xxHash32(%q, %d) = 0x%08X, want 0x%08X", tt.input, tt.seed, got, tt.expected)
+		}
+	}
+}
+
+func TestLz4DecodeReferenceFixtures(t *testing.T) {
+	content := lz4FixtureContent()
+	tests := []struct {
+		file     string
+		expected []byte
+	} {
+		{file: "default.lz4", expected: content},
+		{file: "linked_checksums.lz4", expected: content},
+		{file: "no_content_checksum.lz4", expected: content},
+		{file: "empty.lz4", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "lz4", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			got, err := Lz4Decode(data)
+			if err != nil {
+				t.Fatalf("Lz4Decode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("Lz4Decode returned %d bytes, want %d", len(got), len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestLz4RoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := make([]byte, 100000)
+	random.Read(noise)
+
+	inputs := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Short input", input: []byte("hello")},
+		{name: "Text", input: lz4FixtureContent()},
+		{name: "Random bytes", input: noise},
+		{name: "Several blocks", input: make([]byte, 9<<20)},
+	}
+
+	for _, checksum := range []bool{false, true} {
+		for _, tt := range inputs {
+			t.Run(tt.name, func(t *testing.T) {
+				compressed, err := Lz4(tt.input, checksum)
+				if err != nil {
+					t.Fatalf("Lz4 returned unexpected error: %v", err)
+				}
+
+				got, err := Lz4Decode(compressed)
+				if err != nil {
+					t.Fatalf("Lz4Decode returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, tt.input) {
+					t.Errorf("Lz4Decode(Lz4(x)) returned %d bytes, want %d", len(got), len(tt.input))
+				}
+			})
+		}
+	}
+}
+
+func TestLz4BlockRoundTrip(t *testing.T) {
+	input := bytes.Repeat([]byte("abcdefgh"), 1000)
+	block := Lz4Block(input)
+	got, err := Lz4BlockDecode(block, len(input))
+	if err != nil {
+		t.Fatalf("Lz4BlockDecode returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, input) {
+		t.Errorf("Lz4BlockDecode(Lz4Block(x)) returned %d bytes, want %d", len(got), len(input))
+	}
+	if _, err := Lz4BlockDecode(block, len(input)-1); err == nil {
+		t.Errorf("Lz4BlockDecode with a too small size expected an error")
+	}
+}
+
+func TestLz4DecodeCorrupted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "lz4", "linked_checksums.lz4"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+	} {
+		{name: "Magic number", offset: 0},
+		{name: "Frame descriptor", offset: 5},
+		{name: "Block data", offset: 100},
+		{name: "Content checksum", offset: len(data) - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), data...)
+			corrupted[tt.offset] ^= 0x01
+			if _, err := Lz4Decode(corrupted); err == nil {
+				t.Errorf("Lz4Decode with a flipped bit at %d expected an error", tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,807 @@
+package algorithms
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	lzmaNumStates          = 12
+	lzmaNumLitStates       = 7 // States below this one follow a literal
+	lzmaMaxPosBits         = 4
+	lzmaMinMatch           = 2
+	lzmaMaxMatch           = 273
+	lzmaNumLenToPosStates  = 4  // Distances are coded with one of four models, chosen by the match length
+	lzmaStartPosModelIndex = 4  // Distance slots below this one are the distance itself
+	lzmaEndPosModelIndex   = 14 // Distance slots from this one on use direct bits and align bits
+	lzmaNumFullDistances   = 1 << (lzmaEndPosModelIndex >> 1)
+	lzmaNumAlignBits       = 4
+	lzmaEndMarker          = 0xFFFFFFFF // Distance of the optional end marker
+
+	// Parameters of the encoder
+	lzmaLiteralContextBits  = 3       // lc: high bits of the previous byte that select the literal coder
+	lzmaLiteralPositionBits = 0       // lp: low bits of the position that select the literal coder
+	lzmaPositionBits        = 2       // pb: low bits of the position that select the match probabilities
+	lzmaHashLog             = 17      // Size of the match finder's hash table
+	lzmaChainDepth          = 48      // Most candidates the match finder compares at every position
+	lzmaFarMatch            = 1 << 14 // Matches of 3 bytes farther than this cost more than 3 literals
+
+	// LZMA2 chunks
+	lzma2MaxUnpackedSize     = 1 << 21 // Most uncompressed bytes an LZMA chunk may hold
+	lzma2MaxPackedSize       = 1 << 16 // Most compressed bytes an LZMA chunk may hold
+	lzma2MaxUncompressedSize = 1 << 16 // Most bytes an uncompressed chunk may hold
+	lzma2PackedMargin        = 256     // Room left in a chunk for the symbol being encoded and the flush
+)
+
+// --- // Bit Trees
+
+// encodeBitTree encodes the low `n` bits of `symbol`, most significant first, with a binary tree of probabilities.
+func encodeBitTree(e *rangeEncoder, probs []uint16, n int, symbol uint32) {
+	m := uint32(1)
+	for i := n - 1; i >= 0; i-- {
+		bit := int(symbol>>i) & 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | uint32(bit)
+	}
+}
+
+// decodeBitTree decodes `n` bits written by encodeBitTree.
+func decodeBitTree(d *rangeDecoder, probs []uint16, n int) (uint32, error) {
+	m := uint32(1)
+	for i := 0; i < n; i++ {
+		bit, err := d.decodeBit(&probs[m])
+		if err != nil {
+			return 0, err
+		}
+		m = m<<1 | uint32(bit)
+	}
+	return m - 1<<n, nil
+}
+
+// encodeReverseBitTree is encodeBitTree, least significant bit first.
+func encodeReverseBitTree(e *rangeEncoder, probs []uint16, n int, symbol uint32) {
+	m := uint32(1)
+	for i := 0; i < n; i++ {
+		bit := int(symbol>>i) & 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | uint32(bit)
+	}
+}
+
+// decodeReverseBitTree decodes `n` bits written by encodeReverseBitTree.
+func decodeReverseBitTree(d *rangeDecoder, probs []uint16, n int) (uint32, error) {
+	m, symbol := uint32(1), uint32(0)
+	for i := 0; i < n; i++ {
+		bit, err := d.decodeBit(&probs[m])
+		if err != nil {
+			return 0, err
+		}
+		m = m<<1 | uint32(bit)
+		symbol |= uint32(bit) << i
+	}
+	return symbol, nil
+}
+
+// resetProbabilities sets every probability in `probs` back to one half.
+func resetProbabilities(probs []uint16) {
+	for i := range probs {
+		probs[i] = rangeProbabilityInit
+	}
+}
+
+// --- // LZMA State
+
+// lzmaLengthCoder codes match lengths: 8 short lengths and 8 medium lengths per position state, then 256 long lengths.
+type lzmaLengthCoder struct {
+	choice  uint16
+	choice2 uint16
+	low     [1 << lzmaMaxPosBits][1 << 3]uint16
+	mid     [1 << lzmaMaxPosBits][1 << 3]uint16
+	high    [1 << 8]uint16
+}
+
+// reset sets every probability of the coder back to one half.
+func (c *lzmaLengthCoder) reset() {
+	c.choice, c.choice2 = rangeProbabilityInit, rangeProbabilityInit
+	for i := range c.low {
+		resetProbabilities(c.low[i][:])
+		resetProbabilities(c.mid[i][:])
+	}
+	resetProbabilities(c.high[:])
+}
+
+// encode encodes a match length between lzmaMinMatch and lzmaMaxMatch.
+func (c *lzmaLengthCoder) encode(e *rangeEncoder, length int, posState int) {
+	length -= lzmaMinMatch
+	switch {
+	case length < 8:
+		e.encodeBit(&c.choice, 0)
+		encodeBitTree(e, c.low[posState][:], 3, uint32(length))
+	case length < 16:
+		e.encodeBit(&c.choice, 1)
+		e.encodeBit(&c.choice2, 0)
+		encodeBitTree(e, c.mid[posState][:], 3, uint32(length-8))
+	default:
+		e.encodeBit(&c.choice, 1)
+		e.encodeBit(&c.choice2, 1)
+		encodeBitTree(e, c.high[:], 8, uint32(length-16))
+	}
+}
+
+// decode decodes a match length written by encode.
+func (c *lzmaLengthCoder) decode(d *rangeDecoder, posState int) (int, error) {
+	bit, err := d.decodeBit(&c.choice)
+	if err != nil {
+		return 0, err
+	}
+	var length uint32
+	if bit == 0 {
+		length, err = decodeBitTree(d, c.low[posState][:], 3)
+	} else if bit, err = d.decodeBit(&c.choice2); err == nil {
+		if bit == 0 {
+			length, err = decodeBitTree(d, c.mid[posState][:], 3)
+			length += 8
+		} else {
+			length, err = decodeBitTree(d, c.high[:], 8)
+			length += 16
+		}
+	}
+	return int(length) + lzmaMinMatch, err
+}
+
+// lzmaState holds everything the LZMA encoder and decoder must agree on: the literal coding parameters,
+// the state machine (what the last few symbols were), the last four distances and all adaptive probabilities.
+type lzmaState struct {
+	lc, lp, pb int
+	state      int
+	reps       [4]uint32 // The last four match distances minus one, most recent first
+	isMatch    [lzmaNumStates << lzmaMaxPosBits]uint16
+	isRep      [lzmaNumStates]uint16
+	isRepG0    [lzmaNumStates]uint16
+	isRepG1    [lzmaNumStates]uint16
+	isRepG2    [lzmaNumStates]uint16
+	isRep0Long [lzmaNumStates << lzmaMaxPosBits]uint16
+	posSlot    [lzmaNumLenToPosStates][1 << 6]uint16
+	posSpecial [lzmaNumFullDistances - lzmaEndPosModelIndex + 1]uint16 // One more, since the tree of slot 4 starts at -1
+	align      [1 << lzmaNumAlignBits]uint16
+	matchLen   lzmaLengthCoder
+	repLen     lzmaLengthCoder
+	literal    []uint16 // 0x300 probabilities for each of the 2^(lc+lp) literal coders
+}
+
+// newLzmaState creates a freshly reset state for the given literal context, literal position and position bits.
+func newLzmaState(lc int, lp int, pb int) *lzmaState {
+	s := &lzmaState{lc: lc, lp: lp, pb: pb, literal: make([]uint16, 0x300<<(lc+lp))}
+	s.reset()
+	return s
+}
+
+// reset forgets the state machine, the distances and everything the probabilities have learned.
+func (s *lzmaState) reset() {
+	s.state = 0
+	s.reps = [4]uint32{}
+	resetProbabilities(s.isMatch[:])
+	resetProbabilities(s.isRep[:])
+	resetProbabilities(s.isRepG0[:])
+	resetProbabilities(s.isRepG1[:])
+	resetProbabilities(s.isRepG2[:])
+	resetProbabilities(s.isRep0Long[:])
+	for i := range s.posSlot {
+		resetProbabilities(s.posSlot[i][:])
+	}
+	resetProbabilities(s.posSpecial[:])
+	resetProbabilities(s.align[:])
+	s.matchLen.reset()
+	s.repLen.reset()
+	resetProbabilities(s.literal)
+}
+
+// lzmaDecodeProperties splits an LZMA properties byte into lc, lp and pb.
+func lzmaDecodeProperties(b byte) (int, int, int, error) {
+	if b >= 9*5*5 {
+		return 0, 0, 0, fmt.Errorf("malformed LZMA data: invalid properties byte 0x%02X", b)
+	}
+	return int(b % 9), int(b / 9 % 5), int(b / 45), nil
+}
+
+// lzmaEncodeProperties packs lc, lp and pb into an LZMA properties byte.
+func lzmaEncodeProperties(lc int, lp int, pb int) byte {
+	return byte((pb*5+lp)*9 + lc)
+}
+
+// afterLiteral, afterMatch, afterRep and afterShortRep move the state machine forward.
+func (s *lzmaState) afterLiteral() {
+	switch {
+	case s.state < 4:
+		s.state = 0
+	case s.state < 10:
+		s.state -= 3
+	default:
+		s.state -= 6
+	}
+}
+
+func (s *lzmaState) afterMatch() {
+	if s.state < lzmaNumLitStates {
+		s.state = 7
+	} else {
+		s.state = 10
+	}
+}
+
+func (s *lzmaState) afterRep() {
+	if s.state < lzmaNumLitStates {
+		s.state = 8
+	} else {
+		s.state = 11
+	}
+}
+
+func (s *lzmaState) afterShortRep() {
+	if s.state < lzmaNumLitStates {
+		s.state = 9
+	} else {
+		s.state = 11
+	}
+}
+
+// literalProbs returns the probabilities of the literal coder for position `pos` after the byte `prev`.
+func (s *lzmaState) literalProbs(pos int, prev byte) []uint16 {
+	i := (pos&(1<<s.lp-1))<<s.lc | int(prev)>>(8-s.lc)
+	return s.literal[0x300*i : 0x300*(i+1)]
+}
+
+// lzmaDistanceSlot returns the slot of a distance (minus one): its bit length and the bit after the leading one.
+func lzmaDistanceSlot(dist uint32) uint32 {
+	if dist < lzmaStartPosModelIndex {
+		return dist
+	}
+	n := uint32(bits.Len32(dist))
+	return 2*(n-1) | dist>>(n-2)&1
+}
+
+// encodeDistance encodes a match distance (minus one) with the model chosen by the match length.
+func (s *lzmaState) encodeDistance(e *rangeEncoder, dist uint32, length int) {
+	lenState := length - lzmaMinMatch
+	if lenState >= lzmaNumLenToPosStates {
+		lenState = lzmaNumLenToPosStates - 1
+	}
+	slot := lzmaDistanceSlot(dist)
+	encodeBitTree(e, s.posSlot[lenState][:], 6, slot)
+	if slot < lzmaStartPosModelIndex {
+		return
+	}
+
+	footerBits := int(slot>>1) - 1
+	base := (2 | slot&1) << footerBits
+	reduced := dist - base
+	if slot < lzmaEndPosModelIndex {
+		encodeReverseBitTree(e, s.posSpecial[base-slot:], footerBits, reduced)
+		return
+	}
+	e.encodeDirectBits(reduced>>lzmaNumAlignBits, footerBits-lzmaNumAlignBits)
+	encodeReverseBitTree(e, s.align[:], lzmaNumAlignBits, reduced&(1<<lzmaNumAlignBits-1))
+}
+
+// decodeDistance decodes a match distance (minus one) written by encodeDistance.
+func (s *lzmaState) decodeDistance(d *rangeDecoder, length int) (uint32, error) {
+	lenState := length - lzmaMinMatch
+	if lenState >= lzmaNumLenToPosStates {
+		lenState = lzmaNumLenToPosStates - 1
+	}
+	slot, err := decodeBitTree(d, s.posSlot[lenState][:], 6)
+	if err != nil || slot < lzmaStartPosModelIndex {
+		return slot, err
+	}
+
+	footerBits := int(slot>>1) - 1
+	base := (2 | slot&1) << footerBits
+	if slot < lzmaEndPosModelIndex {
+		reduced, err := decodeReverseBitTree(d, s.posSpecial[base-slot:], footerBits)
+		return base + reduced, err
+	}
+	direct, err := d.decodeDirectBits(footerBits - lzmaNumAlignBits)
+	if err != nil {
+		return 0, err
+	}
+	align, err := decodeReverseBitTree(d, s.align[:], lzmaNumAlignBits)
+	return base + direct<<lzmaNumAlignBits + align, err
+}
+
+// --- // LZMA Decoding
+
+// decodeLiteral decodes one literal. After a match, the byte at the last distance steers the probabilities.
+func (s *lzmaState) decodeLiteral(d *rangeDecoder, probs []uint16, matchByte byte) (byte, error) {
+	symbol := uint32(1)
+	if s.state < lzmaNumLitStates {
+		for symbol < 0x100 {
+			bit, err := d.decodeBit(&probs[symbol])
+			if err != nil {
+				return 0, err
+			}
+			symbol = symbol<<1 | uint32(bit)
+		}
+		return byte(symbol), nil
+	}
+
+	match, offset := uint32(matchByte), uint32(0x100)
+	for symbol < 0x100 {
+		match <<= 1
+		matchBit := match & offset
+		bit, err := d.decodeBit(&probs[offset+matchBit+symbol])
+		if err != nil {
+			return 0, err
+		}
+		symbol = symbol<<1 | uint32(bit)
+		if bit == 0 {
+			offset &^= matchBit
+		} else {
+			offset &= matchBit
+		}
+	}
+	return byte(symbol), nil
+}
+
+// decodeChunk decodes symbols from `d`, appending them to `out` until it holds `end` bytes.
+// The dictionary is out[dictStart:], and positions are counted from dictStart.
+func (s *lzmaState) decodeChunk(d *rangeDecoder, out []byte, dictStart int, end int, dictSize int) ([]byte, error) {
+	posMask := 1<<s.pb - 1
+	for len(out) < end {
+		pos := len(out) - dictStart
+		posState := pos & posMask
+
+		bit, err := d.decodeBit(&s.isMatch[s.state<<lzmaMaxPosBits|posState])
+		if err != nil {
+			return nil, err
+		}
+		if bit == 0 { // Literal
+			prev, matchByte := byte(0), byte(0)
+			if pos > 0 {
+				prev = out[len(out)-1]
+			}
+			if s.state >= lzmaNumLitStates {
+				if uint64(s.reps[0]) >= uint64(pos) {
+					return nil, fmt.Errorf("malformed LZMA data: distance %d at position %d", uint64(s.reps[0])+1, pos)
+				}
+				matchByte = out[len(out)-int(s.reps[0])-1]
+			}
+			b, err := s.decodeLiteral(d, s.literalProbs(pos, prev), matchByte)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b)
+			s.afterLiteral()
+			continue
+		}
+
+		var length int
+		if bit, err = d.decodeBit(&s.isRep[s.state]); err != nil {
+			return nil, err
+		}
+		if bit == 0 { // Match with a new distance
+			if length, err = s.matchLen.decode(d, posState); err != nil {
+				return nil, err
+			}
+			dist, err := s.decodeDistance(d, length)
+			if err != nil {
+				return nil, err
+			}
+			if dist == lzmaEndMarker {
+				return nil, fmt.Errorf("malformed LZMA data: unexpected end marker at position %d", pos)
+			}
+			s.reps = [4]uint32{dist, s.reps[0], s.reps[1], s.reps[2]}
+			s.afterMatch()
+		} else { // Match with one of the last four distances
+			if pos == 0 {
+				return nil, fmt.Errorf("malformed LZMA data: repeated match before any data")
+			}
+			if bit, err = d.decodeBit(&s.isRepG0[s.state]); err != nil {
+				return nil, err
+			}
+			if bit == 0 {
+				if bit, err = d.decodeBit(&s.isRep0Long[s.state<<lzmaMaxPosBits|posState]); err != nil {
+					return nil, err
+				}
+				if bit == 0 { // A single byte at the last distance
+					if uint64(s.reps[0]) >= uint64(pos) {
+						return nil, fmt.Errorf("malformed LZMA data: distance %d at position %d", uint64(s.reps[0])+1, pos)
+					}
+					out = append(out, out[len(out)-int(s.reps[0])-1])
+					s.afterShortRep()
+					continue
+				}
+			} else {
+				var dist uint32
+				if bit, err = d.decodeBit(&s.isRepG1[s.state]); err != nil {
+					return nil, err
+				}
+				if bit == 0 {
+					dist = s.reps[1]
+				} else {
+					if bit, err = d.decodeBit(&s.isRepG2[s.state]); err != nil {
+						return nil, err
+					}
+					if bit == 0 {
+						dist = s.reps[2]
+					} else {
+						dist = s.reps[3]
+						s.reps[3] = s.reps[2]
+					}
+					s.reps[2] = s.reps[1]
+				}
+				s.reps[1] = s.reps[0]
+				s.reps[0] = dist
+			}
+			if length, err = s.repLen.decode(d, posState); err != nil {
+				return nil, err
+			}
+			s.afterRep()
+		}
+
+		if uint64(s.reps[0]) >= uint64(pos) || uint64(s.reps[0]) >= uint64(dictSize) {
+			return nil, fmt.Errorf("malformed LZMA data: distance %d at position %d", uint64(s.reps[0])+1, pos)
+		}
+		if length > end-len(out) {
+			return nil, fmt.Errorf("malformed LZMA data: match runs past the end of the chunk")
+		}
+		from := len(out) - int(s.reps[0]) - 1
+		for j := 0; j < length; j++ { // Byte by byte, since matches may overlap their own output
+			out = append(out, out[from+j])
+		}
+	}
+	return out, nil
+}
+
+// --- // LZMA Encoding
+
+// lzmaEncoder finds matches with hash chains and encodes them greedily as LZMA symbols.
+type lzmaEncoder struct {
+	*lzmaState
+	data     []byte
+	dictSize int
+	head     []int32 // Position + 1 of the last occurrence of each hashed 3-byte sequence
+	chain    []int32 // Position + 1 of the previous occurrence of the sequence at each position
+	hashed   int     // Positions below this one are in the hash chains
+}
+
+// newLzmaEncoder creates an encoder for `data` whose matches reach at most `dictSize` bytes back.
+func newLzmaEncoder(data []byte, dictSize int) *lzmaEncoder {
+	return &lzmaEncoder{
+		lzmaState: newLzmaState(lzmaLiteralContextBits, lzmaLiteralPositionBits, lzmaPositionBits),
+		data:      data,
+		dictSize:  dictSize,
+		head:      make([]int32, 1<<lzmaHashLog),
+		chain:     make([]int32, len(data)),
+	}
+}
+
+// hash returns the hash table index of the 3 bytes at `pos`.
+func (enc *lzmaEncoder) hash(pos int) int {
+	v := uint32(enc.data[pos]) | uint32(enc.data[pos+1])<<8 | uint32(enc.data[pos+2])<<16
+	return int((v * 2654435761) >> (32 - lzmaHashLog))
+}
+
+// insertUntil adds the sequences at the positions below `end` to the hash chains.
+func (enc *lzmaEncoder) insertUntil(end int) {
+	if end > len(enc.data)-2 {
+		end = len(enc.data) - 2
+	}
+	for ; enc.hashed < end; enc.hashed++ {
+		h := enc.hash(enc.hashed)
+		enc.chain[enc.hashed] = enc.head[h]
+		enc.head[h] = int32(enc.hashed + 1)
+	}
+}
+
+// matchLength returns how many bytes (up to `limit`) at `pos` repeat the bytes `distance` back.
+func (enc *lzmaEncoder) matchLength(pos int, distance int, limit int) int {
+	n := 0
+	for n < limit && enc.data[pos+n] == enc.data[pos+n-distance] {
+		n++
+	}
+	return n
+}
+
+// longestMatch returns the longest match (up to `limit` bytes) at `pos` and its distance.
+func (enc *lzmaEncoder) longestMatch(pos int, limit int) (int, int) {
+	best, bestDistance := 0, 0
+	if pos+3 > len(enc.data) {
+		return 0, 0
+	}
+	candidate := int(enc.head[enc.hash(pos)]) - 1
+	for depth := 0; candidate >= 0 && depth < lzmaChainDepth; depth++ {
+		distance := pos - candidate
+		if distance > enc.dictSize {
+			break
+		}
+		if enc.data[candidate+best] == enc.data[pos+best] { // Cheap test before comparing the whole match
+			if n := enc.matchLength(pos, distance, limit); n > best {
+				best, bestDistance = n, distance
+				if n == limit {
+					break
+				}
+			}
+		}
+		candidate = int(enc.chain[candidate]) - 1
+	}
+	return best, bestDistance
+}
+
+// betterMatchAt tells whether the match at `pos` is longer than `length` + 1, in which case it is worth
+// delaying the match before it by a literal (lazy matching).
+func (enc *lzmaEncoder) betterMatchAt(pos int, end int, length int) bool {
+	limit := end - pos
+	if limit > lzmaMaxMatch {
+		limit = lzmaMaxMatch
+	}
+	if limit <= length+1 {
+		return false
+	}
+	enc.insertUntil(pos)
+	next, _ := enc.longestMatch(pos, limit)
+	return next > length+1
+}
+
+// encodeLiteral encodes the byte at `pos`.
+func (enc *lzmaEncoder) encodeLiteral(e *rangeEncoder, pos int, posState int) {
+	e.encodeBit(&enc.isMatch[enc.state<<lzmaMaxPosBits|posState], 0)
+	prev := byte(0)
+	if pos > 0 {
+		prev = enc.data[pos-1]
+	}
+	probs := enc.literalProbs(pos, prev)
+	b := uint32(enc.data[pos])
+
+	if enc.state < lzmaNumLitStates {
+		encodeBitTree(e, probs, 8, b)
+	} else {
+		match, offset, symbol := uint32(enc.data[pos-int(enc.reps[0])-1]), uint32(0x100), uint32(1)
+		for i := 7; i >= 0; i-- {
+			bit := b >> i & 1
+			match <<= 1
+			matchBit := match & offset
+			e.encodeBit(&probs[offset+matchBit+symbol], int(bit))
+			symbol = symbol<<1 | bit
+			if bit == 0 {
+				offset &^= matchBit
+			} else {
+				offset &= matchBit
+			}
+		}
+	}
+	enc.afterLiteral()
+}
+
+// encodeMatch encodes a match with a new distance.
+func (enc *lzmaEncoder) encodeMatch(e *rangeEncoder, distance int, length int, posState int) {
+	e.encodeBit(&enc.isMatch[enc.state<<lzmaMaxPosBits|posState], 1)
+	e.encodeBit(&enc.isRep[enc.state], 0)
+	enc.matchLen.encode(e, length, posState)
+	dist := uint32(distance - 1)
+	enc.encodeDistance(e, dist, length)
+	enc.reps = [4]uint32{dist, enc.reps[0], enc.reps[1], enc.reps[2]}
+	enc.afterMatch()
+}
+
+// encodeRep encodes a match with the last distance number `index`, or a single byte at the last distance (shortRep).
+func (enc *lzmaEncoder) encodeRep(e *rangeEncoder, index int, length int, posState int, shortRep bool) {
+	e.encodeBit(&enc.isMatch[enc.state<<lzmaMaxPosBits|posState], 1)
+	e.encodeBit(&enc.isRep[enc.state], 1)
+	if index == 0 {
+		e.encodeBit(&enc.isRepG0[enc.state], 0)
+		if shortRep {
+			e.encodeBit(&enc.isRep0Long[enc.state<<lzmaMaxPosBits|posState], 0)
+			enc.afterShortRep()
+			return
+		}
+		e.encodeBit(&enc.isRep0Long[enc.state<<lzmaMaxPosBits|posState], 1)
+	} else {
+		e.encodeBit(&enc.isRepG0[enc.state], 1)
+		dist := enc.reps[index]
+		if index == 1 {
+			e.encodeBit(&enc.isRepG1[enc.state], 0)
+		} else {
+			e.encodeBit(&enc.isRepG1[enc.state], 1)
+			e.encodeBit(&enc.isRepG2[enc.state], index-2)
+		}
+		copy(enc.reps[1:index+1], enc.reps[:index])
+		enc.reps[0] = dist
+	}
+	enc.repLen.encode(e, length, posState)
+	enc.afterRep()
+}
+
+// encodeChunk encodes the data from `start` until `end`, or until the encoded chunk is nearly full.
+// It returns the position it stopped at.
+func (enc *lzmaEncoder) encodeChunk(e *rangeEncoder, start int, end int) int {
+	posMask := 1<<enc.pb - 1
+	pos := start
+	for pos < end && e.pending() < lzma2MaxPackedSize-lzma2PackedMargin {
+		limit := end - pos
+		if limit > lzmaMaxMatch {
+			limit = lzmaMaxMatch
+		}
+		posState := pos & posMask
+
+		length, distance := enc.longestMatch(pos, limit)
+		repLength, repIndex := 0, 0
+		for i, rep := range enc.reps {
+			if d := int(rep) + 1; d <= pos {
+				if n := enc.matchLength(pos, d, limit); n > repLength {
+					repLength, repIndex = n, i
+				}
+			}
+		}
+
+		n := 1
+		switch {
+		case repLength >= lzmaMinMatch && repLength+1 >= length:
+			enc.encodeRep(e, repIndex, repLength, posState, false)
+			n = repLength
+		case (length > 3 || (length == 3 && distance <= lzmaFarMatch)) && !enc.betterMatchAt(pos+1, end, length):
+			enc.encodeMatch(e, distance, length, posState)
+			n = length
+		case int(enc.reps[0]) < pos && enc.data[pos] == enc.data[pos-int(enc.reps[0])-1]:
+			enc.encodeRep(e, 0, 1, posState, true)
+		default:
+			enc.encodeLiteral(e, pos, posState)
+		}
+
+		pos += n
+		enc.insertUntil(pos)
+	}
+	return pos
+}
+
+// --- // LZMA2
+
+// lzma2Encode compresses data into LZMA2 chunks, ending with the end marker.
+// Chunks that do not compress are stored as they are.
+func lzma2Encode(data []byte, dictSize int) []byte {
+	var buffer bytes.Buffer
+	enc := newLzmaEncoder(data, dictSize)
+	needDictReset, needProperties, needStateReset := true, true, false
+
+	for pos := 0; pos < len(data); {
+		end := pos + lzma2MaxUnpackedSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if needStateReset {
+			enc.reset()
+		}
+		e := newRangeEncoder()
+		next := enc.encodeChunk(e, pos, end)
+		packed := e.bytes()
+		unpacked := next - pos
+		verbosePrintf("Lzma2: chunk at %v: %v -> %v bytes\n", pos, unpacked, len(packed))
+
+		if len(packed) >= unpacked { // Not worth it; the encoder's state no longer matches the decoder's
+			for start := pos; start < next; start += lzma2MaxUncompressedSize {
+				size := next - start
+				if size > lzma2MaxUncompressedSize {
+					size = lzma2MaxUncompressedSize
+				}
+				control := byte(0x02)
+				if needDictReset {
+					control = 0x01
+				}
+				buffer.Write([]byte{control, byte((size - 1) >> 8), byte(size - 1)})
+				buffer.Write(data[start : start+size])
+				needDictReset = false
+			}
+			needStateReset = true
+		} else {
+			control := byte(0x80)
+			switch {
+			case needDictReset:
+				control = 0xE0
+			case needProperties:
+				control = 0xC0
+			case needStateReset:
+				control = 0xA0
+			}
+			buffer.Write([]byte{
+				control | byte((unpacked-1)>>16),
+				byte((unpacked - 1) >> 8), byte(unpacked - 1),
+				byte((len(packed) - 1) >> 8), byte(len(packed) - 1),
+			})
+			if control >= 0xC0 {
+				buffer.WriteByte(lzmaEncodeProperties(enc.lc, enc.lp, enc.pb))
+			}
+			buffer.Write(packed)
+			needDictReset, needProperties, needStateReset = false, false, false
+		}
+		pos = next
+	}
+
+	buffer.WriteByte(0x00)
+	return buffer.Bytes()
+}
+
+// lzma2Decode decodes LZMA2 chunks up to and including the end marker, returning the data and the bytes read.
+func lzma2Decode(data []byte, dictSize int) ([]byte, int, error) {
+	var out []byte
+	var s *lzmaState
+	dictStart := 0
+	needDictReset, needProperties := true, true
+
+	for pos := 0; ; {
+		if pos >= len(data) {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: missing end marker")
+		}
+		control := data[pos]
+		pos++
+		if control == 0x00 {
+			return out, pos, nil
+		}
+
+		if control == 0x01 || control >= 0xE0 {
+			dictStart = len(out)
+			needDictReset, needProperties = false, true
+		} else if needDictReset {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: the first chunk does not reset the dictionary")
+		}
+
+		if control < 0x80 { // Uncompressed chunk
+			if control > 0x02 {
+				return nil, 0, fmt.Errorf("malformed LZMA2 data: invalid control byte 0x%02X", control)
+			}
+			if pos+2 > len(data) {
+				return nil, 0, fmt.Errorf("malformed LZMA2 data: truncated chunk header")
+			}
+			size := (int(data[pos])<<8 | int(data[pos+1])) + 1
+			pos += 2
+			if size > len(data)-pos {
+				return nil, 0, fmt.Errorf("malformed LZMA2 data: truncated chunk")
+			}
+			out = append(out, data[pos:pos+size]...)
+			pos += size
+			continue
+		}
+
+		// LZMA chunk
+		headerSize := 4
+		if control >= 0xC0 {
+			headerSize++
+		}
+		if pos+headerSize > len(data) {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: truncated chunk header")
+		}
+		unpacked := (int(control&0x1F)<<16 | int(data[pos])<<8 | int(data[pos+1])) + 1
+		packed := (int(data[pos+2])<<8 | int(data[pos+3])) + 1
+		if control >= 0xC0 {
+			lc, lp, pb, err := lzmaDecodeProperties(data[pos+4])
+			if err != nil {
+				return nil, 0, err
+			}
+			if lc+lp > 4 {
+				return nil, 0, fmt.Errorf("malformed LZMA2 data: lc + lp is %d, must be at most 4", lc+lp)
+			}
+			s = newLzmaState(lc, lp, pb)
+			needProperties = false
+		} else if needProperties {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: a chunk after a dictionary reset has no properties")
+		} else if control >= 0xA0 {
+			s.reset()
+		}
+		pos += headerSize
+		if packed > len(data)-pos {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: truncated chunk")
+		}
+
+		chunk := data[pos : pos+packed]
+		d, err := newRangeDecoder(chunk)
+		if err != nil {
+			return nil, 0, err
+		}
+		if out, err = s.decodeChunk(d, out, dictStart, len(out)+unpacked, dictSize); err != nil {
+			return nil, 0, err
+		}
+		if d.pos != len(chunk) || d.code != 0 {
+			return nil, 0, fmt.Errorf("malformed LZMA2 data: chunk of %d bytes does not end where its data does", packed)
+		}
+		pos += packed
+	}
+}
@@ -0,0 +1,279 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Options for LZSS, used by NewLZSSCompressor (and therefore by the factories in core)
+
+var LzssWindowSize = 4096 // How far back (in bytes) a match may start
+var LzssMinMatch = 3      // Shortest repeated substring worth encoding as a match
+
+const (
+	lzssMaxWindowSize = 1 << 24 // Largest accepted window size
+	lzssMaxMatch      = 258     // Longest match the encoder emits
+	lzssMaxChain      = 256     // Number of candidate positions checked for each match
+	lzssHashSize      = 1 << 16 // Number of hash chain heads
+)
+
+// --- // LZSS Encoding
+
+// lzssHash hashes the two bytes at `data[i:]`.
+func lzssHash(data []byte, i int) int {
+	return int(data[i])<<8 | int(data[i+1])
+}
+
+// Lzss encodes data with the LZSS method, returning a byte slice.
+// The output starts with the uncompressed length (as a uvarint) and the minimum match length.
+// Then come groups of up to 8 tokens, each preceded by a flag byte whose bits (least significant first) mark matches.
+// A literal is a single byte; a match is the uvarint `offset - 1` followed by the uvarint `length - minMatch`.
+func Lzss(data []byte, windowSize int, minMatch int) ([]byte, error) {
+	return LzssWithDictionary(data, windowSize, minMatch, nil)
+}
+
+// LzssWithDictionary encodes data like Lzss, but matches may also reach into the last `windowSize` bytes of `dictionary`.
+// With a dictionary, the minimum match length is preceded by a 0 byte and the DictionaryID (4 bytes, little-endian).
+func LzssWithDictionary(data []byte, windowSize int, minMatch int, dictionary []byte) ([]byte, error) {
+	if windowSize < 1 || windowSize > lzssMaxWindowSize {
+		return nil, fmt.Errorf("invalid LZSS window size %d (must be between 1 and %d)", windowSize, lzssMaxWindowSize)
+	}
+	if minMatch < 2 || minMatch > lzssMaxMatch {
+		return nil, fmt.Errorf("invalid LZSS minimum match length %d (must be between 2 and %d)", minMatch, lzssMaxMatch)
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+	buffer.Write(varint[:binary.PutUvarint(varint[:], uint64(len(data)))])
+	if len(dictionary) > 0 {
+		buffer.WriteByte(0)
+		buffer.Write(binary.LittleEndian.AppendUint32(nil, DictionaryID(dictionary)))
+	}
+	buffer.WriteByte(byte(minMatch))
+
+	// The part of the dictionary inside the window comes before the data, so matches find it like earlier data
+	prefix := dictionaryTail(dictionary, windowSize)
+	data = append(append(make([]byte, 0, len(prefix)+len(data)), prefix...), data...)
+
+	head := make([]int, lzssHashSize)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int, len(data))
+
+	// insert adds position `i` to the hash chains.
+	insert := func(i int) {
+		if i+1 < len(data) {
+			h := lzssHash(data, i)
+			prev[i] = head[h]
+			head[h] = i
+		}
+	}
+	for i := 0; i < len(prefix); i++ {
+		insert(i)
+	}
+
+	var tokens bytes.Buffer // Tokens of the current group
+	flags, count := byte(0), 0
+	flush := func() {
+		buffer.WriteByte(flags)
+		buffer.Write(tokens.Bytes())
+		tokens.Reset()
+		flags, count = 0, 0
+	}
+
+	for i := len(prefix); i < len(data); {
+		// Find the longest match inside the window
+		bestLength, bestOffset := 0, 0
+		if i+minMatch <= len(data) {
+			limit := len(data) - i
+			if limit > lzssMaxMatch {
+				limit = lzssMaxMatch
+			}
+			candidate := head[lzssHash(data, i)]
+			for chain := 0; candidate >= 0 && i-candidate <= windowSize && chain < lzssMaxChain; chain++ {
+				length := 0
+				for length < limit && data[candidate+length] == data[i+length] {
+					length++
+				}
+				if length > bestLength {
+					bestLength, bestOffset = length, i-candidate
+					if length == limit {
+						break
+					}
+				}
+				candidate = prev[candidate]
+			}
+		}
+
+		if bestLength >= minMatch {
+			flags |= 1 << count
+			tokens.Write(varint[:binary.PutUvarint(varint[:], uint64(bestOffset-1))])
+			tokens.Write(varint[:binary.PutUvarint(varint[:], uint64(bestLength-minMatch))])
+			for end := i + bestLength; i < end; i++ {
+				insert(i)
+			}
+		} else {
+			tokens.WriteByte(data[i])
+			insert(i)
+			i++
+		}
+
+		count++
+		if count == 8 {
+			flush()
+		}
+	}
+	if count > 0 {
+		flush()
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// --- // LZSS Decoding
+
+// LzssDecode decodes data produced by Lzss.
+func LzssDecode(data []byte) ([]byte, error) {
+	return LzssDecodeWithDictionary(data, nil)
+}
+
+// LzssDecodeWithDictionary decodes data produced by LzssWithDictionary, or by Lzss (the dictionary is then not used).
+// It returns an ErrDictionaryMismatch if the data was compressed with another dictionary.
+func LzssDecodeWithDictionary(data []byte, dictionary []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 || len(data) < n+1 {
+		return nil, fmt.Errorf("malformed LZSS data: invalid header")
+	}
+	data = data[n:]
+	var prefix []byte
+	if data[0] == 0 { // Dictionary ID
+		if len(data) < 6 {
+			return nil, fmt.Errorf("malformed LZSS data: invalid header")
+		}
+		if err := checkDictionary(binary.LittleEndian.Uint32(data[1:]), dictionary); err != nil {
+			return nil, err
+		}
+		prefix = dictionary
+		data = data[5:]
+	}
+	minMatch := int(data[0])
+	data = data[1:]
+
+	// Never trust the header for the allocation size; no token expands to more than lzssMaxMatch bytes
+	if size > uint64(len(data))*lzssMaxMatch {
+		return nil, fmt.Errorf("malformed LZSS data: length %d exceeds the encoded data", size)
+	}
+
+	// The dictionary comes before the output, so matches can reach into it
+	output := make([]byte, 0, len(prefix)+int(size))
+	output = append(output, prefix...)
+	end := len(prefix) + int(size)
+
+	pos := 0
+	for len(output) < end {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("malformed LZSS data: missing flag byte")
+		}
+		flags := data[pos]
+		pos++
+
+		for bit := 0; bit < 8 && len(output) < end; bit++ {
+			if flags&(1<<bit) == 0 { // Literal
+				if pos >= len(data) {
+					return nil, fmt.Errorf("malformed LZSS data: missing literal")
+				}
+				output = append(output, data[pos])
+				pos++
+				continue
+			}
+
+			// Match
+			offset, n := binary.Uvarint(data[pos:])
+			if n <= 0 {
+				return nil, fmt.Errorf("malformed LZSS data: invalid match offset")
+			}
+			pos += n
+			length, n := binary.Uvarint(data[pos:])
+			if n <= 0 {
+				return nil, fmt.Errorf("malformed LZSS data: invalid match length")
+			}
+			pos += n
+
+			if offset >= uint64(len(output)) {
+				return nil, fmt.Errorf("malformed LZSS data: offset %d reaches before the start of the data", offset+1)
+			}
+			if length > lzssMaxMatch {
+				return nil, fmt.Errorf("malformed LZSS data: match length %d is too long", length)
+			}
+			offset++
+			length += uint64(minMatch)
+			if length > uint64(end-len(output)) {
+				return nil, fmt.Errorf("malformed LZSS data: match of length %d runs past the end of the data", length)
+			}
+			from := len(output) - int(offset)
+			for j := 0; j < int(length); j++ { // Byte by byte, since matches may overlap their own output
+				output = append(output, output[from+j])
+			}
+		}
+	}
+
+	return output[len(prefix):], nil
+}
+
+// --- // LZSS Compressor Interface
+
+// LZSSCompressor implements core.GeneralCompressor and core.GeneralDecompressor with LZSS.
+type LZSSCompressor struct {
+	WindowSize int    // How far back (in bytes) a match may start
+	MinMatch   int    // Shortest repeated substring worth encoding as a match
+	Dictionary []byte // Preset dictionary, nil for none
+}
+
+// Compress implements core.Compressor.
+func (l *LZSSCompressor) Compress(data []byte) ([]byte, error) {
+	return LzssWithDictionary(data, l.WindowSize, l.MinMatch, l.Dictionary)
+}
+
+// Decompress implements core.Decompressor.
+func (l *LZSSCompressor) Decompress(data []byte) ([]byte, error) {
+	return LzssDecodeWithDictionary(data, l.Dictionary)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (l *LZSSCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("LzssCompressFile", inputFilePath, outputFilePath, l.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (l *LZSSCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("LzssDecompressFile", inputFilePath, outputFilePath, l.Decompress)
+}
+
+// Factory function for creating an LZSS compressor instance using LzssWindowSize, LzssMinMatch and Dictionary.
+func NewLZSSCompressor() *LZSSCompressor {
+	return NewLZSSCompressorWithDictionary(Dictionary)
+}
+
+// Factory function for creating an LZSS compressor instance with a custom window size and minimum match length.
+func NewLZSSCompressorWithOptions(windowSize int, minMatch int) *LZSSCompressor {
+	return &LZSSCompressor{WindowSize: windowSize, MinMatch: minMatch}
+}
+
+// Factory function for creating an LZSS compressor (or decompressor) instance with a preset dictionary, using LzssWindowSize and LzssMinMatch.
+func NewLZSSCompressorWithDictionary(dictionary []byte) *LZSSCompressor {
+	return &LZSSCompressor{WindowSize: LzssWindowSize, MinMatch: LzssMinMatch, Dictionary: dictionary}
+}
+
+// Factory function for creating an LZSS decompressor instance.
+func NewLZSSDecompressor() *LZSSCompressor {
+	return NewLZSSCompressor()
+}
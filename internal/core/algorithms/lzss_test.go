@@ -0,0 +1,118 @@
+package algorithms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLzssRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []byte
+		windowSize int
+		minMatch   int
+	} {
+		{
+			name:       "Empty input",
+			input:      nil,
+			windowSize: 4096,
+			minMatch:   3,
+		},
+		{
+			name:       "Single character",
+			input:      []byte("A"),
+			windowSize: 4096,
+			minMatch:   3,
+		},
+		{
+			name:       "Repeated substrings",
+			input:      []byte("abcabcabcabcabc hello abcabc hello"),
+			windowSize: 4096,
+			minMatch:   3,
+		},
+		{
+			name:       "Overlapping run",
+			input:      bytes.Repeat([]byte{'A'}, 1000),
+			windowSize: 4096,
+			minMatch:   3,
+		},
+		{
+			name:       "Small window",
+			input:      bytes.Repeat([]byte("0123456789"), 50),
+			windowSize: 8,
+			minMatch:   2,
+		},
+		{
+			name:       "Large minimum match",
+			input:      bytes.Repeat([]byte("abcdef"), 20),
+			windowSize: 4096,
+			minMatch:   10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Lzss(tt.input, tt.windowSize, tt.minMatch)
+			if err != nil {
+				t.Fatalf("Lzss returned unexpected error: %v", err)
+			}
+
+			got, err := LzssDecode(compressed)
+			if err != nil {
+				t.Fatalf("LzssDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("LzssDecode(Lzss(x)) = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestLzssCompresses(t *testing.T) {
+	input := bytes.Repeat([]byte("the quick brown fox "), 100)
+	compressed, err := Lzss(input, 4096, 3)
+	if err != nil {
+		t.Fatalf("Lzss returned unexpected error: %v", err)
+	}
+	if len(compressed) >= len(input)/10 {
+		t.Errorf("Lzss output is %d bytes, expected less than %d", len(compressed), len(input)/10)
+	}
+}
+
+func TestLzssInvalidOptions(t *testing.T) {
+	if _, err := Lzss([]byte("abc"), 0, 3); err == nil {
+		t.Errorf("Lzss with a zero window expected an error")
+	}
+	if _, err := Lzss([]byte("abc"), 4096, 1); err == nil {
+		t.Errorf("Lzss with a minimum match of 1 expected an error")
+	}
+}
+
+func TestLzssDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Missing minimum match",
+			input: []byte{5},
+		},
+		{
+			name:  "Missing literal",
+			input: []byte{2, 3, 0x00, 'A'},
+		},
+		{
+			name:  "Offset before start",
+			input: []byte{4, 3, 0x02, 'A', 5, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LzssDecode(tt.input); err == nil {
+				t.Errorf("LzssDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package algorithms
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Largest code width used by NewLZWCompressor (and therefore by the factories in core), between 9 and 16 bits
+var LzwMaxBits = 16
+
+const (
+	lzwMagic0    = 0x1F  // First magic byte of a .Z file
+	lzwMagic1    = 0x9D  // Second magic byte of a .Z file
+	lzwBitsMask  = 0x1F  // Header bits holding the largest code width
+	lzwBlockMode = 0x80  // Header bit enabling the CLEAR code
+	lzwInitBits  = 9     // Code width at the start and after every CLEAR
+	lzwMinBits   = 9     // Smallest accepted largest code width
+	lzwMaxBits   = 16    // Largest accepted largest code width
+	lzwClear     = 256   // Code that resets the dictionary in block mode
+	lzwFirst     = 257   // First free code in block mode
+	lzwCheckGap  = 10000 // Number of input bytes between compression ratio checks once the dictionary is full
+)
+
+// --- // LZW Encoding
+
+// lzwWriter packs codes least significant bit first, in groups of eight codes the way Unix `compress` does.
+// Whenever the code width changes the current group is padded out, because `uncompress` expects that.
+type lzwWriter struct {
+	buffer     bytes.Buffer
+	acc        uint32 // Pending bits, least significant first
+	nbits      uint   // Number of pending bits in acc
+	groupCodes int    // Number of codes written to the current group
+	width      uint   // Current code width
+}
+
+// write writes one code of the current width.
+func (w *lzwWriter) write(code int) {
+	w.acc |= uint32(code) << w.nbits
+	w.nbits += w.width
+	for w.nbits >= 8 {
+		w.buffer.WriteByte(byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+	w.groupCodes = (w.groupCodes + 1) % 8
+}
+
+// setWidth pads the current group with zero bits and switches to a new code width.
+func (w *lzwWriter) setWidth(width uint) {
+	if w.groupCodes != 0 {
+		padding := (8 - w.groupCodes) * int(w.width)
+		w.nbits += uint(padding) // The pending bits above nbits are already zero
+		for w.nbits >= 8 {
+			w.buffer.WriteByte(byte(w.acc))
+			w.acc >>= 8
+			w.nbits -= 8
+		}
+		w.groupCodes = 0
+	}
+	w.width = width
+}
+
+// bytes flushes the last partial byte and returns the written data.
+func (w *lzwWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buffer.WriteByte(byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buffer.Bytes()
+}
+
+// Lzw compresses data into the Unix `compress` (.Z) format, using codes of up to `maxBits` bits.
+func Lzw(data []byte, maxBits int) ([]byte, error) {
+	if maxBits < lzwMinBits || maxBits > lzwMaxBits {
+		return nil, fmt.Errorf("invalid LZW code width %d (must be between %d and %d)", maxBits, lzwMinBits, lzwMaxBits)
+	}
+
+	w := &lzwWriter{width: lzwInitBits}
+	w.buffer.Write([]byte{lzwMagic0, lzwMagic1, byte(maxBits) | lzwBlockMode})
+	if len(data) == 0 {
+		return w.bytes(), nil // A header alone is a valid empty .Z file
+	}
+
+	maxMaxCode := 1 << maxBits
+	maxCode := 1<<lzwInitBits - 1
+	freeEnt := lzwFirst
+	dictionary := make(map[uint32]int) // (prefix code << 8 | byte) -> code
+
+	// output writes a code and widens the codes once the next free code no longer fits.
+	output := func(code int) {
+		w.write(code)
+		if freeEnt > maxCode {
+			w.setWidth(w.width + 1)
+			if int(w.width) == maxBits {
+				maxCode = maxMaxCode
+			} else {
+				maxCode = 1<<w.width - 1
+			}
+		}
+	}
+
+	checkpoint := lzwCheckGap
+	ratio := 0
+	ent := int(data[0])
+	for i := 1; i < len(data); i++ {
+		c := data[i]
+		key := uint32(ent)<<8 | uint32(c)
+		if code, ok := dictionary[key]; ok {
+			ent = code
+			continue
+		}
+
+		output(ent)
+		ent = int(c)
+
+		if freeEnt < maxMaxCode {
+			dictionary[key] = freeEnt
+			freeEnt++
+		} else if i >= checkpoint {
+			// The dictionary is full; start over once it stops paying off
+			checkpoint = i + lzwCheckGap
+			current := (i << 8) / (w.buffer.Len() + 1)
+			if current > ratio {
+				ratio = current
+			} else {
+				verbosePrintf("Lzw: clearing the dictionary at input byte %v\n", i)
+				ratio = 0
+				dictionary = make(map[uint32]int)
+				freeEnt = lzwFirst
+				w.write(lzwClear)
+				w.setWidth(lzwInitBits)
+				maxCode = 1<<lzwInitBits - 1
+			}
+		}
+	}
+	output(ent)
+
+	return w.bytes(), nil
+}
+
+// --- // LZW Decoding
+
+// LzwDecode decompresses data in the Unix `compress` (.Z) format.
+func LzwDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	if len(data) < 3 || data[0] != lzwMagic0 || data[1] != lzwMagic1 {
+		return nil, fmt.Errorf("malformed LZW data: missing .Z magic bytes")
+	}
+	maxBits := int(data[2] & lzwBitsMask)
+	blockMode := data[2]&lzwBlockMode != 0
+	if maxBits < lzwMinBits || maxBits > lzwMaxBits {
+		return nil, fmt.Errorf("malformed LZW data: unsupported code width %d", maxBits)
+	}
+	data = data[3:]
+
+	maxMaxCode := 1 << maxBits
+	prefix := make([]int, maxMaxCode)
+	suffix := make([]byte, maxMaxCode)
+	for i := 0; i < 256; i++ {
+		suffix[i] = byte(i)
+	}
+
+	width := uint(lzwInitBits)
+	maxCode := 1<<width - 1
+	freeEnt := 256
+	if blockMode {
+		freeEnt = lzwFirst
+	}
+
+	var output bytes.Buffer
+	stack := make([]byte, 0, maxMaxCode)
+	totalBits := len(data) * 8
+	pos := 0        // Bit position of the next code
+	groupStart := 0 // Bit position where the current group of codes started
+	oldCode := -1
+	var finChar byte
+
+	// skipGroup moves `pos` to the end of the current group of eight codes.
+	skipGroup := func() {
+		groupBits := int(width) * 8
+		pos = groupStart + (pos-groupStart+groupBits-1)/groupBits*groupBits
+		groupStart = pos
+	}
+
+	for {
+		if freeEnt > maxCode {
+			skipGroup()
+			width++
+			if int(width) == maxBits {
+				maxCode = maxMaxCode
+			} else {
+				maxCode = 1<<width - 1
+			}
+		}
+		if pos+int(width) > totalBits {
+			break
+		}
+
+		// Read the next code, least significant bit first
+		code := 0
+		for b := 0; b < int(width); b++ {
+			bit := pos + b
+			code |= int(data[bit>>3]>>(bit&7)&1) << b
+		}
+		pos += int(width)
+
+		if oldCode == -1 {
+			if code >= 256 {
+				return nil, fmt.Errorf("malformed LZW data: first code %d is not a literal", code)
+			}
+			oldCode = code
+			finChar = byte(code)
+			output.WriteByte(finChar)
+			continue
+		}
+
+		if code == lzwClear && blockMode {
+			verbosePrintf("LzwDecode: clear code at bit %v\n", pos)
+			freeEnt = lzwFirst - 1
+			skipGroup()
+			width = lzwInitBits
+			maxCode = 1<<width - 1
+			continue
+		}
+
+		inCode := code
+		stack = stack[:0]
+		if code >= freeEnt { // The code being defined right now (the "KwKwK" case)
+			if code > freeEnt {
+				return nil, fmt.Errorf("malformed LZW data: code %d is not defined yet", code)
+			}
+			stack = append(stack, finChar)
+			code = oldCode
+		}
+		for code >= 256 {
+			stack = append(stack, suffix[code])
+			code = prefix[code]
+		}
+		finChar = suffix[code]
+		stack = append(stack, finChar)
+
+		for i := len(stack) - 1; i >= 0; i-- {
+			output.WriteByte(stack[i])
+		}
+
+		if freeEnt < maxMaxCode {
+			prefix[freeEnt] = oldCode
+			suffix[freeEnt] = finChar
+			freeEnt++
+		}
+		oldCode = inCode
+	}
+
+	return output.Bytes(), nil
+}
+
+// --- // LZW Compressor Interface
+
+// LZWCompressor implements core.GeneralCompressor and core.GeneralDecompressor for the Unix `compress` (.Z) format.
+type LZWCompressor struct {
+	MaxBits int // Largest code width, between 9 and 16 bits
+}
+
+// Compress implements core.Compressor.
+func (l *LZWCompressor) Compress(data []byte) ([]byte, error) {
+	return Lzw(data, l.MaxBits)
+}
+
+// Decompress implements core.Decompressor.
+func (l *LZWCompressor) Decompress(data []byte) ([]byte, error) {
+	return LzwDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (l *LZWCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("LzwCompressFile", inputFilePath, outputFilePath, l.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (l *LZWCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("LzwDecompressFile", inputFilePath, outputFilePath, l.Decompress)
+}
+
+// Factory function for creating an LZW compressor instance using LzwMaxBits.
+func NewLZWCompressor() *LZWCompressor {
+	return NewLZWCompressorWithMaxBits(LzwMaxBits)
+}
+
+// Factory function for creating an LZW compressor instance with a custom largest code width.
+func NewLZWCompressorWithMaxBits(maxBits int) *LZWCompressor {
+	return &LZWCompressor{MaxBits: maxBits}
+}
+
+// Factory function for creating an LZW decompressor instance.
+func NewLZWDecompressor() *LZWCompressor {
+	return NewLZWCompressor()
+}
@@ -0,0 +1,107 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestLzwRoundTrip(t *testing.T) {
+	// Pseudo-random text makes the dictionary fill up, which exercises the CLEAR code
+	random := rand.New(rand.NewSource(1))
+	noisy := make([]byte, 300000)
+	for i := range noisy {
+		noisy[i] = byte('a' + random.Intn(26))
+	}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		maxBits int
+	} {
+		{
+			name:    "Empty input",
+			input:   nil,
+			maxBits: 16,
+		},
+		{
+			name:    "Single character",
+			input:   []byte("A"),
+			maxBits: 16,
+		},
+		{
+			name:    "KwKwK case",
+			input:   []byte("abababababababab"),
+			maxBits: 16,
+		},
+		{
+			name:    "Long run",
+			input:   bytes.Repeat([]byte{'A'}, 100000),
+			maxBits: 16,
+		},
+		{
+			name:    "Noisy text, 9 bits",
+			input:   noisy,
+			maxBits: 9,
+		},
+		{
+			name:    "Noisy text, 12 bits",
+			input:   noisy,
+			maxBits: 12,
+		},
+		{
+			name:    "Noisy text, 16 bits",
+			input:   noisy,
+			maxBits: 16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Lzw(tt.input, tt.maxBits)
+			if err != nil {
+				t.Fatalf("Lzw returned unexpected error: %v", err)
+			}
+			if compressed[0] != 0x1F || compressed[1] != 0x9D || compressed[2] != byte(tt.maxBits)|0x80 {
+				t.Fatalf("Lzw header = %x, want 1f9d%02x", compressed[:3], tt.maxBits|0x80)
+			}
+
+			got, err := LzwDecode(compressed)
+			if err != nil {
+				t.Fatalf("LzwDecode returned unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("LzwDecode(Lzw(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestLzwDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Missing magic",
+			input: []byte{'h', 'i', 0x90},
+		},
+		{
+			name:  "Unsupported code width",
+			input: []byte{0x1F, 0x9D, 0x80 | 20},
+		},
+		{
+			name:  "Undefined code",
+			input: []byte{0x1F, 0x9D, 0x90, 'A', 0xFE, 0x03},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LzwDecode(tt.input); err == nil {
+				t.Errorf("LzwDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
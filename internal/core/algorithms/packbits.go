@@ -0,0 +1,140 @@
+package algorithms
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	packBitsMaxLiteral = 128 // Longest literal run a header byte can describe
+	packBitsMaxRepeat  = 128 // Longest repeat run a header byte can describe
+	packBitsMinRepeat  = 3   // Shortest repeat run worth breaking a literal run for
+	packBitsNoOp       = 128 // Header byte (-128) that decoders skip
+)
+
+// --- // PackBits Encoding
+
+// PackBits encodes data with the TIFF/Apple PackBits convention, returning a byte slice.
+// Every run starts with a header byte `n` read as a signed integer:
+// 0 to 127 means the next n+1 bytes are copied literally, -1 to -127 means the next byte is repeated 1-n times and -128 is skipped.
+// Unlike `Rle`, bytes without repeats only cost one extra header byte per 128 bytes.
+func PackBits(data []byte) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	if DATA_LEN == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	literalStart := 0 // Start of the pending literal run
+
+	// flushLiterals writes data[literalStart:end] as literal runs.
+	flushLiterals := func(end int) {
+		for literalStart < end {
+			count := end - literalStart
+			if count > packBitsMaxLiteral {
+				count = packBitsMaxLiteral
+			}
+			buffer.WriteByte(byte(count - 1))
+			buffer.Write(data[literalStart : literalStart+count])
+			verbosePrintf("PackBits: literal run of %v bytes\n", count)
+			literalStart += count
+		}
+	}
+
+	for i := 0; i < DATA_LEN; {
+		// Measure the run starting at i
+		run := 1
+		for i+run < DATA_LEN && data[i+run] == data[i] && run < packBitsMaxRepeat {
+			run++
+		}
+
+		if run < packBitsMinRepeat {
+			i += run // Leave short runs inside the literal run
+			continue
+		}
+
+		flushLiterals(i)
+		buffer.WriteByte(byte(1 - run)) // Two's complement of run-1
+		buffer.WriteByte(data[i])
+		verbosePrintf("PackBits: repeat run of %v x %v\n", run, data[i])
+		i += run
+		literalStart = i
+	}
+	flushLiterals(DATA_LEN)
+
+	return buffer.Bytes(), nil
+}
+
+// --- // PackBits Decoding
+
+// PackBitsDecode decodes data produced by PackBits (or any other PackBits encoder).
+func PackBitsDecode(data []byte) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	if DATA_LEN == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	for i := 0; i < DATA_LEN; {
+		header := data[i]
+		i++
+
+		switch {
+		case header < 128: // Literal run
+			count := int(header) + 1
+			if i+count > DATA_LEN {
+				return nil, fmt.Errorf("malformed PackBits data: literal run of %d bytes at index %d is truncated", count, i-1)
+			}
+			buffer.Write(data[i : i+count])
+			i += count
+		case header == packBitsNoOp:
+			continue
+		default: // Repeat run
+			if i >= DATA_LEN {
+				return nil, fmt.Errorf("malformed PackBits data: repeat run at index %d is missing its byte", i-1)
+			}
+			count := 257 - int(header) // 1 - int8(header)
+			buffer.Write(bytes.Repeat(data[i:i+1], count))
+			i++
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// --- // PackBits Compressor Interface
+
+// PackBitsCompressor implements core.GeneralCompressor and core.GeneralDecompressor with PackBits.
+type PackBitsCompressor struct{}
+
+// Compress implements core.Compressor.
+func (p *PackBitsCompressor) Compress(data []byte) ([]byte, error) {
+	return PackBits(data)
+}
+
+// Decompress implements core.Decompressor.
+func (p *PackBitsCompressor) Decompress(data []byte) ([]byte, error) {
+	return PackBitsDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (p *PackBitsCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("PackBitsCompressFile", inputFilePath, outputFilePath, p.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (p *PackBitsCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("PackBitsDecompressFile", inputFilePath, outputFilePath, p.Decompress)
+}
+
+// Factory function for creating a PackBits compressor instance.
+func NewPackBitsCompressor() *PackBitsCompressor {
+	return &PackBitsCompressor{}
+}
+
+// Factory function for creating a PackBits decompressor instance.
+func NewPackBitsDecompressor() *PackBitsCompressor {
+	return &PackBitsCompressor{}
+}
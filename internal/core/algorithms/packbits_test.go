@@ -0,0 +1,102 @@
+package algorithms
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackBits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	} {
+		{
+			name:     "Empty input",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "Single character",
+			input:    []byte("A"),
+			expected: []byte{0, 'A'},
+		},
+		{
+			name:     "No repeated characters",
+			input:    []byte("ABCDEFG"),
+			expected: []byte{6, 'A', 'B', 'C', 'D', 'E', 'F', 'G'},
+		},
+		{
+			name:     "Apple example",
+			input:    []byte{
+				0xAA, 0xAA, 0xAA, 0x80, 0x00, 0x2A, 0xAA, 0xAA, 0xAA, 0xAA, 0x80, 0x00,
+				0x2A, 0x22, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA,
+			},
+			expected: []byte{0xFE, 0xAA, 0x02, 0x80, 0x00, 0x2A, 0xFD, 0xAA, 0x03, 0x80, 0x00, 0x2A, 0x22, 0xF7, 0xAA},
+		},
+		{
+			name:     "Long run",
+			input:    bytes.Repeat([]byte{'A'}, 300),
+			expected: []byte{0x81, 'A', 0x81, 'A', 0xD5, 'A'}, // 128 + 128 + 44
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PackBits(tt.input)
+			if err != nil {
+				t.Fatalf("PackBits(%v) returned unexpected error: %v", tt.input, err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("PackBits(%v) = %v, want %v", tt.input, got, tt.expected)
+			}
+
+			decoded, err := PackBitsDecode(got)
+			if err != nil {
+				t.Fatalf("PackBitsDecode(%v) returned unexpected error: %v", got, err)
+			}
+			if !bytes.Equal(decoded, tt.input) {
+				t.Errorf("PackBitsDecode(%v) = %v, want %v", got, decoded, tt.input)
+			}
+		})
+	}
+}
+
+func TestPackBitsWorstCase(t *testing.T) {
+	input := make([]byte, 128*100)
+	for i := range input {
+		input[i] = byte(i % 251) // Never repeats
+	}
+
+	got, err := PackBits(input)
+	if err != nil {
+		t.Fatalf("PackBits returned unexpected error: %v", err)
+	}
+	if len(got) != len(input)+100 {
+		t.Errorf("PackBits output is %d bytes, want %d (one header byte per 128 bytes)", len(got), len(input)+100)
+	}
+}
+
+func TestPackBitsDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Truncated literal run",
+			input: []byte{5, 'A', 'B'},
+		},
+		{
+			name:  "Repeat run without byte",
+			input: []byte{0xFE},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PackBitsDecode(tt.input); err == nil {
+				t.Errorf("PackBitsDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Options for PPM, used by NewPPMCompressor (and therefore by the factories in core)
+var (
+	PpmOrder    = 6  // Longest context in bytes, between 1 and 16
+	PpmMemoryMB = 64 // Memory limit of the model in MB; the model starts over once it is reached
+)
+
+const (
+	ppmMaxOrder       = 16      // Largest supported model order
+	ppmMaxMemoryMB    = 2048    // Largest supported memory limit
+	ppmMaxFrequency   = 1 << 13 // Frequencies of a context are halved once their sum exceeds this
+	ppmFrequencyStep  = 2       // Added to the frequency of a symbol every time it is seen (it starts at 1)
+	ppmNodeCost       = 48      // Approximate size in bytes of a context, used for the memory limit
+	ppmEntryCost      = 16      // Approximate size in bytes of a symbol of a context
+	ppmMaxOutputRatio = 64      // Bound on the initial capacity of the output, relative to the compressed size
+)
+
+// --- // PPM Model
+
+// ppmEntry is a symbol seen in a context, with its frequency and the longer context that ends with it.
+type ppmEntry struct {
+	child  *ppmNode
+	freq   uint16
+	symbol byte
+}
+
+// ppmNode is a context: the symbols seen after it, in the order they were first seen.
+type ppmNode struct {
+	entries []ppmEntry
+	total   uint32 // Sum of the frequencies of the entries
+}
+
+// find returns the index of the entry of `symbol`, or -1.
+func (n *ppmNode) find(symbol byte) int {
+	for i := range n.entries {
+		if n.entries[i].symbol == symbol {
+			return i
+		}
+	}
+	return -1
+}
+
+// ppmModel predicts bytes from the contexts of the previous 0 to `order` bytes (prediction by partial matching).
+// A byte is coded in the longest context that has seen it before; every context in between codes an escape, whose
+// frequency is the number of different symbols of the context (PPM method D). Symbols of a context that escaped
+// are excluded from the shorter contexts, and bytes never seen in the order 0 context are coded with equal frequencies.
+type ppmModel struct {
+	order      int
+	limit      int // Memory limit in bytes
+	used       int // Approximate memory used by the contexts
+	root       *ppmNode
+	contexts   []*ppmNode // contexts[k] is the context of the previous k bytes, or nil if it is not known
+	excluded   [256]uint32
+	generation uint32 // Symbols whose `excluded` equals this are excluded
+}
+
+// newPPMModel creates an empty model.
+func newPPMModel(order int, memoryMB int) *ppmModel {
+	m := &ppmModel{order: order, limit: memoryMB << 20, contexts: make([]*ppmNode, order+1)}
+	m.reset()
+	return m
+}
+
+// reset forgets everything the model has seen.
+func (m *ppmModel) reset() {
+	m.root = &ppmNode{}
+	m.used = ppmNodeCost
+	for i := range m.contexts {
+		m.contexts[i] = nil
+	}
+	m.contexts[0] = m.root
+}
+
+// highest returns the order of the longest known context.
+func (m *ppmModel) highest() int {
+	k := m.order
+	for m.contexts[k] == nil {
+		k--
+	}
+	return k
+}
+
+// frequencies returns the sum of the frequencies of the symbols of `node` that are not excluded, and their number.
+func (m *ppmModel) frequencies(node *ppmNode) (sum uint32, count uint32) {
+	for _, entry := range node.entries {
+		if m.excluded[entry.symbol] != m.generation {
+			sum += uint32(entry.freq)
+			count++
+		}
+	}
+	return sum, count
+}
+
+// exclude excludes all the symbols of `node` from the shorter contexts.
+func (m *ppmModel) exclude(node *ppmNode) {
+	for _, entry := range node.entries {
+		m.excluded[entry.symbol] = m.generation
+	}
+}
+
+// add makes `node` see `symbol`, and returns its entry.
+func (m *ppmModel) add(node *ppmNode, symbol byte) *ppmEntry {
+	i := node.find(symbol)
+	if i < 0 {
+		node.entries = append(node.entries, ppmEntry{symbol: symbol, freq: 1})
+		node.total++
+		m.used += ppmEntryCost
+		return &node.entries[len(node.entries)-1]
+	}
+
+	entry := &node.entries[i]
+	entry.freq += ppmFrequencyStep
+	node.total += ppmFrequencyStep
+	if node.total > ppmMaxFrequency {
+		node.total = 0
+		for j := range node.entries {
+			node.entries[j].freq = (node.entries[j].freq + 1) / 2
+			node.total += uint32(node.entries[j].freq)
+		}
+	}
+	return entry
+}
+
+// update adds `symbol` to the contexts from order `found` (where it was coded, or -1) up, then moves to the
+// contexts that end with it.
+func (m *ppmModel) update(symbol byte, found int) {
+	if found < 0 {
+		found = 0
+	}
+	highest := m.highest()
+	for k := found; k <= highest; k++ {
+		m.add(m.contexts[k], symbol)
+	}
+
+	// Longer contexts first, so that contexts[k] is still the old one when contexts[k+1] is derived from it
+	if highest == m.order {
+		highest--
+	}
+	for k := highest; k >= 0; k-- {
+		node := m.contexts[k]
+		i := node.find(symbol)
+		if i < 0 {
+			m.contexts[k+1] = nil
+			continue
+		}
+		entry := &node.entries[i]
+		if entry.child == nil {
+			entry.child = &ppmNode{}
+			m.used += ppmNodeCost
+		}
+		m.contexts[k+1] = entry.child
+	}
+
+	if m.used > m.limit {
+		m.reset()
+	}
+}
+
+// encodeByte encodes `symbol` and updates the model.
+func (m *ppmModel) encodeByte(e *rangeEncoder, symbol byte) {
+	m.generation++
+	found := -1
+	for k := m.highest(); k >= 0 && found < 0; k-- {
+		node := m.contexts[k]
+		sum, count := m.frequencies(node)
+		if count == 0 {
+			continue // Nothing to code, the decoder skips this context as well
+		}
+		total := sum + count
+
+		cum := uint32(0)
+		for _, entry := range node.entries {
+			if m.excluded[entry.symbol] == m.generation {
+				continue
+			}
+			if entry.symbol == symbol {
+				e.encodeFreq(cum, uint32(entry.freq), total)
+				found = k
+				break
+			}
+			cum += uint32(entry.freq)
+		}
+		if found < 0 {
+			e.encodeFreq(sum, count, total) // Escape
+			m.exclude(node)
+		}
+	}
+
+	if found < 0 {
+		cum, count := uint32(0), uint32(0)
+		for c := 0; c < 256; c++ {
+			if m.excluded[c] != m.generation {
+				if c < int(symbol) {
+					cum++
+				}
+				count++
+			}
+		}
+		e.encodeFreq(cum, 1, count)
+	}
+	m.update(symbol, found)
+}
+
+// decodeByte decodes a byte written by encodeByte and updates the model.
+func (m *ppmModel) decodeByte(d *rangeDecoder) (byte, error) {
+	m.generation++
+	for k := m.highest(); k >= 0; k-- {
+		node := m.contexts[k]
+		sum, count := m.frequencies(node)
+		if count == 0 {
+			continue
+		}
+
+		target, err := d.decodeFreq(sum + count)
+		if err != nil {
+			return 0, err
+		}
+		if target >= sum {
+			if err := d.consumeFreq(sum, count); err != nil {
+				return 0, err
+			}
+			m.exclude(node)
+			continue
+		}
+
+		cum := uint32(0)
+		for _, entry := range node.entries {
+			if m.excluded[entry.symbol] == m.generation {
+				continue
+			}
+			if target < cum+uint32(entry.freq) {
+				if err := d.consumeFreq(cum, uint32(entry.freq)); err != nil {
+					return 0, err
+				}
+				m.update(entry.symbol, k)
+				return entry.symbol, nil
+			}
+			cum += uint32(entry.freq)
+		}
+	}
+
+	count := uint32(0)
+	for c := 0; c < 256; c++ {
+		if m.excluded[c] != m.generation {
+			count++
+		}
+	}
+	if count == 0 { // Every byte was excluded, which only corrupt data can lead to
+		return 0, fmt.Errorf("malformed ppm data: no symbol left to decode")
+	}
+	target, err := d.decodeFreq(count)
+	if err != nil {
+		return 0, err
+	}
+	cum := uint32(0)
+	for c := 0; c < 256; c++ {
+		if m.excluded[c] == m.generation {
+			continue
+		}
+		if cum == target {
+			if err := d.consumeFreq(cum, 1); err != nil {
+				return 0, err
+			}
+			m.update(byte(c), -1)
+			return byte(c), nil
+		}
+		cum++
+	}
+	return 0, fmt.Errorf("malformed ppm data: no symbol left to decode")
+}
+
+// --- // PPM Encoding
+
+// checkPpmOptions validates the model order and the memory limit.
+func checkPpmOptions(order int, memoryMB int) error {
+	if order < 1 || order > ppmMaxOrder {
+		return fmt.Errorf("invalid ppm model order %d (must be between 1 and %d)", order, ppmMaxOrder)
+	}
+	if memoryMB < 1 || memoryMB > ppmMaxMemoryMB {
+		return fmt.Errorf("invalid ppm memory limit %d MB (must be between 1 and %d)", memoryMB, ppmMaxMemoryMB)
+	}
+	return nil
+}
+
+// Ppm compresses data with a PPM model of the given order that uses at most about `memoryMB` MB.
+// The output starts with the uncompressed length (as a uvarint), the model order and the memory limit (as a uvarint),
+// because the decoder has to build the same model.
+func Ppm(data []byte, order int, memoryMB int) ([]byte, error) {
+	if err := checkPpmOptions(order, memoryMB); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(binary.AppendUvarint(nil, uint64(len(data))))
+	buffer.WriteByte(byte(order))
+	buffer.Write(binary.AppendUvarint(nil, uint64(memoryMB)))
+
+	e := newRangeEncoder()
+	model := newPPMModel(order, memoryMB)
+	for _, b := range data {
+		model.encodeByte(e, b)
+	}
+	buffer.Write(e.bytes())
+
+	verbosePrintf("Ppm: order: %v, memory: %v MB, len(data): %v, len(compressed): %v\n", order, memoryMB, len(data), buffer.Len())
+	return buffer.Bytes(), nil
+}
+
+// --- // PPM Decoding
+
+// PpmDecode decodes data produced by Ppm.
+func PpmDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 || len(data) < n+1 {
+		return nil, fmt.Errorf("malformed ppm data: invalid header")
+	}
+	order := int(data[n])
+	memoryMB, m := binary.Uvarint(data[n+1:])
+	if m <= 0 {
+		return nil, fmt.Errorf("malformed ppm data: invalid header")
+	}
+	if memoryMB > ppmMaxMemoryMB {
+		return nil, fmt.Errorf("malformed ppm data: unsupported memory limit %d MB", memoryMB)
+	}
+	if err := checkPpmOptions(order, int(memoryMB)); err != nil {
+		return nil, fmt.Errorf("malformed ppm data: %w", err)
+	}
+
+	d, err := newRangeDecoder(data[n+1+m:])
+	if err != nil {
+		return nil, err
+	}
+	// Never trust the header for the allocation size
+	capacity := size
+	if limit := uint64(len(data)) * ppmMaxOutputRatio; capacity > limit {
+		capacity = limit
+	}
+	model := newPPMModel(order, int(memoryMB))
+	output := make([]byte, 0, capacity)
+	for uint64(len(output)) < size {
+		b, err := model.decodeByte(d)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, b)
+	}
+
+	return output, nil
+}
+
+// --- // PPM Compressor Interface
+
+// PPMCompressor implements core.GeneralCompressor and core.GeneralDecompressor with prediction by partial matching.
+// It is slow, but compresses text better than the other algorithms.
+type PPMCompressor struct {
+	Order    int // Longest context in bytes, between 1 and 16
+	MemoryMB int // Memory limit of the model in MB
+}
+
+// Compress implements core.Compressor.
+func (p *PPMCompressor) Compress(data []byte) ([]byte, error) {
+	return Ppm(data, p.Order, p.MemoryMB)
+}
+
+// Decompress implements core.Decompressor.
+func (p *PPMCompressor) Decompress(data []byte) ([]byte, error) {
+	return PpmDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (p *PPMCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("PpmCompressFile", inputFilePath, outputFilePath, p.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (p *PPMCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("PpmDecompressFile", inputFilePath, outputFilePath, p.Decompress)
+}
+
+// Factory function for creating a PPM compressor instance using PpmOrder and PpmMemoryMB.
+func NewPPMCompressor() *PPMCompressor {
+	return NewPPMCompressorWithOptions(PpmOrder, PpmMemoryMB)
+}
+
+// Factory function for creating a PPM compressor instance with a custom model order and memory limit.
+func NewPPMCompressorWithOptions(order int, memoryMB int) *PPMCompressor {
+	return &PPMCompressor{Order: order, MemoryMB: memoryMB}
+}
+
+// Factory function for creating a PPM decompressor instance.
+func NewPPMDecompressor() *PPMCompressor {
+	return NewPPMCompressor()
+}
@@ -0,0 +1,177 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestPpmRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(5))
+	noise := make([]byte, 10000)
+	random.Read(noise)
+
+	inputs := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Single character", input: []byte("A")},
+		{name: "Text", input: lz4FixtureContent()},
+		{name: "Random bytes", input: noise},
+		{name: "Long run", input: bytes.Repeat([]byte{0}, 100000)},
+		{name: "All bytes", input: bytes.Repeat([]byte("\x00\x01\x02\x03\xfc\xfd\xfe\xff"), 1000)},
+	}
+
+	for _, order := range []int{1, 4, 16} {
+		for _, tt := range inputs {
+			t.Run(tt.name, func(t *testing.T) {
+				compressed, err := Ppm(tt.input, order, 16)
+				if err != nil {
+					t.Fatalf("Ppm(order %d) returned unexpected error: %v", order, err)
+				}
+
+				got, err := PpmDecode(compressed)
+				if err != nil {
+					t.Fatalf("PpmDecode(order %d) returned unexpected error: %v", order, err)
+				}
+
+				if !bytes.Equal(got, tt.input) {
+					t.Errorf("PpmDecode(Ppm(x, %d)) returned %d bytes, want %d", order, len(got), len(tt.input))
+				}
+			})
+		}
+	}
+}
+
+func TestPpmMemoryLimit(t *testing.T) {
+	// 1 MB holds the order-12 contexts of about a tenth of the text, so the model starts over several times
+	input := lz4FixtureContent()
+	limited, err := Ppm(input, 12, 1)
+	if err != nil {
+		t.Fatalf("Ppm returned unexpected error: %v", err)
+	}
+	got, err := PpmDecode(limited)
+	if err != nil {
+		t.Fatalf("PpmDecode returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, input) {
+		t.Errorf("PpmDecode(Ppm(x)) with a memory limit returned %d bytes, want %d", len(got), len(input))
+	}
+
+	unlimited, _ := Ppm(input, 12, 64)
+	if len(unlimited) >= len(limited) {
+		t.Errorf("Ppm gave %d bytes with 64 MB and %d with 1 MB, expected fewer with more memory", len(unlimited), len(limited))
+	}
+}
+
+func TestPpmBeatsOtherAlgorithmsOnText(t *testing.T) {
+	input := lz4FixtureContent()
+	ppm, err := Ppm(input, PpmOrder, PpmMemoryMB)
+	if err != nil {
+		t.Fatalf("Ppm returned unexpected error: %v", err)
+	}
+
+	others := []struct {
+		name     string
+		compress func([]byte) ([]byte, error)
+	} {
+		{name: "Huffman", compress: Huffman},
+		{name: "Range order 2", compress: func(data []byte) ([]byte, error) { return RangeEncode(data, 2) }},
+		{name: "Xz", compress: Xz},
+	}
+	for _, tt := range others {
+		t.Run(tt.name, func(t *testing.T) {
+			other, err := tt.compress(input)
+			if err != nil {
+				t.Fatalf("%s returned unexpected error: %v", tt.name, err)
+			}
+			if len(ppm) >= len(other) {
+				t.Errorf("Ppm output is %d bytes, expected less than %s's %d", len(ppm), tt.name, len(other))
+			}
+		})
+	}
+}
+
+func TestPpmHigherOrderHelpsText(t *testing.T) {
+	input := lz4FixtureContent()
+
+	order1, _ := Ppm(input, 1, PpmMemoryMB)
+	order4, _ := Ppm(input, 4, PpmMemoryMB)
+	if len(order4) >= len(order1) {
+		t.Errorf("order-4 output is %d bytes, expected less than order-1's %d", len(order4), len(order1))
+	}
+}
+
+func TestPpmInvalid(t *testing.T) {
+	options := []struct {
+		name     string
+		order    int
+		memoryMB int
+	} {
+		{name: "Order 0", order: 0, memoryMB: 16},
+		{name: "Order 17", order: 17, memoryMB: 16},
+		{name: "No memory", order: 4, memoryMB: 0},
+		{name: "Too much memory", order: 4, memoryMB: 4096},
+	}
+	for _, tt := range options {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Ppm([]byte("abc"), tt.order, tt.memoryMB); err == nil {
+				t.Errorf("Ppm(order %d, %d MB) expected an error", tt.order, tt.memoryMB)
+			}
+		})
+	}
+
+	compressed, err := Ppm(lz4FixtureContent(), 4, 16)
+	if err != nil {
+		t.Fatalf("Ppm returned unexpected error: %v", err)
+	}
+	malformed := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Truncated header", input: []byte{0x80}},
+		{name: "Missing memory limit", input: []byte{3, 4}},
+		{name: "Order 0", input: []byte{3, 0, 16, 0, 0, 0, 0, 0}},
+		{name: "Memory limit too large", input: []byte{3, 4, 0x80, 0x40, 0, 0, 0, 0, 0}},
+		{name: "Truncated data", input: compressed[:len(compressed)/2]},
+	}
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PpmDecode(tt.input); err == nil {
+				t.Errorf("PpmDecode(%d bytes) expected an error", len(tt.input))
+			}
+		})
+	}
+}
+
+func TestPpmDecodeCorrupt(t *testing.T) {
+	compressed, err := Ppm(lz4FixtureContent(), 4, 1)
+	if err != nil {
+		t.Fatalf("Ppm returned unexpected error: %v", err)
+	}
+
+	// Every truncation is missing part of the data, so it must fail (and never panic)
+	for i := 1; i < len(compressed); i += len(compressed)/25 + 1 {
+		if _, err := PpmDecode(compressed[:i]); err == nil {
+			t.Errorf("PpmDecode of the first %d of %d bytes expected an error", i, len(compressed))
+		}
+	}
+
+	// Garbage after a valid header decodes to something or fails, but never panics
+	random := rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		garbage := make([]byte, 2048)
+		random.Read(garbage)
+		PpmDecode(append([]byte{0x80, 0x80, 0x01, byte(1 + i%8), 1}, garbage...)) // 16384 bytes, orders 1 to 8, 1 MB
+	}
+
+	// An empty distribution, which only corrupt data leads to, is an error rather than a division by zero
+	d, err := newRangeDecoder([]byte{0x00, 0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE})
+	if err != nil {
+		t.Fatalf("newRangeDecoder returned unexpected error: %v", err)
+	}
+	if _, err := d.decodeFreq(0); err == nil {
+		t.Errorf("decodeFreq(0) expected an error")
+	}
+}
@@ -0,0 +1,388 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Context model order used by NewRangeCompressor (and therefore by the factories in core), between 0 and 2
+var RangeOrder = 1
+
+const (
+	rangeMaxOrder        = 2       // Largest supported context model order
+	rangeProbabilityBits = 11      // Precision of the bit probabilities
+	rangeProbabilityInit = 1 << 10 // Probability of a 0 bit before anything was seen (one half)
+	rangeMoveBits        = 5       // Adaptation speed; larger is slower
+	rangeTopValue        = 1 << 24 // The range is renormalized once it drops below this
+	rangeMaxOutputRatio  = 64      // Bound on the initial capacity of the output, relative to the compressed size
+)
+
+// --- // Binary Range Encoder
+
+// rangeEncoder encodes bits with adaptive probabilities, in the style of LZMA's range coder.
+type rangeEncoder struct {
+	buffer    bytes.Buffer
+	low       uint64
+	rng       uint32
+	cache     byte
+	cacheSize int
+}
+
+// newRangeEncoder creates an empty rangeEncoder.
+func newRangeEncoder() *rangeEncoder {
+	return &rangeEncoder{rng: 0xFFFFFFFF, cacheSize: 1}
+}
+
+// shiftLow moves the top byte of low to the output, resolving pending carries.
+func (e *rangeEncoder) shiftLow() {
+	if uint32(e.low) < 0xFF000000 || e.low>>32 != 0 {
+		carry := byte(e.low >> 32)
+		temp := e.cache
+		for ; e.cacheSize > 0; e.cacheSize-- {
+			e.buffer.WriteByte(temp + carry)
+			temp = 0xFF
+		}
+		e.cache = byte(e.low >> 24)
+	}
+	e.cacheSize++
+	e.low = (e.low & 0x00FFFFFF) << 8
+}
+
+// encodeBit encodes one bit with the probability (of a 0) at `prob`, then adapts the probability.
+func (e *rangeEncoder) encodeBit(prob *uint16, bit int) {
+	bound := (e.rng >> rangeProbabilityBits) * uint32(*prob)
+	if bit == 0 {
+		e.rng = bound
+		*prob += (1<<rangeProbabilityBits - *prob) >> rangeMoveBits
+	} else {
+		e.low += uint64(bound)
+		e.rng -= bound
+		*prob -= *prob >> rangeMoveBits
+	}
+	for e.rng < rangeTopValue {
+		e.rng <<= 8
+		e.shiftLow()
+	}
+}
+
+// encodeDirectBits encodes the low `n` bits of `value` (most significant first), each with a fixed probability of one half.
+func (e *rangeEncoder) encodeDirectBits(value uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.rng >>= 1
+		if value>>i&1 != 0 {
+			e.low += uint64(e.rng)
+		}
+		for e.rng < rangeTopValue {
+			e.rng <<= 8
+			e.shiftLow()
+		}
+	}
+}
+
+// encodeFreq encodes the symbol that has the frequencies from `cum` to `cum+freq` of `total`, for models with counts
+// instead of bit probabilities. `total` must be at most 2^16.
+func (e *rangeEncoder) encodeFreq(cum, freq, total uint32) {
+	r := e.rng / total
+	e.low += uint64(r * cum)
+	e.rng = r * freq
+	for e.rng < rangeTopValue {
+		e.rng <<= 8
+		e.shiftLow()
+	}
+}
+
+// pending returns an upper bound of the size of the encoded data if the encoder was flushed now.
+func (e *rangeEncoder) pending() int {
+	return e.buffer.Len() + e.cacheSize + 5
+}
+
+// bytes flushes the encoder and returns the encoded data.
+func (e *rangeEncoder) bytes() []byte {
+	for i := 0; i < 5; i++ {
+		e.shiftLow()
+	}
+	return e.buffer.Bytes()
+}
+
+// --- // Binary Range Decoder
+
+// rangeDecoder decodes bits written by rangeEncoder.
+type rangeDecoder struct {
+	data []byte
+	pos  int
+	code uint32
+	rng  uint32
+}
+
+// newRangeDecoder creates a rangeDecoder over `data`.
+func newRangeDecoder(data []byte) (*rangeDecoder, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return nil, fmt.Errorf("malformed range coder data: invalid start")
+	}
+	d := &rangeDecoder{data: data, pos: 5, rng: 0xFFFFFFFF}
+	d.code = binary.BigEndian.Uint32(data[1:5])
+	return d, nil
+}
+
+// nextByte returns the next input byte, or an error past the end of the input.
+func (d *rangeDecoder) nextByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, fmt.Errorf("malformed range coder data: unexpected end of data")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+// decodeBit decodes one bit with the probability (of a 0) at `prob`, then adapts the probability.
+func (d *rangeDecoder) decodeBit(prob *uint16) (int, error) {
+	bound := (d.rng >> rangeProbabilityBits) * uint32(*prob)
+	bit := 0
+	if d.code < bound {
+		d.rng = bound
+		*prob += (1<<rangeProbabilityBits - *prob) >> rangeMoveBits
+	} else {
+		d.code -= bound
+		d.rng -= bound
+		*prob -= *prob >> rangeMoveBits
+		bit = 1
+	}
+	for d.rng < rangeTopValue {
+		b, err := d.nextByte()
+		if err != nil {
+			return 0, err
+		}
+		d.rng <<= 8
+		d.code = d.code<<8 | uint32(b)
+	}
+	return bit, nil
+}
+
+// decodeDirectBits decodes `n` bits written by encodeDirectBits.
+func (d *rangeDecoder) decodeDirectBits(n int) (uint32, error) {
+	value := uint32(0)
+	for i := 0; i < n; i++ {
+		d.rng >>= 1
+		bit := uint32(0)
+		if d.code >= d.rng {
+			d.code -= d.rng
+			bit = 1
+		}
+		value = value<<1 | bit
+		for d.rng < rangeTopValue {
+			b, err := d.nextByte()
+			if err != nil {
+				return 0, err
+			}
+			d.rng <<= 8
+			d.code = d.code<<8 | uint32(b)
+		}
+	}
+	return value, nil
+}
+
+// decodeFreq returns the cumulative frequency (out of `total`) of the next symbol written by encodeFreq. The symbol
+// must then be removed with consumeFreq.
+func (d *rangeDecoder) decodeFreq(total uint32) (uint32, error) {
+	if total == 0 || d.rng < total { // Only corrupt data leads to an empty or too precise distribution
+		return 0, fmt.Errorf("malformed range coder data: invalid frequency total %d", total)
+	}
+	d.rng /= total
+	value := d.code / d.rng
+	if value >= total {
+		return 0, fmt.Errorf("malformed range coder data: frequency out of range")
+	}
+	return value, nil
+}
+
+// consumeFreq removes the symbol with the frequencies from `cum` to `cum+freq` found by decodeFreq.
+func (d *rangeDecoder) consumeFreq(cum, freq uint32) error {
+	d.code -= cum * d.rng
+	d.rng *= freq
+	for d.rng < rangeTopValue {
+		b, err := d.nextByte()
+		if err != nil {
+			return err
+		}
+		d.rng <<= 8
+		d.code = d.code<<8 | uint32(b)
+	}
+	return nil
+}
+
+// --- // Order-N Context Model
+
+// rangeModel holds a binary tree of bit probabilities for every context of the previous `order` bytes.
+// Trees are only allocated once their context shows up.
+type rangeModel struct {
+	order    int
+	contexts []*[256]uint16
+	context  int // Index of the current context
+}
+
+// newRangeModel creates a model of the given order.
+func newRangeModel(order int) *rangeModel {
+	return &rangeModel{order: order, contexts: make([]*[256]uint16, 1<<(8*order))}
+}
+
+// tree returns the probability tree of the current context.
+func (m *rangeModel) tree() *[256]uint16 {
+	tree := m.contexts[m.context]
+	if tree == nil {
+		tree = &[256]uint16{}
+		for i := range tree {
+			tree[i] = rangeProbabilityInit
+		}
+		m.contexts[m.context] = tree
+	}
+	return tree
+}
+
+// update makes `b` part of the context of the next byte.
+func (m *rangeModel) update(b byte) {
+	m.context = (m.context<<8 | int(b)) & (len(m.contexts) - 1)
+}
+
+// encodeByte encodes `b` most significant bit first, each bit with the probability of its position in the tree.
+func (m *rangeModel) encodeByte(e *rangeEncoder, b byte) {
+	tree := m.tree()
+	node := 1
+	for i := 7; i >= 0; i-- {
+		bit := int(b>>i) & 1
+		e.encodeBit(&tree[node], bit)
+		node = node<<1 | bit
+	}
+	m.update(b)
+}
+
+// decodeByte decodes a byte written by encodeByte.
+func (m *rangeModel) decodeByte(d *rangeDecoder) (byte, error) {
+	tree := m.tree()
+	node := 1
+	for node < 256 {
+		bit, err := d.decodeBit(&tree[node])
+		if err != nil {
+			return 0, err
+		}
+		node = node<<1 | bit
+	}
+	b := byte(node)
+	m.update(b)
+	return b, nil
+}
+
+// --- // Range Coder Encoding
+
+// RangeEncode compresses data with an adaptive binary range coder, predicting every byte from the previous `order` bytes.
+// The output starts with the uncompressed length (as a uvarint) and the model order.
+func RangeEncode(data []byte, order int) ([]byte, error) {
+	if order < 0 || order > rangeMaxOrder {
+		return nil, fmt.Errorf("invalid range coder model order %d (must be between 0 and %d)", order, rangeMaxOrder)
+	}
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	buffer.Write(header[:binary.PutUvarint(header[:], uint64(len(data)))])
+	buffer.WriteByte(byte(order))
+
+	e := newRangeEncoder()
+	model := newRangeModel(order)
+	for _, b := range data {
+		model.encodeByte(e, b)
+	}
+	buffer.Write(e.bytes())
+
+	verbosePrintf("RangeEncode: order: %v, len(data): %v, len(compressed): %v\n", order, len(data), buffer.Len())
+	return buffer.Bytes(), nil
+}
+
+// --- // Range Coder Decoding
+
+// RangeDecode decodes data produced by RangeEncode.
+func RangeDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 || len(data) < n+1 {
+		return nil, fmt.Errorf("malformed range coder data: invalid header")
+	}
+	order := int(data[n])
+	if order > rangeMaxOrder {
+		return nil, fmt.Errorf("malformed range coder data: unsupported model order %d", order)
+	}
+	// Never trust the header for the allocation size; a byte costs at least 8 * 2^-11 bits
+	if size > uint64(len(data))*2048 {
+		return nil, fmt.Errorf("malformed range coder data: length %d exceeds the encoded data", size)
+	}
+	if size > uint64(MaxDecodedSize) {
+		return nil, fmt.Errorf("malformed range coder data: length %d exceeds the decoded size limit of %d bytes", size, MaxDecodedSize)
+	}
+
+	d, err := newRangeDecoder(data[n+1:])
+	if err != nil {
+		return nil, err
+	}
+	capacity := size // The output grows as it is decoded, so garbage fails before it allocates the whole length
+	if limit := uint64(len(data)) * rangeMaxOutputRatio; capacity > limit {
+		capacity = limit
+	}
+	model := newRangeModel(order)
+	output := make([]byte, 0, capacity)
+	for uint64(len(output)) < size {
+		b, err := model.decodeByte(d)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, b)
+	}
+
+	return output, nil
+}
+
+// --- // Range Coder Compressor Interface
+
+// RangeCompressor implements core.GeneralCompressor and core.GeneralDecompressor with an adaptive range coder.
+type RangeCompressor struct {
+	Order int // Number of previous bytes each byte is predicted from, between 0 and 2
+}
+
+// Compress implements core.Compressor.
+func (r *RangeCompressor) Compress(data []byte) ([]byte, error) {
+	return RangeEncode(data, r.Order)
+}
+
+// Decompress implements core.Decompressor.
+func (r *RangeCompressor) Decompress(data []byte) ([]byte, error) {
+	return RangeDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (r *RangeCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("RangeCompressFile", inputFilePath, outputFilePath, r.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (r *RangeCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("RangeDecompressFile", inputFilePath, outputFilePath, r.Decompress)
+}
+
+// Factory function for creating a range coder compressor instance using RangeOrder.
+func NewRangeCompressor() *RangeCompressor {
+	return NewRangeCompressorWithOrder(RangeOrder)
+}
+
+// Factory function for creating a range coder compressor instance with a custom model order.
+func NewRangeCompressorWithOrder(order int) *RangeCompressor {
+	return &RangeCompressor{Order: order}
+}
+
+// Factory function for creating a range coder decompressor instance.
+func NewRangeDecompressor() *RangeCompressor {
+	return NewRangeCompressor()
+}
@@ -0,0 +1,96 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRangeRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := make([]byte, 10000)
+	random.Read(noise)
+
+	inputs := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Single character", input: []byte("A")},
+		{name: "Text", input: bytes.Repeat([]byte("she sells sea shells by the sea shore "), 50)},
+		{name: "Random bytes", input: noise},
+		{name: "Long run", input: bytes.Repeat([]byte{0}, 100000)},
+	}
+
+	for order := 0; order <= 2; order++ {
+		for _, tt := range inputs {
+			t.Run(tt.name, func(t *testing.T) {
+				compressed, err := RangeEncode(tt.input, order)
+				if err != nil {
+					t.Fatalf("RangeEncode(order %d) returned unexpected error: %v", order, err)
+				}
+
+				got, err := RangeDecode(compressed)
+				if err != nil {
+					t.Fatalf("RangeDecode(order %d) returned unexpected error: %v", order, err)
+				}
+
+				if !bytes.Equal(got, tt.input) {
+					t.Errorf("RangeDecode(RangeEncode(x, %d)) returned %d bytes, want %d", order, len(got), len(tt.input))
+				}
+			})
+		}
+	}
+}
+
+func TestRangeBeatsHuffmanOnSkewedData(t *testing.T) {
+	// 97% zeros: Huffman needs at least one bit per byte, the range coder much less
+	random := rand.New(rand.NewSource(2))
+	input := make([]byte, 100000)
+	for i := range input {
+		if random.Intn(100) < 3 {
+			input[i] = 1
+		}
+	}
+
+	huffman, err := Huffman(input)
+	if err != nil {
+		t.Fatalf("Huffman returned unexpected error: %v", err)
+	}
+	ranged, err := RangeEncode(input, 0)
+	if err != nil {
+		t.Fatalf("RangeEncode returned unexpected error: %v", err)
+	}
+	if len(ranged) >= len(huffman)/2 {
+		t.Errorf("RangeEncode output is %d bytes, expected less than half of Huffman's %d", len(ranged), len(huffman))
+	}
+}
+
+func TestRangeHigherOrderHelpsText(t *testing.T) {
+	input := bytes.Repeat([]byte("the rain in spain stays mainly in the plain. "), 200)
+
+	order0, _ := RangeEncode(input, 0)
+	order2, _ := RangeEncode(input, 2)
+	if len(order2) >= len(order0) {
+		t.Errorf("order-2 output is %d bytes, expected less than order-0's %d", len(order2), len(order0))
+	}
+}
+
+func TestRangeInvalidOrder(t *testing.T) {
+	if _, err := RangeEncode([]byte("abc"), 3); err == nil {
+		t.Errorf("RangeEncode with order 3 expected an error")
+	}
+	if _, err := RangeDecode([]byte{3, 7, 0, 0, 0, 0, 0}); err == nil {
+		t.Errorf("RangeDecode with order 7 expected an error")
+	}
+	if _, err := RangeDecode([]byte{100, 1, 0, 0xFF, 0xFF}); err == nil {
+		t.Errorf("RangeDecode of truncated data expected an error")
+	}
+
+	defer func(limit int) { MaxDecodedSize = limit }(MaxDecodedSize)
+	MaxDecodedSize = 1000
+	if _, err := RangeDecode([]byte{0xD0, 0x0F, 1, 0, 0, 0, 0, 0}); err == nil || !strings.Contains(err.Error(), "decoded size limit") {
+		t.Errorf("RangeDecode with a length beyond the decoded size limit returned %v, want an error", err)
+	}
+}
@@ -2,7 +2,10 @@ package algorithms
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 )
 
@@ -95,6 +98,171 @@ func RleAsString(data string) (string, error) {
 	return string(compressedBytes), nil
 }
 
+// --- // RLE Format Versions
+
+// The original format is a plain list of (count, byte) pairs, and `Rle` never writes a count of 0.
+// A leading 0 byte therefore marks a versioned format, followed by the version byte.
+const rleFormatMarker = 0x00
+
+// Largest output decoders produce from counts stored in the data (such as the varint counts of RLE); data that decodes
+// to more is treated as corrupt, so a damaged count fails with an error instead of exhausting the memory
+var MaxDecodedSize = 1 << 30
+
+const ( // Versions of the RLE format, written after rleFormatMarker
+	RleFormatVarint      = 1 // (uvarint count, byte) pairs, so runs are never split
+	RleFormatSymbolWidth = 2 // (uvarint count, symbol) pairs with multi-byte symbols
+	RleFormatChecked     = 3 // Uncompressed length and checksum (see Integrity), followed by data in one of the other formats
+)
+
+var RleVarintCounts = false // Whether NewRLECompressor and NewRLEFileToFileCompressor write the varint format
+var RleSymbolWidth = 1       // Symbol width in bytes used by NewRLECompressor and NewRLEFileToFileCompressor
+
+const rleMaxSymbolWidth = 255 // Largest symbol width the format can store
+
+// errRleLimit is returned by the RLE decoders when a run would take the output past their limit.
+type errRleLimit struct {
+	Run   uint64 // Length of the run, in units
+	Unit  string // "bytes" or "symbols"
+	Limit int    // Limit of the decoder, in bytes
+	Size  uint64 // Length the output would have reached with the run (at most math.MaxUint64)
+}
+
+// Format the errRleLimit error message.
+func (e *errRleLimit) Error() string {
+	return fmt.Sprintf("malformed RLE data: run of %d %s exceeds the decoded size limit of %d bytes", e.Run, e.Unit, e.Limit)
+}
+
+// Checks whether a run of `count` units of `width` bytes fits after `length` bytes of output (and `reserved` more) within
+// `limit` bytes, returning an errRleLimit if it does not.
+func rleCheckRun(length int, reserved int, count uint64, width int, limit int) error {
+	if remaining := limit - length - reserved; remaining >= 0 && count <= uint64(remaining/width) {
+		return nil
+	}
+	unit := "bytes"
+	if width > 1 {
+		unit = "symbols"
+	}
+	size := uint64(math.MaxUint64)
+	if count <= (math.MaxUint64-uint64(length+reserved))/uint64(width) {
+		size = uint64(length+reserved) + count*uint64(width)
+	}
+	return &errRleLimit { Run: count, Unit: unit, Limit: limit, Size: size }
+}
+
+// Encodes data using the RLE compression method with uvarint (LEB128) counts, returning a byte slice.
+// Unlike `Rle`, runs longer than 255 bytes are stored as a single pair.
+func RleVarint(data []byte) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	verbosePrintf("RleVarint: DATA_LEN: %v\n", DATA_LEN)
+
+	if DATA_LEN == 0 {
+		verbosePrintf("RleVarint: DATA_LEN == 0\n")
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var buffer bytes.Buffer // Initialize the buffer with the format header
+	buffer.WriteByte(rleFormatMarker)
+	buffer.WriteByte(RleFormatVarint)
+
+	var countBytes [binary.MaxVarintLen64]byte
+	start := 0 // Start of the current run
+	for i := 1; i <= DATA_LEN; i++ {
+		if i < DATA_LEN && data[i] == data[start] {
+			continue // Pattern found
+		}
+		count := uint64(i - start)
+		buffer.Write(countBytes[:binary.PutUvarint(countBytes[:], count)]) // Write the count as a uvarint
+		buffer.WriteByte(data[start])                                      // and the repeated byte character
+		verbosePrintf("RleVarint: count: %v, char: %v\n", count, data[start])
+		start = i
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Encodes data using the RLE compression method on symbols of `width` bytes (e.g. 2, 4 or 8 for 16, 32 or 64-bit values), returning a byte slice.
+// Runs are found by comparing whole symbols, so the byte order of the values does not matter.
+// The header is followed by the width, the length of the trailing partial symbol and its bytes, and then (uvarint count, symbol) pairs.
+func RleSymbols(data []byte, width int) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	verbosePrintf("RleSymbols: DATA_LEN: %v, width: %v\n", DATA_LEN, width)
+
+	if width < 1 || width > rleMaxSymbolWidth {
+		generalPrintf("RleSymbols: err: invalid symbol width %v\n", width)
+		return nil, fmt.Errorf("invalid RLE symbol width %d (must be between 1 and %d)", width, rleMaxSymbolWidth)
+	}
+	if DATA_LEN == 0 {
+		verbosePrintf("RleSymbols: DATA_LEN == 0\n")
+		return nil, nil // Return nil slice for empty input
+	}
+
+	SYMBOLS_LEN := DATA_LEN - DATA_LEN%width // Length of the whole symbols
+	tail := data[SYMBOLS_LEN:]               // Bytes left over after the last whole symbol
+
+	var buffer bytes.Buffer // Initialize the buffer with the format header
+	buffer.WriteByte(rleFormatMarker)
+	buffer.WriteByte(RleFormatSymbolWidth)
+	buffer.WriteByte(byte(width))
+	buffer.WriteByte(byte(len(tail)))
+	buffer.Write(tail)
+
+	var countBytes [binary.MaxVarintLen64]byte
+	start := 0 // Start of the current run
+	for i := width; i <= SYMBOLS_LEN; i += width {
+		if i < SYMBOLS_LEN && bytes.Equal(data[i:i+width], data[start:start+width]) {
+			continue // Pattern found
+		}
+		count := uint64((i - start) / width)
+		buffer.Write(countBytes[:binary.PutUvarint(countBytes[:], count)]) // Write the count as a uvarint
+		buffer.Write(data[start : start+width])                            // and the repeated symbol
+		verbosePrintf("RleSymbols: count: %v, symbol: %v\n", count, data[start:start+width])
+		start = i
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Encodes data with `encode` (Rle, RleVarint or RleSymbols) and stores the uncompressed length and a checksum of type
+// `checksumType` in front of it, so RleDecode can tell corrupt data from good data.
+func RleChecked(data []byte, encode func([]byte) ([]byte, error), checksumType int) ([]byte, error) {
+	verbosePrintf("RleChecked: DATA_LEN: %v, checksumType: %v\n", len(data), checksumType)
+
+	integrity, err := NewIntegrity(data, checksumType)
+	if err != nil {
+		generalPrintf("RleChecked: err: %v\n", err)
+		return nil, err
+	}
+	if len(data) == 0 {
+		verbosePrintf("RleChecked: DATA_LEN == 0\n")
+		return nil, nil // Return nil slice for empty input
+	}
+
+	encoded, err := encode(data)
+	if err != nil {
+		return nil, err
+	}
+	output := integrity.Append([]byte{rleFormatMarker, RleFormatChecked})
+	return append(output, encoded...), nil
+}
+
+// Returns the RLE encoding function for the given options.
+func rleEncoder(varintCounts bool, symbolWidth int, checksumType int) func([]byte) ([]byte, error) {
+	if checksumType != ChecksumNone {
+		encode := rleEncoder(varintCounts, symbolWidth, ChecksumNone)
+		return func(data []byte) ([]byte, error) { return RleChecked(data, encode, checksumType) }
+	}
+	switch {
+	case symbolWidth > 1:
+		return func(data []byte) ([]byte, error) { return RleSymbols(data, symbolWidth) }
+	case varintCounts:
+		return RleVarint
+	default:
+		return Rle
+	}
+}
+
 // --- // RLE Decoding
 
 func RleDecode(data []byte) ([]byte, error) {
@@ -107,6 +275,17 @@ func RleDecode(data []byte) ([]byte, error) {
 		return nil, nil // Return nil slice for empty input
 	}
 
+	return rleDecodeLimited(data, MaxDecodedSize)
+}
+
+// Decodes any RLE format into at most `limit` bytes.
+func rleDecodeLimited(data []byte, limit int) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	if data[0] == rleFormatMarker { // Versioned format, since the original one never has a count of 0
+		return rleDecodeVersioned(data, limit)
+	}
+
 	var buffer bytes.Buffer // Initialize the empty buffer for storing the decompressed data
 
 	for i := 0; i < DATA_LEN; i += 2 { // Increment by 2 to read count-character pairs
@@ -120,6 +299,10 @@ func RleDecode(data []byte) ([]byte, error) {
 		char := data[i + 1]   // Read the character byte
 		verbosePrintf("RleDecode: count: %v, char: %v\n", count, char)
 
+		if err := rleCheckRun(buffer.Len(), 0, uint64(count), 1, limit); err != nil {
+			generalPrintf("RleDecode: err: %v\n", err)
+			return nil, err
+		}
 		for j := 0; j < count; j++ { // Write the character 'count' times
 			verbosePrintf("RleDecode: j: %v\n", j)
 			if err := buffer.WriteByte(char); err != nil { // Try to write the byte to the buffer
@@ -132,6 +315,141 @@ func RleDecode(data []byte) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Decodes the versioned RLE formats, which start with rleFormatMarker and a version byte, into at most `limit` bytes.
+func rleDecodeVersioned(data []byte, limit int) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	if DATA_LEN < 2 {
+		generalPrintf("rleDecodeVersioned: err: missing format version\n")
+		return nil, fmt.Errorf("malformed RLE data: missing format version")
+	}
+	version := data[1]
+	verbosePrintf("rleDecodeVersioned: version: %v\n", version)
+
+	switch version {
+	case RleFormatVarint:
+		return rleDecodeVarint(data[2:], limit)
+	case RleFormatSymbolWidth:
+		return rleDecodeSymbols(data[2:], limit)
+	case RleFormatChecked:
+		return rleDecodeChecked(data[2:], limit)
+	default:
+		generalPrintf("rleDecodeVersioned: err: unsupported format version %v\n", version)
+		return nil, fmt.Errorf("unsupported RLE format version %d", version)
+	}
+}
+
+// Decodes the length and checksum written by RleChecked and the data after them into at most `limit` bytes, and verifies
+// the decoded data. Decoding stops with an ErrLengthMismatch as soon as the output passes the stored length.
+func rleDecodeChecked(data []byte, limit int) ([]byte, error) {
+	integrity, n, err := ParseIntegrity(data)
+	if err != nil {
+		generalPrintf("rleDecodeChecked: err: %v\n", err)
+		return nil, fmt.Errorf("malformed RLE data: %w", err)
+	}
+	verbosePrintf("rleDecodeChecked: length: %v, checksum: %08x\n", integrity.Length, integrity.Checksum)
+	if integrity.Length > uint64(limit) {
+		generalPrintf("rleDecodeChecked: err: stored length %v exceeds the limit of %v\n", integrity.Length, limit)
+		return nil, fmt.Errorf("malformed RLE data: stored length %d exceeds the decoded size limit of %d bytes", integrity.Length, limit)
+	}
+	if len(data) == n {
+		return nil, integrity.Verify(nil)
+	}
+
+	decodedData, err := rleDecodeLimited(data[n:], int(integrity.Length))
+	var limitErr *errRleLimit
+	if errors.As(err, &limitErr) {
+		err = &ErrLengthMismatch{Expected: integrity.Length, Actual: limitErr.Size}
+	}
+	if err != nil {
+		generalPrintf("rleDecodeChecked: err: %v\n", err)
+		return nil, err
+	}
+	if err := integrity.Verify(decodedData); err != nil {
+		generalPrintf("rleDecodeChecked: err: %v\n", err)
+		return nil, err
+	}
+	return decodedData, nil
+}
+
+// Decodes (uvarint count, byte) pairs, failing once the output would exceed `limit` bytes.
+func rleDecodeVarint(data []byte, limit int) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	var buffer bytes.Buffer // Initialize the empty buffer for storing the decompressed data
+
+	for i := 0; i < DATA_LEN; {
+		count, n := binary.Uvarint(data[i:]) // Read the count
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed RLE data: invalid count at index %d", i)
+		}
+		i += n
+		if i >= DATA_LEN {
+			return nil, fmt.Errorf("malformed RLE data: incomplete pair at index %d", i-n)
+		}
+		char := data[i] // Read the character byte
+		i++
+		verbosePrintf("rleDecodeVarint: count: %v, char: %v\n", count, char)
+
+		if err := rleCheckRun(buffer.Len(), 0, count, 1, limit); err != nil {
+			generalPrintf("rleDecodeVarint: err: %v\n", err)
+			return nil, err
+		}
+		for j := uint64(0); j < count; j++ { // Write the character 'count' times
+			buffer.WriteByte(char)
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decodes the width, the trailing partial symbol and the (uvarint count, symbol) pairs written by RleSymbols,
+// failing once the output would exceed `limit` bytes.
+func rleDecodeSymbols(data []byte, limit int) ([]byte, error) {
+	DATA_LEN := len(data)
+
+	if DATA_LEN < 2 {
+		return nil, fmt.Errorf("malformed RLE data: missing symbol width")
+	}
+	width := int(data[0])
+	TAIL_LEN := int(data[1])
+	if width == 0 || TAIL_LEN >= width {
+		return nil, fmt.Errorf("malformed RLE data: invalid symbol width %d with a %d byte tail", width, TAIL_LEN)
+	}
+	if 2+TAIL_LEN > DATA_LEN {
+		return nil, fmt.Errorf("malformed RLE data: truncated tail")
+	}
+	tail := data[2 : 2+TAIL_LEN]
+	verbosePrintf("rleDecodeSymbols: width: %v, tail: %v\n", width, tail)
+
+	var buffer bytes.Buffer // Initialize the empty buffer for storing the decompressed data
+
+	for i := 2 + TAIL_LEN; i < DATA_LEN; {
+		count, n := binary.Uvarint(data[i:]) // Read the count
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed RLE data: invalid count at index %d", i)
+		}
+		i += n
+		if i+width > DATA_LEN {
+			return nil, fmt.Errorf("malformed RLE data: incomplete pair at index %d", i-n)
+		}
+		symbol := data[i : i+width] // Read the symbol
+		i += width
+		verbosePrintf("rleDecodeSymbols: count: %v, symbol: %v\n", count, symbol)
+
+		if err := rleCheckRun(buffer.Len(), TAIL_LEN, count, width, limit); err != nil {
+			generalPrintf("rleDecodeSymbols: err: %v\n", err)
+			return nil, err
+		}
+		for j := uint64(0); j < count; j++ { // Write the symbol 'count' times
+			buffer.Write(symbol)
+		}
+	}
+	buffer.Write(tail)
+
+	return buffer.Bytes(), nil
+}
+
 func RleDecodeAsString(data []byte) (string, error) {
 	decompressedBytes, err := RleDecode(data) // Use the fixed RLE decode function
 	verbosePrintf("RleDecodeAsString: decompressedBytes: %v\n", decompressedBytes)
@@ -144,7 +462,11 @@ func RleDecodeAsString(data []byte) (string, error) {
 
 // --- // RLE Compressor Interface
 
-type RLECompressor struct {}
+type RLECompressor struct {
+	VarintCounts bool // Write the varint format (see RleVarint) instead of byte counts
+	SymbolWidth  int  // Compare symbols of this many bytes (see RleSymbols); 0 or 1 compares single bytes
+	Checksum     int  // Store the length and this checksum (see RleChecked); ChecksumNone stores neither
+}
 
 // Compress implements core.Compressor.
 func (r *RLECompressor) Compress(data []byte) ([]byte, error) {
@@ -154,7 +476,7 @@ func (r *RLECompressor) Compress(data []byte) ([]byte, error) {
 		return nil, nil
 	}
 
-	compressedData, err := Rle(data)
+	compressedData, err := rleEncoder(r.VarintCounts, r.SymbolWidth, r.Checksum)(data)
 	verbosePrintf("RLECompressor: compressedData: %v\n", compressedData)
 	if err != nil {
 		generalPrintf("RLECompressor: err: %v\n", err)
@@ -182,8 +504,14 @@ func (r *RLECompressor) Decompress(data []byte) ([]byte, error) {
 }
 
 // Factory functions for creating instances of RLECompressor.
+// It stores no length or checksum, since the containers it runs in record their own (see RLEFileToFileCompressor).
 func NewRLECompressor() *RLECompressor {
-	return &RLECompressor {}
+	return &RLECompressor { VarintCounts: RleVarintCounts, SymbolWidth: RleSymbolWidth }
+}
+
+// Factory function for creating an RLECompressor instance that compares symbols of `symbolWidth` bytes.
+func NewRLECompressorWithSymbolWidth(symbolWidth int) *RLECompressor {
+	return &RLECompressor { SymbolWidth: symbolWidth }
 }
 
 // Factory function for creating a decompressor instance.
@@ -249,11 +577,29 @@ func RleDecompressFile(inputFilePath string, outputFilePath string) error {
 	return nil
 }
 
-type RLEFileToFileCompressor struct {}
+// RleVarintCompressFile compresses a file into the varint RLE format and writes the result to another file.
+func RleVarintCompressFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("RleVarintCompressFile", inputFilePath, outputFilePath, RleVarint)
+}
+
+type RLEFileToFileCompressor struct {
+	VarintCounts bool // Write the varint format (see RleVarint) instead of byte counts
+	SymbolWidth  int  // Compare symbols of this many bytes (see RleSymbols); 0 or 1 compares single bytes
+	Checksum     int  // Store the length and this checksum (see RleChecked); ChecksumNone stores neither
+}
 type RLEFileToFileDecompressor struct {}
 
 // CompressFile implements core.FileToFileCompressor.
 func (r *RLEFileToFileCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	if r.Checksum != ChecksumNone {
+		return TransformFile("RleCheckedCompressFile", inputFilePath, outputFilePath, rleEncoder(r.VarintCounts, r.SymbolWidth, r.Checksum))
+	}
+	if r.SymbolWidth > 1 {
+		return TransformFile("RleSymbolsCompressFile", inputFilePath, outputFilePath, rleEncoder(r.VarintCounts, r.SymbolWidth, ChecksumNone))
+	}
+	if r.VarintCounts {
+		return RleVarintCompressFile(inputFilePath, outputFilePath)
+	}
 	return RleCompressFile(inputFilePath, outputFilePath)
 }
 
@@ -264,7 +610,7 @@ func (r *RLEFileToFileDecompressor) DecompressFileToFile(inputFilePath string, o
 
 // Factory functions for creating instances of RLEFileToFileCompressor.
 func NewRLEFileToFileCompressor() *RLEFileToFileCompressor {
-	return &RLEFileToFileCompressor {}
+	return &RLEFileToFileCompressor { VarintCounts: RleVarintCounts, SymbolWidth: RleSymbolWidth, Checksum: ChecksumType }
 }
 
 // Factory function for creating a decompressor instance.
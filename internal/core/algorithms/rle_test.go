@@ -2,6 +2,8 @@ package algorithms
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"testing"
 )
 
@@ -66,3 +68,256 @@ func TestRle(t *testing.T) {
 		})
 	}
 }
+
+func TestRleVarint(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	} {
+		{
+			name:     "Empty string",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "Repeated characters",
+			input:    []byte("AAABBC"),
+			expected: []byte{0, RleFormatVarint, 3, 'A', 2, 'B', 1, 'C'},
+		},
+		{
+			name:     "No overflow",
+			input:    bytes.Repeat([]byte{'A'}, 300), // 300 A's
+			expected: []byte{0, RleFormatVarint, 0xAC, 0x02, 'A'}, // 300 as a uvarint
+		},
+		{
+			name:     "Megabyte of zeros",
+			input:    make([]byte, 1 << 20),
+			expected: []byte{0, RleFormatVarint, 0x80, 0x80, 0x40, 0}, // 1 << 20 as a uvarint
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RleVarint(tt.input)
+			if err != nil {
+				t.Fatalf("RleVarint returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("RleVarint = %v, want %v", got, tt.expected)
+			}
+
+			decoded, err := RleDecode(got)
+			if err != nil {
+				t.Fatalf("RleDecode(%v) returned unexpected error: %v", got, err)
+			}
+			if !bytes.Equal(decoded, tt.input) {
+				t.Errorf("RleDecode(RleVarint(x)) returned %d bytes, want %d", len(decoded), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestRleDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	} {
+		{
+			name:     "Byte counts",
+			input:    []byte{255, 'A', 45, 'A', 1, 'B'},
+			expected: append(bytes.Repeat([]byte{'A'}, 300), 'B'),
+		},
+		{
+			name:     "Varint counts",
+			input:    []byte{0, RleFormatVarint, 0xAC, 0x02, 'A', 1, 'B'},
+			expected: append(bytes.Repeat([]byte{'A'}, 300), 'B'),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RleDecode(tt.input)
+			if err != nil {
+				t.Fatalf("RleDecode(%v) returned unexpected error: %v", tt.input, err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("RleDecode(%v) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRleDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{
+			name:  "Incomplete pair",
+			input: []byte{3, 'A', 2},
+		},
+		{
+			name:  "Missing format version",
+			input: []byte{0},
+		},
+		{
+			name:  "Unknown format version",
+			input: []byte{0, 99, 1, 'A'},
+		},
+		{
+			name:  "Incomplete varint pair",
+			input: []byte{0, RleFormatVarint, 0xAC, 0x02},
+		},
+		{
+			name:  "Varint run beyond the decoded size limit",
+			input: []byte{0, RleFormatVarint, 0x80, 0x80, 0x80, 0x80, 0x08, 'A'}, // 2^31 bytes
+		},
+		{
+			name:  "Tail as long as a symbol",
+			input: []byte{0, RleFormatSymbolWidth, 2, 2, 'A', 'B'},
+		},
+		{
+			name:  "Symbol run beyond the decoded size limit",
+			input: []byte{0, RleFormatSymbolWidth, 4, 0, 0x80, 0x80, 0x80, 0x80, 0x08, 'A', 'B', 'C', 'D'}, // 2^31 symbols
+		},
+		{
+			name:  "Incomplete symbol",
+			input: []byte{0, RleFormatSymbolWidth, 4, 0, 1, 'A', 'B'},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RleDecode(tt.input); err == nil {
+				t.Errorf("RleDecode(%v) expected an error", tt.input)
+			}
+		})
+	}
+}
+
+func TestRleSymbols(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		width    int
+		expected []byte
+	} {
+		{
+			name:     "Empty input",
+			input:    nil,
+			width:    2,
+			expected: nil,
+		},
+		{
+			name:     "16-bit values",
+			input:    []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00},
+			width:    2,
+			expected: []byte{0, RleFormatSymbolWidth, 2, 0, 3, 0x01, 0x00, 1, 0x02, 0x00},
+		},
+		{
+			name:     "Byte runs that are not symbol runs",
+			input:    []byte{0xAA, 0xAA, 0xAA, 0xBB, 0xAA, 0xAA, 0xAA, 0xBB},
+			width:    4,
+			expected: []byte{0, RleFormatSymbolWidth, 4, 0, 2, 0xAA, 0xAA, 0xAA, 0xBB},
+		},
+		{
+			name:     "Length not a multiple of the width",
+			input:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			width:    8,
+			expected: []byte{0, RleFormatSymbolWidth, 8, 3, 9, 10, 11, 2, 1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			name:     "Shorter than one symbol",
+			input:    []byte{1, 2, 3},
+			width:    4,
+			expected: []byte{0, RleFormatSymbolWidth, 4, 3, 1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RleSymbols(tt.input, tt.width)
+			if err != nil {
+				t.Fatalf("RleSymbols returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("RleSymbols = %v, want %v", got, tt.expected)
+			}
+
+			decoded, err := RleDecode(got)
+			if err != nil {
+				t.Fatalf("RleDecode(%v) returned unexpected error: %v", got, err)
+			}
+			if !bytes.Equal(decoded, tt.input) {
+				t.Errorf("RleDecode(RleSymbols(x)) = %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	if _, err := RleSymbols([]byte{1}, 0); err == nil {
+		t.Errorf("RleSymbols with a width of 0 expected an error")
+	}
+}
+
+func TestRleChecked(t *testing.T) {
+	input := []byte("aaaaaaaaaabbbbbbbbbbcd")
+
+	for _, checksumType := range []int{ChecksumCRC32, ChecksumCRC32C} {
+		t.Run(ChecksumNames[checksumType], func(t *testing.T) {
+			got, err := RleChecked(input, Rle, checksumType)
+			if err != nil {
+				t.Fatalf("RleChecked returned unexpected error: %v", err)
+			}
+			plain, _ := Rle(input)
+			expected := []byte{0, RleFormatChecked, byte(len(input)), byte(checksumType)}
+			expected = binary.LittleEndian.AppendUint32(expected, checksum(input, checksumType))
+			expected = append(expected, plain...)
+			if !bytes.Equal(got, expected) {
+				t.Errorf("RleChecked = %v, want %v", got, expected)
+			}
+
+			decoded, err := RleDecode(got)
+			if err != nil {
+				t.Fatalf("RleDecode(%v) returned unexpected error: %v", got, err)
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Errorf("RleDecode(RleChecked(x)) = %q, want %q", decoded, input)
+			}
+
+			// A corrupt count changes the length, a corrupt byte the checksum
+			corrupt := append([]byte(nil), got...)
+			corrupt[len(corrupt)-2] = 5
+			if _, err := RleDecode(corrupt); !errors.Is(err, &ErrLengthMismatch{}) {
+				t.Errorf("RleDecode of a corrupt count returned %v, want an ErrLengthMismatch", err)
+			}
+			corrupt = append([]byte(nil), got...)
+			corrupt[len(corrupt)-1] = 'x'
+			if _, err := RleDecode(corrupt); !errors.Is(err, &ErrChecksumMismatch{}) {
+				t.Errorf("RleDecode of a corrupt byte returned %v, want an ErrChecksumMismatch", err)
+			}
+		})
+	}
+
+	if _, err := RleChecked(input, Rle, 7); err == nil {
+		t.Errorf("RleChecked with an unknown checksum expected an error")
+	}
+	if _, err := RleDecode([]byte{0, RleFormatChecked, 1, ChecksumCRC32}); err == nil {
+		t.Errorf("RleDecode with a truncated checksum expected an error")
+	}
+
+	// The stored length limits the output, so a huge run fails before it is written out
+	huge := []byte{0, RleFormatChecked, 4, ChecksumCRC32, 0, 0, 0, 0, 0, RleFormatVarint, 2, 'A', 0x80, 0x80, 0x80, 0x80, 0x01, 'B'}
+	var mismatch *ErrLengthMismatch
+	if _, err := RleDecode(huge); !errors.As(err, &mismatch) || mismatch.Expected != 4 || mismatch.Actual != 2+1<<28 {
+		t.Errorf("RleDecode of a run past the stored length returned %v, want an ErrLengthMismatch", err)
+	}
+	if _, err := RleDecode([]byte{0, RleFormatChecked, 0x80, 0x80, 0x80, 0x80, 0x08, ChecksumCRC32, 0, 0, 0, 0, 1, 'A'}); err == nil || errors.Is(err, &ErrLengthMismatch{}) {
+		t.Errorf("RleDecode with a stored length beyond the decoded size limit returned %v, want a malformed data error", err)
+	}
+	// Containers record the length and checksum themselves, so the compressor they use leaves them out
+	if got, _ := NewRLECompressor().Compress(input); bytes.HasPrefix(got, []byte{0, RleFormatChecked}) {
+		t.Errorf("NewRLECompressor().Compress stored a length and checksum: %v", got)
+	}
+}
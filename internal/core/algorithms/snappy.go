@@ -0,0 +1,358 @@
+package algorithms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// Options for Snappy, used by NewSnappyCompressor (and therefore by the factories in core)
+
+var SnappyRaw = false // Whether to use the raw block format instead of the framing format
+
+const (
+	snappyTagLiteral   = 0x00
+	snappyTagCopy1     = 0x01 // Copy with an 11-bit offset and a length of 4 to 11
+	snappyTagCopy2     = 0x02 // Copy with a 16-bit offset and a length of 1 to 64
+	snappyTagCopy4     = 0x03 // Copy with a 32-bit offset and a length of 1 to 64
+	snappyMinMatch     = 4
+	snappyMaxOffset    = 65535 // Farthest match the compressor looks for (so it never needs 4-byte offsets)
+	snappyHashLog      = 14
+	snappyMaxExpansion = 22    // A 3-byte copy can produce 64 bytes, so no block decodes to more than 22x its size
+	snappyMaxChunkSize = 65536 // Most uncompressed bytes a chunk of the framing format may hold
+	snappyChecksumMask = 0xA282EAD8
+
+	// Chunk types of the framing format
+	snappyChunkCompressed   = 0x00
+	snappyChunkUncompressed = 0x01
+	snappyChunkStreamID     = 0xFF
+)
+
+// The stream identifier chunk that starts every stream of the framing format
+var snappyStreamID = []byte{snappyChunkStreamID, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// snappyMaskedChecksum returns the masked CRC-32C the framing format stores for the uncompressed data of a chunk.
+func snappyMaskedChecksum(data []byte) uint32 {
+	c := crc32.Checksum(data, crc32cTable)
+	return (c>>15 | c<<17) + snappyChecksumMask
+}
+
+// --- // Snappy Block Encoding
+
+// snappyWriteLiteral writes a literal element.
+func snappyWriteLiteral(buffer *bytes.Buffer, literal []byte) {
+	n := len(literal) - 1
+	switch {
+	case n < 60:
+		buffer.WriteByte(byte(n)<<2 | snappyTagLiteral)
+	case n < 1<<8:
+		buffer.WriteByte(60<<2 | snappyTagLiteral)
+		buffer.WriteByte(byte(n))
+	case n < 1<<16:
+		buffer.WriteByte(61<<2 | snappyTagLiteral)
+		buffer.WriteByte(byte(n))
+		buffer.WriteByte(byte(n >> 8))
+	case n < 1<<24:
+		buffer.WriteByte(62<<2 | snappyTagLiteral)
+		buffer.WriteByte(byte(n))
+		buffer.WriteByte(byte(n >> 8))
+		buffer.WriteByte(byte(n >> 16))
+	default:
+		buffer.WriteByte(63<<2 | snappyTagLiteral)
+		buffer.WriteByte(byte(n))
+		buffer.WriteByte(byte(n >> 8))
+		buffer.WriteByte(byte(n >> 16))
+		buffer.WriteByte(byte(n >> 24))
+	}
+	buffer.Write(literal)
+}
+
+// snappyWriteCopy writes a match as one or more copy elements of at most 64 bytes each.
+func snappyWriteCopy(buffer *bytes.Buffer, offset int, length int) {
+	for length >= 68 {
+		buffer.WriteByte(63<<2 | snappyTagCopy2)
+		buffer.WriteByte(byte(offset))
+		buffer.WriteByte(byte(offset >> 8))
+		length -= 64
+	}
+	if length > 64 { // Leave at least 4 bytes for the last element
+		buffer.WriteByte(59<<2 | snappyTagCopy2)
+		buffer.WriteByte(byte(offset))
+		buffer.WriteByte(byte(offset >> 8))
+		length -= 60
+	}
+	if length < 12 && offset < 2048 {
+		buffer.WriteByte(byte(offset>>8)<<5 | byte(length-4)<<2 | snappyTagCopy1)
+		buffer.WriteByte(byte(offset))
+		return
+	}
+	buffer.WriteByte(byte(length-1)<<2 | snappyTagCopy2)
+	buffer.WriteByte(byte(offset))
+	buffer.WriteByte(byte(offset >> 8))
+}
+
+// SnappyBlock compresses data into the raw Snappy block format: the uncompressed length followed by elements.
+func SnappyBlock(data []byte) []byte {
+	var buffer bytes.Buffer
+	var word [binary.MaxVarintLen64]byte
+	buffer.Write(word[:binary.PutUvarint(word[:], uint64(len(data)))])
+
+	n := len(data)
+	anchor := 0
+	if n >= snappyMinMatch {
+		table := make([]int32, 1<<snappyHashLog) // Position + 1 of the last occurrence of each hashed 4-byte sequence
+		for i := 0; i+snappyMinMatch <= n; {
+			sequence := binary.LittleEndian.Uint32(data[i:])
+			h := (sequence * 0x1E35A7BD) >> (32 - snappyHashLog)
+			ref := int(table[h]) - 1
+			table[h] = int32(i + 1)
+
+			if ref < 0 || i-ref > snappyMaxOffset || binary.LittleEndian.Uint32(data[ref:]) != sequence {
+				i++
+				continue
+			}
+
+			length := snappyMinMatch
+			for i+length < n && data[ref+length] == data[i+length] {
+				length++
+			}
+
+			if anchor < i {
+				snappyWriteLiteral(&buffer, data[anchor:i])
+			}
+			snappyWriteCopy(&buffer, i-ref, length)
+			i += length
+			anchor = i
+		}
+	}
+
+	if anchor < n {
+		snappyWriteLiteral(&buffer, data[anchor:])
+	}
+	return buffer.Bytes()
+}
+
+// --- // Snappy Block Decoding
+
+// SnappyBlockDecode decodes data in the raw Snappy block format.
+func SnappyBlockDecode(data []byte) ([]byte, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("malformed Snappy data: invalid length preamble")
+	}
+	data = data[n:]
+	if length > uint64(len(data))*snappyMaxExpansion {
+		return nil, fmt.Errorf("malformed Snappy data: %d bytes cannot decode to %d", len(data), length)
+	}
+
+	output := make([]byte, 0, length)
+	for pos := 0; pos < len(data); {
+		tag := data[pos]
+		pos++
+
+		if tag&0x03 == snappyTagLiteral {
+			size := int(tag >> 2)
+			if size >= 60 { // The length minus one follows in 1 to 4 bytes
+				extra := size - 59
+				if pos+extra > len(data) {
+					return nil, fmt.Errorf("malformed Snappy data: truncated literal length")
+				}
+				size = 0
+				for j := extra - 1; j >= 0; j-- {
+					size = size<<8 | int(data[pos+j])
+				}
+				pos += extra
+			}
+			size++
+			if size <= 0 || size > len(data)-pos {
+				return nil, fmt.Errorf("malformed Snappy data: literal of %d bytes runs past the end of the data", size)
+			}
+			if uint64(size) > length-uint64(len(output)) {
+				return nil, fmt.Errorf("malformed Snappy data: decoded data exceeds %d bytes", length)
+			}
+			output = append(output, data[pos:pos+size]...)
+			pos += size
+			continue
+		}
+
+		var offset, size int
+		switch tag & 0x03 {
+		case snappyTagCopy1:
+			if pos+1 > len(data) {
+				return nil, fmt.Errorf("malformed Snappy data: truncated copy")
+			}
+			size = int(tag>>2&0x07) + 4
+			offset = int(tag>>5)<<8 | int(data[pos])
+			pos++
+		case snappyTagCopy2:
+			if pos+2 > len(data) {
+				return nil, fmt.Errorf("malformed Snappy data: truncated copy")
+			}
+			size = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(data[pos:]))
+			pos += 2
+		case snappyTagCopy4:
+			if pos+4 > len(data) {
+				return nil, fmt.Errorf("malformed Snappy data: truncated copy")
+			}
+			size = int(tag>>2) + 1
+			offset64 := uint64(binary.LittleEndian.Uint32(data[pos:]))
+			if offset64 > uint64(len(output)) {
+				return nil, fmt.Errorf("malformed Snappy data: invalid copy offset %d", offset64)
+			}
+			offset = int(offset64)
+			pos += 4
+		}
+		if offset == 0 || offset > len(output) {
+			return nil, fmt.Errorf("malformed Snappy data: invalid copy offset %d", offset)
+		}
+		if uint64(size) > length-uint64(len(output)) {
+			return nil, fmt.Errorf("malformed Snappy data: decoded data exceeds %d bytes", length)
+		}
+		from := len(output) - offset
+		for j := 0; j < size; j++ { // Byte by byte, since copies may overlap their own output
+			output = append(output, output[from+j])
+		}
+	}
+
+	if uint64(len(output)) != length {
+		return nil, fmt.Errorf("malformed Snappy data: decoded %d bytes, the preamble says %d", len(output), length)
+	}
+	return output, nil
+}
+
+// --- // Snappy Framing Format
+
+// Snappy compresses data into the Snappy framing format (`.sz` files): a stream identifier followed by chunks
+// of at most 64 KB, each with the masked CRC-32C of its data. Chunks that do not compress are stored as they are.
+func Snappy(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	var word [4]byte
+	buffer.Write(snappyStreamID)
+
+	for start := 0; start < len(data); start += snappyMaxChunkSize {
+		end := start + snappyMaxChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[start:end]
+		body := SnappyBlock(chunk)
+		chunkType := byte(snappyChunkCompressed)
+		if len(body) >= len(chunk)-len(chunk)/8 { // Not worth it
+			body = chunk
+			chunkType = snappyChunkUncompressed
+		}
+		verbosePrintf("Snappy: chunk at %v: %v -> %v bytes\n", start, len(chunk), len(body))
+
+		binary.LittleEndian.PutUint32(word[:], uint32(len(body)+4)<<8|uint32(chunkType)) // Type, then a 24-bit length
+		buffer.Write(word[:])
+		binary.LittleEndian.PutUint32(word[:], snappyMaskedChecksum(chunk))
+		buffer.Write(word[:])
+		buffer.Write(body)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// SnappyDecode decompresses data in the Snappy framing format, verifying the checksum of every chunk.
+// Padding and reserved skippable chunks are skipped, and concatenated streams are accepted.
+func SnappyDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+	if !bytes.HasPrefix(data, snappyStreamID) {
+		return nil, fmt.Errorf("malformed Snappy data: missing stream identifier")
+	}
+
+	var output []byte
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("malformed Snappy data: truncated chunk header")
+		}
+		chunkType := data[0]
+		size := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+		data = data[4:]
+		if size > len(data) {
+			return nil, fmt.Errorf("malformed Snappy data: truncated chunk at offset %d", len(output))
+		}
+		body := data[:size]
+		data = data[size:]
+
+		switch {
+		case chunkType == snappyChunkStreamID:
+			if !bytes.Equal(body, snappyStreamID[4:]) {
+				return nil, fmt.Errorf("malformed Snappy data: invalid stream identifier")
+			}
+		case chunkType == snappyChunkCompressed || chunkType == snappyChunkUncompressed:
+			if size < 4 {
+				return nil, fmt.Errorf("malformed Snappy data: chunk too short for its checksum")
+			}
+			checksum := binary.LittleEndian.Uint32(body)
+			chunk := body[4:]
+			if chunkType == snappyChunkCompressed {
+				var err error
+				if chunk, err = SnappyBlockDecode(chunk); err != nil {
+					return nil, err
+				}
+			}
+			if len(chunk) > snappyMaxChunkSize {
+				return nil, fmt.Errorf("malformed Snappy data: chunk of %d bytes at offset %d", len(chunk), len(output))
+			}
+			if snappyMaskedChecksum(chunk) != checksum {
+				return nil, fmt.Errorf("malformed Snappy data: checksum mismatch at offset %d", len(output))
+			}
+			output = append(output, chunk...)
+		case chunkType >= 0x80: // Padding and reserved skippable chunks
+		default:
+			return nil, fmt.Errorf("malformed Snappy data: reserved unskippable chunk type 0x%02X", chunkType)
+		}
+	}
+
+	return output, nil
+}
+
+// --- // Snappy Compressor Interface
+
+// SnappyCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the Snappy formats.
+type SnappyCompressor struct {
+	Raw bool // Use the raw block format instead of the framing format
+}
+
+// Compress implements core.Compressor.
+func (s *SnappyCompressor) Compress(data []byte) ([]byte, error) {
+	if s.Raw {
+		return SnappyBlock(data), nil
+	}
+	return Snappy(data)
+}
+
+// Decompress implements core.Decompressor.
+func (s *SnappyCompressor) Decompress(data []byte) ([]byte, error) {
+	if s.Raw {
+		return SnappyBlockDecode(data)
+	}
+	return SnappyDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (s *SnappyCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("SnappyCompressFile", inputFilePath, outputFilePath, s.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (s *SnappyCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("SnappyDecompressFile", inputFilePath, outputFilePath, s.Decompress)
+}
+
+// Factory function for creating a Snappy compressor instance using SnappyRaw.
+func NewSnappyCompressor() *SnappyCompressor {
+	return &SnappyCompressor{Raw: SnappyRaw}
+}
+
+// Factory function for creating a Snappy decompressor instance using SnappyRaw.
+func NewSnappyDecompressor() *SnappyCompressor {
+	return NewSnappyCompressor()
+}
@@ -0,0 +1,131 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/snappy were made with github.com/golang/snappy v0.0.4, the Go port by the Snappy authors:
+//
+//	raw.snappy:       snappy.Encode of lz4FixtureContent()
+//	framed.sz:        snappy.NewBufferedWriter with lz4FixtureContent()
+//	framed_random.sz: snappy.NewBufferedWriter with 3000 bytes of rand.New(rand.NewSource(1)) (an uncompressed chunk)
+
+func snappyRandomFixtureContent() []byte {
+	noise := make([]byte, 3000)
+	rand.New(rand.NewSource(1)).Read(noise)
+	return noise
+}
+
+func TestSnappyDecodeReferenceFixtures(t *testing.T) {
+	tests := []struct {
+		file     string
+		raw      bool
+		expected []byte
+	} {
+		{file: "raw.snappy", raw: true, expected: lz4FixtureContent()},
+		{file: "framed.sz", raw: false, expected: lz4FixtureContent()},
+		{file: "framed_random.sz", raw: false, expected: snappyRandomFixtureContent()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "snappy", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			got, err := (&SnappyCompressor{Raw: tt.raw}).Decompress(data)
+			if err != nil {
+				t.Fatalf("Decompress returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("Decompress returned %d bytes, want %d", len(got), len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestSnappyRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	noise := make([]byte, 100000)
+	random.Read(noise)
+
+	inputs := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Short input", input: []byte("abc")},
+		{name: "Text", input: lz4FixtureContent()},
+		{name: "Random bytes", input: noise},
+		{name: "Long runs", input: bytes.Repeat([]byte{'x'}, 300000)},
+	}
+
+	for _, raw := range []bool{false, true} {
+		for _, tt := range inputs {
+			t.Run(tt.name, func(t *testing.T) {
+				snappy := &SnappyCompressor{Raw: raw}
+				compressed, err := snappy.Compress(tt.input)
+				if err != nil {
+					t.Fatalf("Compress returned unexpected error: %v", err)
+				}
+
+				got, err := snappy.Decompress(compressed)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, tt.input) {
+					t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(tt.input))
+				}
+			})
+		}
+	}
+}
+
+func TestSnappyMatchesReference(t *testing.T) {
+	// What the reference writer produces for "a": the stream identifier, then an uncompressed chunk
+	expected := []byte{0xFF, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y', 0x01, 0x05, 0x00, 0x00, 0x78, 0x6E, 0xE4, 0x28, 'a'}
+
+	got, err := Snappy([]byte("a"))
+	if err != nil {
+		t.Fatalf("Snappy returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Snappy(\"a\") = % x, want % x", got, expected)
+	}
+}
+
+func TestSnappyDecodeMalformed(t *testing.T) {
+	framed, err := os.ReadFile(filepath.Join("testdata", "snappy", "framed.sz"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	flipped := append([]byte(nil), framed...)
+	flipped[len(flipped)-1] ^= 0x01
+
+	tests := []struct {
+		name  string
+		input []byte
+		raw   bool
+	} {
+		{name: "Missing stream identifier", input: framed[10:], raw: false},
+		{name: "Flipped bit", input: flipped, raw: false},
+		{name: "Truncated stream", input: framed[:len(framed)-1], raw: false},
+		{name: "Unskippable chunk", input: append(append([]byte(nil), snappyStreamID...), 0x02, 0x00, 0x00, 0x00), raw: false},
+		{name: "Length larger than the data", input: []byte{0xFF, 0xFF, 0x03, 0x00, 'a'}, raw: true},
+		{name: "Copy before any data", input: []byte{0x04, 0x01, 0x01}, raw: true},
+		{name: "Length smaller than the data", input: []byte{0x01, 0x04, 'a', 'b'}, raw: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&SnappyCompressor{Raw: tt.raw}).Decompress(tt.input); err == nil {
+				t.Errorf("Decompress expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// --- // xxHash32
+
+const (
+	xxh32Prime1 uint32 = 0x9E3779B1
+	xxh32Prime2 uint32 = 0x85EBCA77
+	xxh32Prime3 uint32 = 0xC2B2AE3D
+	xxh32Prime4 uint32 = 0x27D4EB2F
+	xxh32Prime5 uint32 = 0x165667B1
+)
+
+// xxh32Round mixes one 4-byte lane into an accumulator.
+func xxh32Round(acc uint32, input uint32) uint32 {
+	acc += input * xxh32Prime2
+	acc = bits.RotateLeft32(acc, 13)
+	return acc * xxh32Prime1
+}
+
+// xxHash32 computes the 32-bit xxHash of data, as used by the LZ4 frame format.
+func xxHash32(data []byte, seed uint32) uint32 {
+	n := len(data)
+	var h uint32
+
+	if n >= 16 {
+		v1 := seed + xxh32Prime1 + xxh32Prime2
+		v2 := seed + xxh32Prime2
+		v3 := seed
+		v4 := seed - xxh32Prime1
+		for len(data) >= 16 {
+			v1 = xxh32Round(v1, binary.LittleEndian.Uint32(data[0:]))
+			v2 = xxh32Round(v2, binary.LittleEndian.Uint32(data[4:]))
+			v3 = xxh32Round(v3, binary.LittleEndian.Uint32(data[8:]))
+			v4 = xxh32Round(v4, binary.LittleEndian.Uint32(data[12:]))
+			data = data[16:]
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + xxh32Prime5
+	}
+
+	h += uint32(n)
+	for ; len(data) >= 4; data = data[4:] {
+		h += binary.LittleEndian.Uint32(data) * xxh32Prime3
+		h = bits.RotateLeft32(h, 17) * xxh32Prime4
+	}
+	for _, b := range data {
+		h += uint32(b) * xxh32Prime5
+		h = bits.RotateLeft32(h, 11) * xxh32Prime1
+	}
+
+	h ^= h >> 15
+	h *= xxh32Prime2
+	h ^= h >> 13
+	h *= xxh32Prime3
+	h ^= h >> 16
+	return h
+}
+
+// --- // xxHash64
+
+const (
+	xxh64Prime1 uint64 = 0x9E3779B185EBCA87
+	xxh64Prime2 uint64 = 0xC2B2AE3D27D4EB4F
+	xxh64Prime3 uint64 = 0x165667B19E3779F9
+	xxh64Prime4 uint64 = 0x85EBCA77C2B2AE63
+	xxh64Prime5 uint64 = 0x27D4EB2F165667C5
+)
+
+// xxh64Round mixes one 8-byte lane into an accumulator.
+func xxh64Round(acc uint64, input uint64) uint64 {
+	acc += input * xxh64Prime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxh64Prime1
+}
+
+// xxh64MergeRound folds an accumulator into the hash of a long input.
+func xxh64MergeRound(h uint64, acc uint64) uint64 {
+	h ^= xxh64Round(0, acc)
+	return h*xxh64Prime1 + xxh64Prime4
+}
+
+// xxHash64 computes the 64-bit xxHash of data, as used by the zstd frame format.
+func xxHash64(data []byte, seed uint64) uint64 {
+	n := len(data)
+	var h uint64
+
+	if n >= 32 {
+		v1 := seed + xxh64Prime1 + xxh64Prime2
+		v2 := seed + xxh64Prime2
+		v3 := seed
+		v4 := seed - xxh64Prime1
+		for len(data) >= 32 {
+			v1 = xxh64Round(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxh64Round(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxh64Round(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxh64Round(v4, binary.LittleEndian.Uint64(data[24:]))
+			data = data[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxh64MergeRound(h, v1)
+		h = xxh64MergeRound(h, v2)
+		h = xxh64MergeRound(h, v3)
+		h = xxh64MergeRound(h, v4)
+	} else {
+		h = seed + xxh64Prime5
+	}
+
+	h += uint64(n)
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxh64Round(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxh64Prime1 + xxh64Prime4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxh64Prime1
+		h = bits.RotateLeft64(h, 23)*xxh64Prime2 + xxh64Prime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxh64Prime5
+		h = bits.RotateLeft64(h, 11) * xxh64Prime1
+	}
+
+	h ^= h >> 33
+	h *= xxh64Prime2
+	h ^= h >> 29
+	h *= xxh64Prime3
+	h ^= h >> 32
+	return h
+}
@@ -0,0 +1,420 @@
+package algorithms
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+)
+
+const (
+	xzHeaderSize     = 12
+	xzFooterSize     = 12
+	xzFilterLZMA2    = 0x21
+	xzFilterDelta    = 0x03
+	xzFilterX86      = 0x04
+	xzFilterARMThumb = 0x08
+	xzFilterARM64    = 0x0A
+	xzMaxDictSize    = 1 << 30 // Largest dictionary the encoder uses (and the size it rounds the input up to)
+	xzMinDictSize    = 4 << 10
+	xzMaxVLISize     = 9
+	xzDefaultCheck   = xzCheckCRC64
+	xzBlockFlagSizes = 0xC0 // Block header flags for the compressed and uncompressed sizes
+	xzBlockFlagsMask = 0x3C // Reserved block header flags
+
+	// Integrity checks
+	xzCheckNone   = 0x00
+	xzCheckCRC32  = 0x01
+	xzCheckCRC64  = 0x04
+	xzCheckSHA256 = 0x0A
+)
+
+var (
+	xzHeaderMagic = []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}
+	xzFooterMagic = []byte{'Y', 'Z'}
+	crc64Table    = crc64.MakeTable(crc64.ECMA)
+)
+
+// Sizes of the integrity checks this package can verify
+var xzCheckSizes = map[byte]int{xzCheckNone: 0, xzCheckCRC32: 4, xzCheckCRC64: 8, xzCheckSHA256: 32}
+
+// xzCheck computes the integrity check of type `checkType` as it is stored after a block.
+func xzCheck(checkType byte, data []byte) []byte {
+	var check [8]byte
+	switch checkType {
+	case xzCheckCRC32:
+		binary.LittleEndian.PutUint32(check[:], crc32.ChecksumIEEE(data))
+		return check[:4]
+	case xzCheckCRC64:
+		binary.LittleEndian.PutUint64(check[:], crc64.Checksum(data, crc64Table))
+		return check[:8]
+	case xzCheckSHA256:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+	return nil
+}
+
+// xzReadVLI reads a variable-length integer of the xz format (a uvarint of at most 9 bytes, without trailing zeros).
+func xzReadVLI(data []byte) (uint64, int, error) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 || n > xzMaxVLISize || (n > 1 && data[n-1] == 0) {
+		return 0, 0, fmt.Errorf("malformed xz data: invalid variable-length integer")
+	}
+	return value, n, nil
+}
+
+// xzAppendVLI appends a variable-length integer of the xz format.
+func xzAppendVLI(data []byte, value uint64) []byte {
+	return binary.AppendUvarint(data, value)
+}
+
+// xzDictSize returns the dictionary size of an LZMA2 dictionary size byte.
+func xzDictSize(b byte) (int, error) {
+	if b > 40 {
+		return 0, fmt.Errorf("malformed xz data: invalid LZMA2 dictionary size byte %d", b)
+	}
+	if b == 40 {
+		return 0xFFFFFFFF, nil
+	}
+	return (2 | int(b)&1) << (b/2 + 11), nil
+}
+
+// xzPad appends zeros to `data` until `size` is a multiple of four.
+func xzPad(data []byte, size int) []byte {
+	for ; size%4 != 0; size++ {
+		data = append(data, 0)
+	}
+	return data
+}
+
+// --- // xz Encoding
+
+// Xz compresses data into an xz stream (`.xz` files) with a single LZMA2 block and a CRC-64 check,
+// readable by the reference `xz` tool.
+func Xz(data []byte) ([]byte, error) {
+	flags := []byte{0x00, xzDefaultCheck}
+	output := append(append([]byte(nil), xzHeaderMagic...), flags...)
+	output = binary.LittleEndian.AppendUint32(output, crc32.ChecksumIEEE(flags))
+
+	var index []byte
+	records := 0
+	if len(data) > 0 {
+		// The smallest dictionary that holds all of the data, so that decoders do not allocate more than needed
+		dictByte := byte(0)
+		for size, _ := xzDictSize(dictByte); size < len(data) && size < xzMaxDictSize; size, _ = xzDictSize(dictByte) {
+			dictByte++
+		}
+		dictSize, _ := xzDictSize(dictByte)
+
+		header := []byte{0x00, 0x00, xzFilterLZMA2, 0x01, dictByte} // Size, flags (one filter), LZMA2 and its properties
+		header = xzPad(header, len(header)+4)
+		header[0] = byte((len(header)+4)/4 - 1)
+		header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(header))
+
+		compressed := lzma2Encode(data, dictSize)
+		output = append(output, header...)
+		output = append(output, compressed...)
+		output = xzPad(output, len(compressed))
+		output = append(output, xzCheck(xzDefaultCheck, data)...)
+
+		index = xzAppendVLI(index, uint64(len(header)+len(compressed)+xzCheckSizes[xzDefaultCheck]))
+		index = xzAppendVLI(index, uint64(len(data)))
+		records++
+		verbosePrintf("Xz: block: %v -> %v bytes, dictionary: %v bytes\n", len(data), len(compressed), dictSize)
+	}
+
+	// Index
+	index = append(xzAppendVLI([]byte{0x00}, uint64(records)), index...)
+	index = xzPad(index, len(index))
+	index = binary.LittleEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+	output = append(output, index...)
+
+	// Footer
+	footer := binary.LittleEndian.AppendUint32(nil, uint32(len(index)/4-1))
+	footer = append(footer, flags...)
+	output = binary.LittleEndian.AppendUint32(output, crc32.ChecksumIEEE(footer))
+	output = append(output, footer...)
+	output = append(output, xzFooterMagic...)
+
+	return output, nil
+}
+
+// --- // xz Decoding
+
+// XzDecode decompresses one or more concatenated xz streams, with optional stream padding between them.
+// Every block must end its filter chain with LZMA2, optionally after delta and the x86, ARM64 and ARM Thumb BCJ filters;
+// its integrity check (none, CRC-32, CRC-64 or SHA-256) is verified.
+func XzDecode(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	var output []byte
+	for len(data) > 0 {
+		stream, rest, err := xzDecodeStream(data)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, stream...)
+
+		// Stream padding: null bytes, a multiple of four of them
+		padding := 0
+		for padding < len(rest) && rest[padding] == 0 {
+			padding++
+		}
+		if padding%4 != 0 {
+			return nil, fmt.Errorf("malformed xz data: stream padding of %d bytes is not a multiple of four", padding)
+		}
+		data = rest[padding:]
+	}
+
+	return output, nil
+}
+
+// xzDecodeStream decodes the stream at the start of `data`, returning its content and the data after it.
+func xzDecodeStream(data []byte) ([]byte, []byte, error) {
+	if len(data) < xzHeaderSize || !bytes.Equal(data[:6], xzHeaderMagic) {
+		return nil, nil, fmt.Errorf("malformed xz data: missing stream header")
+	}
+	flags := data[6:8]
+	if binary.LittleEndian.Uint32(data[8:]) != crc32.ChecksumIEEE(flags) {
+		return nil, nil, fmt.Errorf("malformed xz data: stream header checksum mismatch")
+	}
+	if flags[0] != 0 || flags[1]&0xF0 != 0 {
+		return nil, nil, fmt.Errorf("malformed xz data: reserved stream flags are set")
+	}
+	checkType := flags[1]
+	if _, ok := xzCheckSizes[checkType]; !ok {
+		return nil, nil, fmt.Errorf("unsupported xz data: integrity check type 0x%02X", checkType)
+	}
+	data = data[xzHeaderSize:]
+
+	// Blocks, until the index indicator
+	var content []byte
+	var records [][2]uint64 // Unpadded and uncompressed size of every block
+	for {
+		if len(data) == 0 {
+			return nil, nil, fmt.Errorf("malformed xz data: missing index")
+		}
+		if data[0] == 0x00 {
+			break
+		}
+		block, unpadded, n, err := xzDecodeBlock(data, checkType)
+		if err != nil {
+			return nil, nil, err
+		}
+		content = append(content, block...)
+		records = append(records, [2]uint64{uint64(unpadded), uint64(len(block))})
+		data = data[n:]
+	}
+
+	// Index, which must list exactly the blocks that were read
+	pos := 1
+	count, n, err := xzReadVLI(data[pos:])
+	if err != nil {
+		return nil, nil, err
+	}
+	pos += n
+	if count != uint64(len(records)) {
+		return nil, nil, fmt.Errorf("malformed xz data: the index lists %d blocks, the stream has %d", count, len(records))
+	}
+	for _, record := range records {
+		for _, expected := range record {
+			value, n, err := xzReadVLI(data[pos:])
+			if err != nil {
+				return nil, nil, err
+			}
+			if value != expected {
+				return nil, nil, fmt.Errorf("malformed xz data: the index does not match the blocks")
+			}
+			pos += n
+		}
+	}
+	for ; pos%4 != 0; pos++ {
+		if pos >= len(data) || data[pos] != 0 {
+			return nil, nil, fmt.Errorf("malformed xz data: invalid index padding")
+		}
+	}
+	if pos+4 > len(data) || binary.LittleEndian.Uint32(data[pos:]) != crc32.ChecksumIEEE(data[:pos]) {
+		return nil, nil, fmt.Errorf("malformed xz data: index checksum mismatch")
+	}
+	indexSize := pos + 4
+	data = data[indexSize:]
+
+	// Footer
+	if len(data) < xzFooterSize {
+		return nil, nil, fmt.Errorf("malformed xz data: truncated stream footer")
+	}
+	if !bytes.Equal(data[10:12], xzFooterMagic) || binary.LittleEndian.Uint32(data) != crc32.ChecksumIEEE(data[4:10]) {
+		return nil, nil, fmt.Errorf("malformed xz data: invalid stream footer")
+	}
+	if (uint64(binary.LittleEndian.Uint32(data[4:]))+1)*4 != uint64(indexSize) || !bytes.Equal(data[8:10], flags) {
+		return nil, nil, fmt.Errorf("malformed xz data: the stream footer does not match the stream")
+	}
+
+	return content, data[xzFooterSize:], nil
+}
+
+// xzFilter returns the function that undoes a non-last filter of a block's filter chain.
+func xzFilter(id uint64, properties []byte) (func([]byte) []byte, error) {
+	switch id {
+	case xzFilterDelta:
+		if len(properties) != 1 {
+			return nil, fmt.Errorf("malformed xz data: delta filter properties must be 1 byte")
+		}
+		distance := int(properties[0]) + 1
+		return func(data []byte) []byte {
+			out, _ := DeltaUnfilter(data, 1, distance) // Cannot fail with a width of 1
+			return out
+		}, nil
+	case xzFilterX86, xzFilterARM64, xzFilterARMThumb:
+		start := uint32(0)
+		switch len(properties) {
+		case 0:
+		case 4:
+			start = binary.LittleEndian.Uint32(properties)
+		default:
+			return nil, fmt.Errorf("malformed xz data: BCJ filter properties must be 0 or 4 bytes")
+		}
+		arch := map[uint64]int{xzFilterX86: BcjX86, xzFilterARM64: BcjARM64, xzFilterARMThumb: BcjARMThumb}[id]
+		return func(data []byte) []byte { return BcjUnfilter(data, arch, start) }, nil
+	default:
+		return nil, fmt.Errorf("unsupported xz data: filter 0x%02X", id)
+	}
+}
+
+// xzDecodeBlock decodes the block at the start of `data`, returning its content, its unpadded size and its total size.
+func xzDecodeBlock(data []byte, checkType byte) ([]byte, int, int, error) {
+	headerSize := (int(data[0]) + 1) * 4
+	if headerSize > len(data) {
+		return nil, 0, 0, fmt.Errorf("malformed xz data: truncated block header")
+	}
+	header := data[:headerSize]
+	if binary.LittleEndian.Uint32(header[headerSize-4:]) != crc32.ChecksumIEEE(header[:headerSize-4]) {
+		return nil, 0, 0, fmt.Errorf("malformed xz data: block header checksum mismatch")
+	}
+	flags := header[1]
+	if flags&xzBlockFlagsMask != 0 {
+		return nil, 0, 0, fmt.Errorf("malformed xz data: reserved block flags are set")
+	}
+
+	// Optional sizes, then the filter chain
+	fields := header[2 : headerSize-4]
+	var sizes [2]int64 // Compressed and uncompressed size, -1 when absent
+	for i, bit := range []byte{0x40, 0x80} {
+		sizes[i] = -1
+		if flags&bit != 0 {
+			value, n, err := xzReadVLI(fields)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			sizes[i] = int64(value) // At most 63 bits in 9 bytes
+			fields = fields[n:]
+		}
+	}
+
+	dictSize := 0
+	var filters []func([]byte) []byte // Undo the filters in front of LZMA2, in the order they were applied
+	for i := 0; i <= int(flags&0x03); i++ {
+		id, n, err := xzReadVLI(fields)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		fields = fields[n:]
+		propertiesSize, n, err := xzReadVLI(fields)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		fields = fields[n:]
+		if propertiesSize > uint64(len(fields)) {
+			return nil, 0, 0, fmt.Errorf("malformed xz data: truncated filter properties")
+		}
+		properties := fields[:propertiesSize]
+		fields = fields[propertiesSize:]
+
+		if i < int(flags&0x03) {
+			filter, err := xzFilter(id, properties)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+		if id != xzFilterLZMA2 {
+			return nil, 0, 0, fmt.Errorf("unsupported xz data: filter 0x%02X (the last filter must be LZMA2)", id)
+		}
+		if len(properties) != 1 {
+			return nil, 0, 0, fmt.Errorf("malformed xz data: LZMA2 filter properties must be 1 byte")
+		}
+		if dictSize, err = xzDictSize(properties[0]); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	for _, b := range fields {
+		if b != 0 {
+			return nil, 0, 0, fmt.Errorf("malformed xz data: invalid block header padding")
+		}
+	}
+
+	// Compressed data, padding and check
+	content, compressedSize, err := lzma2Decode(data[headerSize:], dictSize)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if (sizes[0] >= 0 && sizes[0] != int64(compressedSize)) || (sizes[1] >= 0 && sizes[1] != int64(len(content))) {
+		return nil, 0, 0, fmt.Errorf("malformed xz data: the block header sizes do not match the block")
+	}
+	pos := headerSize + compressedSize
+	for ; pos%4 != 0; pos++ {
+		if pos >= len(data) || data[pos] != 0 {
+			return nil, 0, 0, fmt.Errorf("malformed xz data: invalid block padding")
+		}
+	}
+	for i := len(filters) - 1; i >= 0; i-- {
+		content = filters[i](content)
+	}
+	checkSize := xzCheckSizes[checkType]
+	if pos+checkSize > len(data) || !bytes.Equal(data[pos:pos+checkSize], xzCheck(checkType, content)) {
+		return nil, 0, 0, fmt.Errorf("malformed xz data: block check mismatch")
+	}
+
+	return content, headerSize + compressedSize + checkSize, pos + checkSize, nil
+}
+
+// --- // xz Compressor Interface
+
+// XZCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the xz format.
+type XZCompressor struct{}
+
+// Compress implements core.Compressor.
+func (x *XZCompressor) Compress(data []byte) ([]byte, error) {
+	return Xz(data)
+}
+
+// Decompress implements core.Decompressor.
+func (x *XZCompressor) Decompress(data []byte) ([]byte, error) {
+	return XzDecode(data)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (x *XZCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("XzCompressFile", inputFilePath, outputFilePath, x.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (x *XZCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("XzDecompressFile", inputFilePath, outputFilePath, x.Decompress)
+}
+
+// Factory function for creating an xz compressor instance.
+func NewXZCompressor() *XZCompressor {
+	return &XZCompressor{}
+}
+
+// Factory function for creating an xz decompressor instance.
+func NewXZDecompressor() *XZCompressor {
+	return NewXZCompressor()
+}
@@ -0,0 +1,125 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/xz were made with the reference `xz` tool (XZ Utils 5.6.4):
+//
+//	default.xz:             xz -6 of lz4FixtureContent() (one block, CRC-64)
+//	crc32_blocks.xz:        xz -1 -T2 --check=crc32 --block-size=20000 (6 blocks with sizes in their headers)
+//	sha256_padding_none.xz: xz --check=sha256, 4 bytes of stream padding, then xz --check=none (the content twice)
+//	random.xz:              xz of snappyRandomFixtureContent() (an uncompressed LZMA2 chunk)
+//	empty.xz:               xz of an empty input (a stream without blocks)
+
+func TestXzDecodeReferenceFixtures(t *testing.T) {
+	content := lz4FixtureContent()
+	tests := []struct {
+		file     string
+		expected []byte
+	} {
+		{file: "default.xz", expected: content},
+		{file: "crc32_blocks.xz", expected: content},
+		{file: "sha256_padding_none.xz", expected: append(append([]byte(nil), content...), content...)},
+		{file: "random.xz", expected: snappyRandomFixtureContent()},
+		{file: "empty.xz", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "xz", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			got, err := XzDecode(data)
+			if err != nil {
+				t.Fatalf("XzDecode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("XzDecode returned %d bytes, want %d", len(got), len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestXzRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	noise := make([]byte, 200000)
+	random.Read(noise)
+
+	// Text, then noise (stored chunks), then text again (a chunk after a state reset), then long runs (several chunks)
+	var mixed bytes.Buffer
+	mixed.Write(lz4FixtureContent())
+	mixed.Write(noise)
+	mixed.Write(lz4FixtureContent())
+	mixed.Write(make([]byte, 5<<20))
+
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Single character", input: []byte("A")},
+		{name: "Text", input: lz4FixtureContent()},
+		{name: "Random bytes", input: noise},
+		{name: "Mixed data", input: mixed.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Xz(tt.input)
+			if err != nil {
+				t.Fatalf("Xz returned unexpected error: %v", err)
+			}
+
+			got, err := XzDecode(compressed)
+			if err != nil {
+				t.Fatalf("XzDecode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("XzDecode(Xz(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestXzDecodeCorrupted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "xz", "crc32_blocks.xz"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+	} {
+		{name: "Stream header", offset: 7},
+		{name: "Block header", offset: 14},
+		{name: "Compressed data", offset: 500},
+		{name: "Check", offset: 12 + 1372 - 1},
+		{name: "Index", offset: len(data) - 20},
+		{name: "Stream footer", offset: len(data) - 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), data...)
+			corrupted[tt.offset] ^= 0x01
+			if _, err := XzDecode(corrupted); err == nil {
+				t.Errorf("XzDecode with a flipped bit at %d expected an error", tt.offset)
+			}
+		})
+	}
+
+	if _, err := XzDecode(data[:len(data)-1]); err == nil {
+		t.Errorf("XzDecode of a truncated stream expected an error")
+	}
+	if _, err := XzDecode(append(append([]byte(nil), data...), 0, 0)); err == nil {
+		t.Errorf("XzDecode with 2 bytes of stream padding expected an error")
+	}
+}
@@ -0,0 +1,913 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	zstdMagic               = 0xFD2FB528
+	zstdSkippableMagicMask  = 0xFFFFFFF0 // Skippable frames use the magic numbers 0x184D2A50 to 0x184D2A5F
+	zstdSkippableMagic      = 0x184D2A50
+	zstdDictionaryMagic     = 0xEC30A437 // Dictionaries in the zstd format, with an ID and entropy tables
+	zstdMaxBlockSize        = 128 << 10
+	zstdMinWindowLog        = 10
+	zstdHuffmanMaxBits      = 11
+	zstdHuffmanWeightMaxLog = 6
+
+	// Block types
+	zstdBlockRaw        = 0
+	zstdBlockRLE        = 1
+	zstdBlockCompressed = 2
+
+	// Literals section types
+	zstdLiteralsRaw        = 0
+	zstdLiteralsRLE        = 1
+	zstdLiteralsCompressed = 2
+	zstdLiteralsTreeless   = 3 // Compressed with the Huffman table of the previous block
+
+	// Modes of the sequence code tables
+	zstdModePredefined = 0
+	zstdModeRLE        = 1
+	zstdModeFSE        = 2
+	zstdModeRepeat     = 3
+
+	// Largest codes and table logs of the literal lengths, offsets and match lengths
+	zstdMaxLLCode = 35
+	zstdMaxOFCode = 31
+	zstdMaxMLCode = 52
+	zstdMaxLLLog  = 9
+	zstdMaxOFLog  = 8
+	zstdMaxMLLog  = 9
+)
+
+// Repeat offsets at the start of a frame without a dictionary
+var zstdInitialReps = [3]int{1, 4, 8}
+
+// Baselines and extra bits of the literal length and match length codes
+var (
+	zstdLLBase = [zstdMaxLLCode + 1]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	zstdLLBits = [zstdMaxLLCode + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	zstdMLBase = [zstdMaxMLCode + 1]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	zstdMLBits = [zstdMaxMLCode + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+)
+
+// Predefined distributions of the sequence codes (-1 is a "less than one" probability)
+var (
+	zstdLLDefault = zstdMustFSETable([]int{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}, 6)
+	zstdOFDefault = zstdMustFSETable([]int{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}, 5)
+	zstdMLDefault = zstdMustFSETable([]int{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1, -1, -1,
+	}, 6)
+)
+
+// zstdMustFSETable builds one of the predefined tables, which are known to be valid.
+func zstdMustFSETable(counts []int, tableLog int) *fseTable {
+	t, err := newFSETable(counts, tableLog)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// --- // Bit Streams
+
+// zstdBitReader reads a zstd bit stream backwards: the last byte holds a 1 bit just above the last bits written,
+// and the bits are read from there towards the first byte. Reading past the start gives zeros.
+type zstdBitReader struct {
+	data []byte
+	pos  int // Number of bits left; negative once the reader went past the start
+}
+
+// newZstdBitReader creates a zstdBitReader over `data`.
+func newZstdBitReader(data []byte) (*zstdBitReader, error) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, fmt.Errorf("malformed zstd data: invalid bit stream end")
+	}
+	return &zstdBitReader{data: data, pos: len(data)*8 - 9 + bits.Len8(data[len(data)-1])}, nil
+}
+
+// peek returns the next `n` bits (at most 56) without consuming them.
+func (r *zstdBitReader) peek(n int) uint64 {
+	start, shift := r.pos-n, 0
+	if start < 0 {
+		start, shift = 0, -start
+	}
+	if n <= shift {
+		return 0
+	}
+	var v uint64
+	for i, k := start>>3, 0; k < 8 && i+k < len(r.data); k++ {
+		v |= uint64(r.data[i+k]) << (8 * k)
+	}
+	v = v >> (start & 7) & (1<<(n-shift) - 1)
+	return v << shift
+}
+
+// readBits consumes and returns the next `n` bits.
+func (r *zstdBitReader) readBits(n int) uint64 {
+	v := r.peek(n)
+	r.pos -= n
+	return v
+}
+
+// readZstdForwardBits reads `n` bits of `data` starting at bit `pos`, least significant bit first.
+func readZstdForwardBits(data []byte, pos int, n int) uint32 {
+	v := uint32(0)
+	for i := 0; i < n; i++ {
+		if (pos+i)>>3 < len(data) {
+			v |= uint32(data[(pos+i)>>3]>>((pos+i)&7)&1) << i
+		}
+	}
+	return v
+}
+
+// --- // FSE Tables
+
+// zstdReadFSETable reads an FSE table description, returning the table and the number of bytes read.
+func zstdReadFSETable(data []byte, maxSymbol int, maxLog int) (*fseTable, int, error) {
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("malformed zstd data: missing FSE table")
+	}
+	tableLog := int(data[0]&0x0F) + fseMinTableLog
+	if tableLog > maxLog {
+		return nil, 0, fmt.Errorf("malformed zstd data: FSE table log %d exceeds %d", tableLog, maxLog)
+	}
+
+	pos := 4
+	remaining := 1<<tableLog + 1
+	threshold := 1 << tableLog
+	nbBits := tableLog + 1
+	var counts []int
+	for remaining > 1 {
+		if len(counts) > maxSymbol {
+			return nil, 0, fmt.Errorf("malformed zstd data: FSE table has more than %d symbols", maxSymbol+1)
+		}
+		max := 2*threshold - 1 - remaining
+		value := int(readZstdForwardBits(data, pos, nbBits))
+		if value&(threshold-1) < max {
+			value &= threshold - 1
+			pos += nbBits - 1
+		} else {
+			if value >= threshold {
+				value -= max
+			}
+			pos += nbBits
+		}
+
+		count := value - 1 // -1 is a "less than one" probability
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		counts = append(counts, count)
+
+		if count == 0 { // Followed by the number of further zero counts, 2 bits at a time
+			for {
+				repeat := int(readZstdForwardBits(data, pos, 2))
+				pos += 2
+				for i := 0; i < repeat; i++ {
+					counts = append(counts, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+		for remaining < threshold && threshold > 1 {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+
+	n := (pos + 7) / 8
+	if remaining != 1 || n > len(data) || len(counts) > maxSymbol+1 {
+		return nil, 0, fmt.Errorf("malformed zstd data: invalid FSE table")
+	}
+	t, err := newFSETable(counts, tableLog)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed zstd data: %w", err)
+	}
+	return t, n, nil
+}
+
+// --- // Huffman Tables
+
+// zstdHuffmanTable decodes literals by looking up the next maxBits bits.
+type zstdHuffmanTable struct {
+	maxBits int
+	symbol  []byte
+	length  []uint8
+}
+
+// newZstdHuffmanTable builds the table for the weights of all symbols (including the last one).
+// A symbol of weight w has a code of maxBits+1-w bits; codes are assigned by increasing weight, then symbol.
+func newZstdHuffmanTable(weights []uint8, maxBits int) *zstdHuffmanTable {
+	t := &zstdHuffmanTable{maxBits: maxBits, symbol: make([]byte, 1<<maxBits), length: make([]uint8, 1<<maxBits)}
+	pos := 0
+	for w := 1; w <= maxBits; w++ {
+		for s, weight := range weights {
+			if int(weight) != w {
+				continue
+			}
+			for i := 0; i < 1<<(w-1); i++ {
+				t.symbol[pos] = byte(s)
+				t.length[pos] = uint8(maxBits + 1 - w)
+				pos++
+			}
+		}
+	}
+	return t
+}
+
+// zstdCompleteWeights adds the implied weight of the last symbol to `weights`, returning them and the largest code length.
+func zstdCompleteWeights(weights []uint8) ([]uint8, int, error) {
+	total := 0
+	for _, w := range weights {
+		if w > zstdHuffmanMaxBits {
+			return nil, 0, fmt.Errorf("malformed zstd data: Huffman weight %d", w)
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, 0, fmt.Errorf("malformed zstd data: Huffman table without symbols")
+	}
+	maxBits := bits.Len(uint(total))
+	left := 1<<maxBits - total
+	if maxBits > zstdHuffmanMaxBits || left&(left-1) != 0 {
+		return nil, 0, fmt.Errorf("malformed zstd data: Huffman weights do not form a complete code")
+	}
+	return append(weights, uint8(bits.Len(uint(left)))), maxBits, nil
+}
+
+// zstdReadHuffmanTable reads a Huffman tree description, returning the table and the number of bytes read.
+func zstdReadHuffmanTable(data []byte) (*zstdHuffmanTable, int, error) {
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("malformed zstd data: missing Huffman table")
+	}
+	header := int(data[0])
+	var weights []uint8
+	n := 0
+
+	if header >= 128 { // Weights as 4-bit numbers
+		count := header - 127
+		n = 1 + (count+1)/2
+		if n > len(data) {
+			return nil, 0, fmt.Errorf("malformed zstd data: truncated Huffman table")
+		}
+		for i := 0; i < count; i++ {
+			weights = append(weights, data[1+i/2]>>(4*(1-i%2))&0x0F)
+		}
+	} else { // Weights compressed with FSE, decoded with two interleaved states
+		n = 1 + header
+		if n > len(data) {
+			return nil, 0, fmt.Errorf("malformed zstd data: truncated Huffman table")
+		}
+		t, tableSize, err := zstdReadFSETable(data[1:n], 255, zstdHuffmanWeightMaxLog)
+		if err != nil {
+			return nil, 0, err
+		}
+		r, err := newZstdBitReader(data[1+tableSize : n])
+		if err != nil {
+			return nil, 0, err
+		}
+		states := [2]uint32{uint32(r.readBits(t.tableLog)), uint32(r.readBits(t.tableLog))}
+		for i := 0; ; i ^= 1 {
+			if len(weights) >= 255 {
+				return nil, 0, fmt.Errorf("malformed zstd data: too many Huffman weights")
+			}
+			state := states[i]
+			weights = append(weights, uint8(t.decodeSymbol[state]))
+			states[i] = t.decodeBase[state] + uint32(r.readBits(int(t.decodeBits[state])))
+			if r.pos < 0 { // The other state holds the last weight
+				weights = append(weights, uint8(t.decodeSymbol[states[i^1]]))
+				break
+			}
+		}
+	}
+
+	weights, maxBits, err := zstdCompleteWeights(weights)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(weights) > 256 {
+		return nil, 0, fmt.Errorf("malformed zstd data: too many Huffman weights")
+	}
+	return newZstdHuffmanTable(weights, maxBits), n, nil
+}
+
+// decodeStream decodes one Huffman-coded stream into `out`, which must be filled exactly.
+func (t *zstdHuffmanTable) decodeStream(data []byte, out []byte) error {
+	r, err := newZstdBitReader(data)
+	if err != nil {
+		return err
+	}
+	for i := range out {
+		index := r.peek(t.maxBits)
+		out[i] = t.symbol[index]
+		r.pos -= int(t.length[index])
+	}
+	if r.pos != 0 {
+		return fmt.Errorf("malformed zstd data: Huffman stream does not end with its literals")
+	}
+	return nil
+}
+
+// --- // Dictionaries
+
+// zstdDictionary is a preset dictionary: its content, which goes in front of every frame, and the entropy tables and
+// repeat offsets the frames start with.
+type zstdDictionary struct {
+	id      uint32 // 0 for raw content
+	content []byte
+	huffman *zstdHuffmanTable
+	tables  [3]*fseTable // Literal length, offset and match length tables, nil for raw content
+	reps    [3]int
+}
+
+// zstdParseDictionary reads a dictionary in the zstd format, or takes any other data as raw content, without tables
+// and with the usual repeat offsets (as the reference tool does).
+func zstdParseDictionary(dictionary []byte) (*zstdDictionary, error) {
+	if len(dictionary) < 8 || binary.LittleEndian.Uint32(dictionary) != zstdDictionaryMagic {
+		return &zstdDictionary{content: dictionary, reps: zstdInitialReps}, nil
+	}
+
+	d := &zstdDictionary{id: binary.LittleEndian.Uint32(dictionary[4:])}
+	data := dictionary[8:]
+	huffman, n, err := zstdReadHuffmanTable(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+	}
+	d.huffman = huffman
+	data = data[n:]
+
+	// The tables come in the order offsets, match lengths, literal lengths
+	for _, table := range []struct {
+		index     int
+		maxSymbol int
+		maxLog    int
+	} {
+		{index: 1, maxSymbol: zstdMaxOFCode, maxLog: zstdMaxOFLog},
+		{index: 2, maxSymbol: zstdMaxMLCode, maxLog: zstdMaxMLLog},
+		{index: 0, maxSymbol: zstdMaxLLCode, maxLog: zstdMaxLLLog},
+	} {
+		t, n, err := zstdReadFSETable(data, table.maxSymbol, table.maxLog)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+		}
+		d.tables[table.index] = t
+		data = data[n:]
+	}
+
+	if len(data) < 12 {
+		return nil, fmt.Errorf("invalid zstd dictionary: truncated repeat offsets")
+	}
+	d.content = data[12:]
+	for i := range d.reps {
+		d.reps[i] = int(binary.LittleEndian.Uint32(data[4*i:]))
+		if d.reps[i] == 0 || d.reps[i] > len(d.content) {
+			return nil, fmt.Errorf("invalid zstd dictionary: repeat offset %d is out of range", d.reps[i])
+		}
+	}
+	return d, nil
+}
+
+// --- // Zstandard Decoding
+
+// zstdDecoder holds the state that carries over from block to block within a frame.
+type zstdDecoder struct {
+	output     []byte // Everything decoded so far; the current frame starts at frameStart
+	frameStart int
+	dictionary []byte          // Preset dictionary as given, nil for none
+	preset     *zstdDictionary // The parsed dictionary, whose content is put in front of every frame
+	prefix     int             // Number of dictionary bytes before frameStart, removed once the frame is decoded
+	huffman    *zstdHuffmanTable
+	tables     [3]*fseTable // Literal length, offset and match length tables of the previous block
+	reps       [3]int
+}
+
+// ZstdDecode decompresses one or more concatenated zstd frames, skipping skippable frames.
+// Content checksums are verified when present; frames that need a dictionary fail with an ErrDictionaryMismatch.
+func ZstdDecode(data []byte) ([]byte, error) {
+	return ZstdDecodeWithDictionary(data, nil)
+}
+
+// ZstdDecodeWithDictionary decompresses zstd frames like ZstdDecode, with the content of `dictionary` (in the zstd
+// format, or raw content) in front of every frame. It returns an ErrDictionaryMismatch if a frame records the ID of
+// another dictionary.
+func ZstdDecodeWithDictionary(data []byte, dictionary []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil // Return nil slice for empty input
+	}
+
+	preset, err := zstdParseDictionary(dictionary)
+	if err != nil {
+		return nil, err
+	}
+	z := &zstdDecoder{dictionary: dictionary, preset: preset}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("malformed zstd data: truncated magic number")
+		}
+		magic := binary.LittleEndian.Uint32(data)
+
+		if magic&zstdSkippableMagicMask == zstdSkippableMagic {
+			if len(data) < 8 {
+				return nil, fmt.Errorf("malformed zstd data: truncated skippable frame")
+			}
+			size := uint64(binary.LittleEndian.Uint32(data[4:]))
+			if size > uint64(len(data)-8) {
+				return nil, fmt.Errorf("malformed zstd data: truncated skippable frame")
+			}
+			data = data[8+size:]
+			continue
+		}
+		if magic != zstdMagic {
+			return nil, fmt.Errorf("malformed zstd data: unknown magic number 0x%08X", magic)
+		}
+
+		rest, err := z.decodeFrame(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+	}
+
+	return z.output, nil
+}
+
+// decodeFrame decodes the frame after its magic number, returning the data after it.
+func (z *zstdDecoder) decodeFrame(data []byte) ([]byte, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("malformed zstd data: truncated frame header")
+	}
+	descriptor := data[0]
+	pos := 1
+	if descriptor&0x08 != 0 {
+		return nil, fmt.Errorf("malformed zstd data: reserved frame header bit is set")
+	}
+	singleSegment := descriptor&0x20 != 0
+	checksum := descriptor&0x04 != 0
+	dictIDSize := []int{0, 1, 2, 4}[descriptor&0x03]
+	contentSizeSize := []int{0, 2, 4, 8}[descriptor>>6]
+	if singleSegment && contentSizeSize == 0 {
+		contentSizeSize = 1
+	}
+	headerSize := pos + dictIDSize + contentSizeSize
+	if !singleSegment {
+		headerSize++
+	}
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("malformed zstd data: truncated frame header")
+	}
+
+	windowSize := uint64(0)
+	if !singleSegment {
+		exponent, mantissa := uint64(data[pos]>>3), uint64(data[pos]&0x07)
+		windowBase := uint64(1) << (zstdMinWindowLog + exponent)
+		windowSize = windowBase + windowBase/8*mantissa
+		pos++
+	}
+	dictID := uint32(0)
+	for i := 0; i < dictIDSize; i++ {
+		dictID |= uint32(data[pos+i]) << (8 * i)
+	}
+	pos += dictIDSize
+	if dictID != 0 {
+		if err := checkDictionary(dictID, z.dictionary); err != nil {
+			return nil, err
+		}
+	}
+	contentSize := int64(-1)
+	if contentSizeSize > 0 {
+		size := uint64(0)
+		for i := 0; i < contentSizeSize; i++ {
+			size |= uint64(data[pos+i]) << (8 * i)
+		}
+		if contentSizeSize == 2 {
+			size += 256
+		}
+		contentSize = int64(size & (1<<63 - 1))
+		if singleSegment {
+			windowSize = size
+		}
+	}
+	pos += contentSizeSize
+	data = data[pos:]
+
+	// Blocks, after the dictionary so matches can reach into it and starting with its tables
+	z.output = append(z.output, z.preset.content...)
+	z.prefix = len(z.preset.content)
+	z.frameStart = len(z.output)
+	z.huffman = z.preset.huffman
+	z.tables = z.preset.tables
+	z.reps = z.preset.reps
+	blockMaxSize := uint64(zstdMaxBlockSize)
+	if windowSize < blockMaxSize {
+		blockMaxSize = windowSize
+	}
+	for last := false; !last; {
+		if len(data) < 3 {
+			return nil, fmt.Errorf("malformed zstd data: truncated block header")
+		}
+		header := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+		data = data[3:]
+		last = header&1 != 0
+		blockType := header >> 1 & 0x03
+		size := int(header >> 3)
+
+		switch blockType {
+		case zstdBlockRaw:
+			if size > len(data) {
+				return nil, fmt.Errorf("malformed zstd data: truncated block")
+			}
+			z.output = append(z.output, data[:size]...)
+			data = data[size:]
+		case zstdBlockRLE:
+			if len(data) < 1 {
+				return nil, fmt.Errorf("malformed zstd data: truncated block")
+			}
+			for i := 0; i < size; i++ {
+				z.output = append(z.output, data[0])
+			}
+			data = data[1:]
+		case zstdBlockCompressed:
+			if size > len(data) {
+				return nil, fmt.Errorf("malformed zstd data: truncated block")
+			}
+			start := len(z.output)
+			if err := z.decodeCompressedBlock(data[:size]); err != nil {
+				return nil, err
+			}
+			if uint64(len(z.output)-start) > blockMaxSize {
+				return nil, fmt.Errorf("malformed zstd data: block decodes to more than %d bytes", blockMaxSize)
+			}
+			data = data[size:]
+		default:
+			return nil, fmt.Errorf("malformed zstd data: reserved block type")
+		}
+		if uint64(size) > blockMaxSize {
+			return nil, fmt.Errorf("malformed zstd data: block of %d bytes exceeds %d", size, blockMaxSize)
+		}
+	}
+
+	content := z.output[z.frameStart:]
+	if contentSize >= 0 && int64(len(content)) != contentSize {
+		return nil, fmt.Errorf("malformed zstd data: frame has %d bytes, its header says %d", len(content), contentSize)
+	}
+	if checksum {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("malformed zstd data: truncated content checksum")
+		}
+		if binary.LittleEndian.Uint32(data) != uint32(xxHash64(content, 0)) {
+			return nil, fmt.Errorf("malformed zstd data: content checksum mismatch")
+		}
+		data = data[4:]
+	}
+	z.output = append(z.output[:z.frameStart-z.prefix], content...)
+	return data, nil
+}
+
+// decodeCompressedBlock decodes the literals and sequences of a compressed block.
+func (z *zstdDecoder) decodeCompressedBlock(block []byte) error {
+	literals, n, err := z.decodeLiterals(block)
+	if err != nil {
+		return err
+	}
+	return z.decodeSequences(block[n:], literals)
+}
+
+// decodeLiterals decodes the literals section of a block, returning the literals and the section size.
+func (z *zstdDecoder) decodeLiterals(block []byte) ([]byte, int, error) {
+	if len(block) < 1 {
+		return nil, 0, fmt.Errorf("malformed zstd data: missing literals section")
+	}
+	literalsType := block[0] & 0x03
+	sizeFormat := block[0] >> 2 & 0x03
+
+	if literalsType == zstdLiteralsRaw || literalsType == zstdLiteralsRLE {
+		headerSize := []int{1, 2, 1, 3}[sizeFormat]
+		if len(block) < headerSize {
+			return nil, 0, fmt.Errorf("malformed zstd data: truncated literals header")
+		}
+		var size int
+		switch headerSize {
+		case 1:
+			size = int(block[0] >> 3)
+		case 2:
+			size = int(block[0]>>4) | int(block[1])<<4
+		default:
+			size = int(block[0]>>4) | int(block[1])<<4 | int(block[2])<<12
+		}
+		if size > zstdMaxBlockSize {
+			return nil, 0, fmt.Errorf("malformed zstd data: %d literals exceed the block size", size)
+		}
+
+		if literalsType == zstdLiteralsRaw {
+			if headerSize+size > len(block) {
+				return nil, 0, fmt.Errorf("malformed zstd data: truncated literals")
+			}
+			return block[headerSize : headerSize+size], headerSize + size, nil
+		}
+		if headerSize >= len(block) {
+			return nil, 0, fmt.Errorf("malformed zstd data: truncated literals")
+		}
+		literals := make([]byte, size)
+		for i := range literals {
+			literals[i] = block[headerSize]
+		}
+		return literals, headerSize + 1, nil
+	}
+
+	// Huffman-coded literals, in one stream or four
+	headerSize := []int{3, 3, 4, 5}[sizeFormat]
+	sizeBits := []uint{10, 10, 14, 18}[sizeFormat]
+	if len(block) < headerSize {
+		return nil, 0, fmt.Errorf("malformed zstd data: truncated literals header")
+	}
+	header := uint64(0)
+	for i := 0; i < headerSize; i++ {
+		header |= uint64(block[i]) << (8 * i)
+	}
+	size := int(header >> 4 & (1<<sizeBits - 1))
+	compressedSize := int(header >> (4 + sizeBits) & (1<<sizeBits - 1))
+	if size > zstdMaxBlockSize {
+		return nil, 0, fmt.Errorf("malformed zstd data: %d literals exceed the block size", size)
+	}
+	if headerSize+compressedSize > len(block) {
+		return nil, 0, fmt.Errorf("malformed zstd data: truncated literals")
+	}
+	data := block[headerSize : headerSize+compressedSize]
+
+	if literalsType == zstdLiteralsCompressed {
+		t, n, err := zstdReadHuffmanTable(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		z.huffman = t
+		data = data[n:]
+	} else if z.huffman == nil {
+		return nil, 0, fmt.Errorf("malformed zstd data: literals reuse a Huffman table that does not exist")
+	}
+
+	literals := make([]byte, size)
+	if sizeFormat == 0 { // A single stream
+		if err := z.huffman.decodeStream(data, literals); err != nil {
+			return nil, 0, err
+		}
+		return literals, headerSize + compressedSize, nil
+	}
+	if len(data) < 6 {
+		return nil, 0, fmt.Errorf("malformed zstd data: truncated literals jump table")
+	}
+	streams := data[6:]
+	segment := (size + 3) / 4
+	for i := 0; i < 4; i++ {
+		streamSize := len(streams)
+		if i < 3 {
+			streamSize = int(binary.LittleEndian.Uint16(data[2*i:]))
+		}
+		start, end := i*segment, (i+1)*segment
+		if end > size {
+			end = size
+		}
+		if start > end {
+			start = end
+		}
+		if streamSize > len(streams) {
+			return nil, 0, fmt.Errorf("malformed zstd data: truncated literals stream")
+		}
+		if err := z.huffman.decodeStream(streams[:streamSize], literals[start:end]); err != nil {
+			return nil, 0, err
+		}
+		streams = streams[streamSize:]
+	}
+	return literals, headerSize + compressedSize, nil
+}
+
+// sequenceTable returns the table of one sequence code for the given mode, reading it from `data` if needed.
+func (z *zstdDecoder) sequenceTable(i int, mode byte, data []byte, defaults *fseTable, maxSymbol int, maxLog int) (int, error) {
+	switch mode {
+	case zstdModePredefined:
+		z.tables[i] = defaults
+		return 0, nil
+	case zstdModeRLE:
+		if len(data) < 1 {
+			return 0, fmt.Errorf("malformed zstd data: truncated sequences header")
+		}
+		if int(data[0]) > maxSymbol {
+			return 0, fmt.Errorf("malformed zstd data: invalid sequence code %d", data[0])
+		}
+		counts := make([]int, data[0]+1)
+		counts[data[0]] = 1
+		t, err := newFSETable(counts, 0)
+		z.tables[i] = t
+		return 1, err
+	case zstdModeFSE:
+		t, n, err := zstdReadFSETable(data, maxSymbol, maxLog)
+		z.tables[i] = t
+		return n, err
+	default:
+		if z.tables[i] == nil {
+			return 0, fmt.Errorf("malformed zstd data: sequences reuse a table that does not exist")
+		}
+		return 0, nil
+	}
+}
+
+// decodeSequences decodes the sequences section and executes the sequences, appending to the output.
+func (z *zstdDecoder) decodeSequences(data []byte, literals []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("malformed zstd data: missing sequences section")
+	}
+	count := int(data[0])
+	pos := 1
+	switch {
+	case count == 255:
+		if len(data) < 3 {
+			return fmt.Errorf("malformed zstd data: truncated sequences header")
+		}
+		count = int(data[1]) | int(data[2])<<8 + 0x7F00
+		pos = 3
+	case count >= 128:
+		if len(data) < 2 {
+			return fmt.Errorf("malformed zstd data: truncated sequences header")
+		}
+		count = (count-128)<<8 | int(data[1])
+		pos = 2
+	}
+	if count == 0 {
+		if pos != len(data) {
+			return fmt.Errorf("malformed zstd data: data after an empty sequences section")
+		}
+		z.output = append(z.output, literals...)
+		return nil
+	}
+
+	if pos >= len(data) {
+		return fmt.Errorf("malformed zstd data: truncated sequences header")
+	}
+	modes := data[pos]
+	pos++
+	if modes&0x03 != 0 {
+		return fmt.Errorf("malformed zstd data: reserved sequences header bits are set")
+	}
+	for i, table := range []struct {
+		mode      byte
+		defaults  *fseTable
+		maxSymbol int
+		maxLog    int
+	}{
+		{modes >> 6, zstdLLDefault, zstdMaxLLCode, zstdMaxLLLog},
+		{modes >> 4 & 0x03, zstdOFDefault, zstdMaxOFCode, zstdMaxOFLog},
+		{modes >> 2 & 0x03, zstdMLDefault, zstdMaxMLCode, zstdMaxMLLog},
+	} {
+		n, err := z.sequenceTable(i, table.mode, data[pos:], table.defaults, table.maxSymbol, table.maxLog)
+		if err != nil {
+			return err
+		}
+		pos += n
+	}
+
+	r, err := newZstdBitReader(data[pos:])
+	if err != nil {
+		return err
+	}
+	ll, of, ml := z.tables[0], z.tables[1], z.tables[2]
+	llState := uint32(r.readBits(ll.tableLog))
+	ofState := uint32(r.readBits(of.tableLog))
+	mlState := uint32(r.readBits(ml.tableLog))
+
+	for i := 0; i < count; i++ {
+		llCode, ofCode, mlCode := ll.decodeSymbol[llState], of.decodeSymbol[ofState], ml.decodeSymbol[mlState]
+		if ofCode > zstdMaxOFCode {
+			return fmt.Errorf("malformed zstd data: invalid offset code %d", ofCode)
+		}
+		offsetValue := 1<<ofCode + int(r.readBits(int(ofCode)))
+		matchLength := int(zstdMLBase[mlCode]) + int(r.readBits(int(zstdMLBits[mlCode])))
+		literalLength := int(zstdLLBase[llCode]) + int(r.readBits(int(zstdLLBits[llCode])))
+		if i < count-1 {
+			llState = ll.decodeBase[llState] + uint32(r.readBits(int(ll.decodeBits[llState])))
+			mlState = ml.decodeBase[mlState] + uint32(r.readBits(int(ml.decodeBits[mlState])))
+			ofState = of.decodeBase[ofState] + uint32(r.readBits(int(of.decodeBits[ofState])))
+		}
+
+		// Offset values 1 to 3 are repeat offsets (shifted by one after an empty literal run), the others are offsets + 3
+		offset := 0
+		if offsetValue > 3 {
+			offset = offsetValue - 3
+			z.reps = [3]int{offset, z.reps[0], z.reps[1]}
+		} else {
+			index := offsetValue - 1
+			if literalLength == 0 {
+				index++
+			}
+			switch index {
+			case 0:
+				offset = z.reps[0]
+			case 1:
+				offset = z.reps[1]
+				z.reps = [3]int{offset, z.reps[0], z.reps[2]}
+			case 2:
+				offset = z.reps[2]
+				z.reps = [3]int{offset, z.reps[0], z.reps[1]}
+			default:
+				offset = z.reps[0] - 1
+				z.reps = [3]int{offset, z.reps[0], z.reps[1]}
+			}
+		}
+
+		// Execute the sequence: literals, then the match
+		if literalLength > len(literals) {
+			return fmt.Errorf("malformed zstd data: sequence uses more literals than the block has")
+		}
+		z.output = append(z.output, literals[:literalLength]...)
+		literals = literals[literalLength:]
+		if offset <= 0 || offset > len(z.output)-z.frameStart+z.prefix {
+			return fmt.Errorf("malformed zstd data: invalid offset %d at position %d", offset, len(z.output)-z.frameStart)
+		}
+		from := len(z.output) - offset
+		for j := 0; j < matchLength; j++ { // Byte by byte, since matches may overlap their own output
+			z.output = append(z.output, z.output[from+j])
+		}
+		if len(z.output)-z.frameStart > 0 && matchLength > zstdMaxBlockSize {
+			return fmt.Errorf("malformed zstd data: match of %d bytes exceeds the block size", matchLength)
+		}
+	}
+	if r.pos != 0 {
+		return fmt.Errorf("malformed zstd data: sequences bit stream does not end with its sequences")
+	}
+
+	z.output = append(z.output, literals...)
+	return nil
+}
+
+// --- // Zstandard Compressor Interface
+
+// ZstdCompressor implements core.GeneralCompressor and core.GeneralDecompressor with the zstd format.
+type ZstdCompressor struct {
+	Dictionary []byte // Preset dictionary (in the zstd format or raw content), nil for none
+}
+
+// Compress implements core.Compressor.
+func (z *ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	return ZstdWithDictionary(data, z.Dictionary)
+}
+
+// Decompress implements core.Decompressor.
+func (z *ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return ZstdDecodeWithDictionary(data, z.Dictionary)
+}
+
+// CompressFileToFile implements core.FileToFileCompressor.
+func (z *ZstdCompressor) CompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("ZstdCompressFile", inputFilePath, outputFilePath, z.Compress)
+}
+
+// DecompressFileToFile implements core.FileToFileDecompressor.
+func (z *ZstdCompressor) DecompressFileToFile(inputFilePath string, outputFilePath string) error {
+	return TransformFile("ZstdDecompressFile", inputFilePath, outputFilePath, z.Decompress)
+}
+
+// Factory function for creating a zstd compressor instance using Dictionary.
+func NewZstdCompressor() *ZstdCompressor {
+	return NewZstdCompressorWithDictionary(Dictionary)
+}
+
+// Factory function for creating a zstd compressor (or decompressor) instance with a preset dictionary.
+func NewZstdCompressorWithDictionary(dictionary []byte) *ZstdCompressor {
+	return &ZstdCompressor{Dictionary: dictionary}
+}
+
+// Factory function for creating a zstd decompressor instance.
+func NewZstdDecompressor() *ZstdCompressor {
+	return NewZstdCompressor()
+}
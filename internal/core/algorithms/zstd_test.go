@@ -0,0 +1,179 @@
+package algorithms
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/zstd were made with the reference `zstd` tool (v1.5.6):
+//
+//	default.zst:       zstd -3 of lz4FixtureContent() (content size and checksum in the frame)
+//	level19.zst:       zstd -19 (FSE-coded sequence tables and Huffman weights)
+//	fast_no_check.zst: zstd -1 --no-check
+//	stream.zst:        zstd -3 from a pipe (no content size, a window descriptor instead)
+//	concatenated.zst:  zstd of "hello hello hello world\n", followed by default.zst
+//	random.zst:        zstd of snappyRandomFixtureContent() (a raw block)
+//	zeros.zst:         zstd of 300000 zero bytes from a pipe (RLE blocks)
+//	empty.zst:         zstd of an empty input
+
+func TestXxHash64(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	} {
+		{input: "", expected: 0xEF46DB3751D8E999},
+		{input: "a", expected: 0xD24EC4F1A98C6E5B},
+		{input: "abc", expected: 0x44BC2CF5AD770999},
+		{input: "The quick brown fox jumps over the lazy dog, then the quick brown fox sleeps.", expected: 0x73A8685935130B06},
+	}
+
+	for _, tt := range tests {
+		if got := xxHash64([]byte(tt.input), 0); got != tt.expected {
+			t.Errorf("xxHash64(%q, 0) = 0x%016X, want 0x%016X", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestZstdDecodeReferenceFixtures(t *testing.T) {
+	content := lz4FixtureContent()
+	tests := []struct {
+		file     string
+		expected []byte
+	} {
+		{file: "default.zst", expected: content},
+		{file: "level19.zst", expected: content},
+		{file: "fast_no_check.zst", expected: content},
+		{file: "stream.zst", expected: content},
+		{file: "concatenated.zst", expected: append([]byte("hello hello hello world\n"), content...)},
+		{file: "random.zst", expected: snappyRandomFixtureContent()},
+		{file: "zeros.zst", expected: make([]byte, 300000)},
+		{file: "empty.zst", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "zstd", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			got, err := ZstdDecode(data)
+			if err != nil {
+				t.Fatalf("ZstdDecode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("ZstdDecode returned %d bytes, want %d", len(got), len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestZstdSkippableFrame(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "zstd", "default.zst"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	skippable := []byte{0x5A, 0x2A, 0x4D, 0x18, 3, 0, 0, 0, 'x', 'y', 'z'}
+	got, err := ZstdDecode(append(skippable, data...))
+	if err != nil {
+		t.Fatalf("ZstdDecode returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, lz4FixtureContent()) {
+		t.Errorf("ZstdDecode returned %d bytes, want %d", len(got), len(lz4FixtureContent()))
+	}
+}
+
+func TestZstdRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	noise := make([]byte, 200000)
+	random.Read(noise)
+
+	// Text, noise (raw blocks), a long run (an RLE block) and text again, matched across blocks
+	var mixed bytes.Buffer
+	mixed.Write(lz4FixtureContent())
+	mixed.Write(noise)
+	mixed.Write(make([]byte, 300000))
+	mixed.Write(lz4FixtureContent())
+
+	tests := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Empty input", input: nil},
+		{name: "Single character", input: []byte("A")},
+		{name: "Short repetition", input: []byte("abcabcabcabcabcabc")},
+		{name: "Text", input: lz4FixtureContent()},
+		{name: "Random bytes", input: noise},
+		{name: "Mixed data", input: mixed.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Zstd(tt.input)
+			if err != nil {
+				t.Fatalf("Zstd returned unexpected error: %v", err)
+			}
+
+			got, err := ZstdDecode(compressed)
+			if err != nil {
+				t.Fatalf("ZstdDecode returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("ZstdDecode(Zstd(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestZstdCompresses(t *testing.T) {
+	content := lz4FixtureContent()
+	compressed, err := Zstd(content)
+	if err != nil {
+		t.Fatalf("Zstd returned unexpected error: %v", err)
+	}
+	if len(compressed) > len(content)/5 {
+		t.Errorf("Zstd compressed %d bytes to %d, expected at least 5:1", len(content), len(compressed))
+	}
+}
+
+func TestZstdDecodeCorrupted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "zstd", "default.zst"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		mask   byte
+	} {
+		{name: "Magic number", offset: 0, mask: 0x01},
+		{name: "Reserved header bit", offset: 4, mask: 0x08},
+		{name: "Dictionary ID", offset: 4, mask: 0x01},
+		{name: "Content size", offset: 5, mask: 0x01},
+		{name: "Block type", offset: 9, mask: 0x04},
+		{name: "Compressed data", offset: 3000, mask: 0x01},
+		{name: "Checksum", offset: len(data) - 1, mask: 0x01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), data...)
+			corrupted[tt.offset] ^= tt.mask
+			if _, err := ZstdDecode(corrupted); err == nil {
+				t.Errorf("ZstdDecode with byte %d changed expected an error", tt.offset)
+			}
+		})
+	}
+
+	if _, err := ZstdDecode(data[:len(data)-1]); err == nil {
+		t.Errorf("ZstdDecode of a truncated frame expected an error")
+	}
+	if _, err := ZstdDecode(append(append([]byte(nil), data...), 0, 0)); err == nil {
+		t.Errorf("ZstdDecode with 2 bytes of trailing garbage expected an error")
+	}
+}
@@ -0,0 +1,513 @@
+package algorithms
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	zstdHashLog        = 16
+	zstdMinMatch       = 4
+	zstdMaxOffset      = 1 << 27 // Keeps the offset codes within the predefined offset table
+	zstdMinHuffmanSize = 64      // Fewer literals are stored as they are
+)
+
+// zstdSequence is a run of literals followed by a match, with the offset value as it is coded (repeat code or offset + 3).
+type zstdSequence struct {
+	literalLength int
+	matchLength   int
+	offsetValue   int
+}
+
+// --- // Bit Streams
+
+// zstdBitWriter writes the bit streams read by zstdBitReader: bits are added from the least significant end,
+// and the stream is closed with a 1 bit so the reader can find its end.
+type zstdBitWriter struct {
+	out   []byte
+	acc   uint64
+	nbits uint
+}
+
+// addBits appends the low `n` bits (at most 32) of `value`.
+func (w *zstdBitWriter) addBits(value uint64, n uint) {
+	w.acc |= (value & (1<<n - 1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// close adds the end marker and returns the stream.
+func (w *zstdBitWriter) close() []byte {
+	w.addBits(1, 1)
+	if w.nbits > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+// zstdEncodeSymbol moves the FSE `state` to the one that decodes to `symbol`, writing the bits the decoder reads.
+func zstdEncodeSymbol(w *zstdBitWriter, t *fseTable, state uint32, symbol int) uint32 {
+	count := uint32(t.counts[symbol])
+	if t.counts[symbol] == -1 {
+		count = 1
+	}
+	nbits := uint(0)
+	for state>>nbits >= 2*count {
+		nbits++
+	}
+	w.addBits(uint64(state), nbits)
+	return t.encodeState[symbol][state>>nbits-count]
+}
+
+// --- // Sequence Codes
+
+// zstdLiteralLengthCode returns the code of a literal length.
+func zstdLiteralLengthCode(length int) int {
+	code := zstdMaxLLCode
+	for int(zstdLLBase[code]) > length {
+		code--
+	}
+	return code
+}
+
+// zstdMatchLengthCode returns the code of a match length.
+func zstdMatchLengthCode(length int) int {
+	code := zstdMaxMLCode
+	for int(zstdMLBase[code]) > length {
+		code--
+	}
+	return code
+}
+
+// --- // Zstandard Encoding
+
+// zstdEncoder finds matches with a single hash table, keeping the repeat offsets in step with the decoder.
+type zstdEncoder struct {
+	data  []byte
+	table []int32 // Position + 1 of the last occurrence of each hash
+	reps  [3]int
+}
+
+// zstdHash hashes the 4 bytes at `data[i:]`.
+func zstdHash(data []byte, i int) uint32 {
+	return binary.LittleEndian.Uint32(data[i:]) * 2654435761 >> (32 - zstdHashLog)
+}
+
+// Zstd compresses data into a single zstd frame, with its content size and checksum in the frame.
+// Blocks are compressed with a greedy match finder, Huffman-coded literals and the predefined sequence tables.
+func Zstd(data []byte) ([]byte, error) {
+	return ZstdWithDictionary(data, nil)
+}
+
+// ZstdWithDictionary compresses data like Zstd, but matches may also reach into the content of `dictionary`, which is
+// in the zstd format or raw content. The ID of a dictionary in the zstd format goes in the frame header; raw content
+// has none, as with the reference tool.
+func ZstdWithDictionary(data []byte, dictionary []byte) ([]byte, error) {
+	preset, err := zstdParseDictionary(dictionary)
+	if err != nil {
+		return nil, err
+	}
+	out := binary.LittleEndian.AppendUint32(nil, zstdMagic)
+
+	// Frame header: a single segment frame (no window descriptor) with the smallest content size field, after the
+	// 4-byte dictionary ID if there is one
+	size := uint64(len(data))
+	var descriptor byte
+	var contentSize []byte
+	switch {
+	case size < 256:
+		descriptor, contentSize = 0x24, []byte{byte(size)}
+	case size < 65536+256:
+		descriptor, contentSize = 0x64, binary.LittleEndian.AppendUint16(nil, uint16(size-256))
+	case size < 1<<32:
+		descriptor, contentSize = 0xA4, binary.LittleEndian.AppendUint32(nil, uint32(size))
+	default:
+		descriptor, contentSize = 0xE4, binary.LittleEndian.AppendUint64(nil, size)
+	}
+	if preset.id != 0 {
+		out = append(out, descriptor|0x03)
+		out = binary.LittleEndian.AppendUint32(out, preset.id)
+	} else {
+		out = append(out, descriptor)
+	}
+	out = append(out, contentSize...)
+
+	// The dictionary comes before the data, so matches find it like earlier data
+	prefix := dictionaryTail(preset.content, zstdMaxOffset)
+	content := data
+	if len(prefix) > 0 {
+		content = append(append(make([]byte, 0, len(prefix)+len(data)), prefix...), data...)
+	}
+	e := &zstdEncoder{data: content, table: make([]int32, 1<<zstdHashLog), reps: preset.reps}
+	for i := 0; i+zstdMinMatch <= len(prefix); i++ {
+		e.table[zstdHash(content, i)] = int32(i + 1)
+	}
+	start := len(prefix)
+	for {
+		end := start + zstdMaxBlockSize
+		if end > len(content) {
+			end = len(content)
+		}
+		last := uint32(0)
+		if end == len(content) {
+			last = 1
+		}
+		block := content[start:end]
+
+		if len(block) > 1 && zstdSingleByte(block) {
+			out = zstdAppendBlockHeader(out, last|zstdBlockRLE<<1|uint32(len(block))<<3)
+			out = append(out, block[0])
+		} else if compressed := e.compressBlock(start, end); compressed != nil {
+			out = zstdAppendBlockHeader(out, last|zstdBlockCompressed<<1|uint32(len(compressed))<<3)
+			out = append(out, compressed...)
+		} else {
+			out = zstdAppendBlockHeader(out, last|zstdBlockRaw<<1|uint32(len(block))<<3)
+			out = append(out, block...)
+		}
+
+		start = end
+		if last == 1 {
+			break
+		}
+	}
+
+	out = binary.LittleEndian.AppendUint32(out, uint32(xxHash64(data, 0)))
+	verbosePrintf("Zstd: %d bytes compressed to %d bytes\n", len(data), len(out))
+	return out, nil
+}
+
+// zstdAppendBlockHeader appends a 3-byte block header.
+func zstdAppendBlockHeader(out []byte, header uint32) []byte {
+	return append(out, byte(header), byte(header>>8), byte(header>>16))
+}
+
+// zstdSingleByte reports whether all of `data` is the same byte.
+func zstdSingleByte(data []byte) bool {
+	for _, b := range data {
+		if b != data[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// compressBlock compresses data[start:end], returning nil if the block is better stored as it is.
+// The repeat offsets are only kept when the compressed block is used.
+func (e *zstdEncoder) compressBlock(start int, end int) []byte {
+	data := e.data
+	reps := e.reps
+	var literals []byte
+	var sequences []zstdSequence
+
+	literalStart := start
+	for i := start; i+zstdMinMatch <= end; {
+		h := zstdHash(data, i)
+		candidate := int(e.table[h]) - 1
+		e.table[h] = int32(i + 1)
+
+		offset := 0
+		if i > literalStart && i >= reps[0] && binary.LittleEndian.Uint32(data[i:]) == binary.LittleEndian.Uint32(data[i-reps[0]:]) {
+			offset = reps[0]
+		} else if candidate >= 0 && i-candidate <= zstdMaxOffset &&
+			binary.LittleEndian.Uint32(data[i:]) == binary.LittleEndian.Uint32(data[candidate:]) {
+			offset = i - candidate
+		} else {
+			i += 1 + (i-literalStart)>>6 // Skip ahead faster through data that does not match
+			continue
+		}
+
+		length := zstdMinMatch
+		for i+length < end && data[i+length] == data[i+length-offset] {
+			length++
+		}
+		for i > literalStart && i > offset && data[i-1] == data[i-1-offset] {
+			i--
+			length++
+		}
+
+		// Offset value 1 repeats the last offset; after an empty literal run it would mean the second one
+		literalLength := i - literalStart
+		offsetValue := offset + 3
+		if literalLength > 0 && offset == reps[0] {
+			offsetValue = 1
+		} else {
+			reps = [3]int{offset, reps[0], reps[1]}
+		}
+		literals = append(literals, data[literalStart:i]...)
+		sequences = append(sequences, zstdSequence{literalLength: literalLength, matchLength: length, offsetValue: offsetValue})
+
+		for _, p := range []int{i + length - 2, i + length - 1} {
+			if p+zstdMinMatch <= end {
+				e.table[zstdHash(data, p)] = int32(p + 1)
+			}
+		}
+		i += length
+		literalStart = i
+	}
+	literals = append(literals, data[literalStart:end]...)
+
+	block := zstdEncodeLiterals(literals)
+	block = zstdEncodeSequences(block, sequences)
+	if len(block) >= end-start {
+		return nil
+	}
+	e.reps = reps
+	return block
+}
+
+// zstdEncodeLiterals returns the literals section for `literals`: Huffman-coded in four streams when that is
+// smaller, otherwise stored or run-length coded.
+func zstdEncodeLiterals(literals []byte) []byte {
+	size := len(literals)
+	if size > 1 && zstdSingleByte(literals) {
+		return append(zstdRawLiteralsHeader(zstdLiteralsRLE, size), literals[0])
+	}
+	raw := append(zstdRawLiteralsHeader(zstdLiteralsRaw, size), literals...)
+	if size < zstdMinHuffmanSize {
+		return raw
+	}
+
+	// The table stores the weights of all symbols but the last as 4-bit numbers, which allows up to 128 of them
+	var freqs [256]int
+	highest := 0
+	for _, b := range literals {
+		freqs[b]++
+		if int(b) > highest {
+			highest = int(b)
+		}
+	}
+	if highest > 128 {
+		return raw
+	}
+	lengths, codes, body := zstdHuffmanCode(freqs[:highest+1])
+
+	// Four streams, each written from its last literal so the decoder reads them in order
+	segment := (size + 3) / 4
+	jumpTable := len(body)
+	body = append(body, make([]byte, 6)...)
+	for i := 0; i < 4; i++ {
+		start, end := i*segment, (i+1)*segment
+		if end > size {
+			end = size
+		}
+		if start > end {
+			start = end
+		}
+		w := &zstdBitWriter{}
+		for j := end - 1; j >= start; j-- {
+			w.addBits(codes[literals[j]], uint(lengths[literals[j]]))
+		}
+		stream := w.close()
+		if i < 3 {
+			if len(stream) > 0xFFFF {
+				return raw
+			}
+			binary.LittleEndian.PutUint16(body[jumpTable+2*i:], uint16(len(stream)))
+		}
+		body = append(body, stream...)
+	}
+
+	// Header with the literal count and compressed size in 10, 14 or 18 bits each
+	sizeFormat, sizeBits, headerSize := 1, uint(10), 3
+	if size >= 1<<10 || len(body) >= 1<<10 {
+		sizeFormat, sizeBits, headerSize = 2, 14, 4
+	}
+	if size >= 1<<14 || len(body) >= 1<<14 {
+		sizeFormat, sizeBits, headerSize = 3, 18, 5
+	}
+	if headerSize+len(body) >= len(raw) {
+		return raw
+	}
+	header := uint64(zstdLiteralsCompressed) | uint64(sizeFormat)<<2 | uint64(size)<<4 | uint64(len(body))<<(4+sizeBits)
+	out := make([]byte, 0, headerSize+len(body))
+	for i := 0; i < headerSize; i++ {
+		out = append(out, byte(header>>(8*i)))
+	}
+	return append(out, body...)
+}
+
+// zstdHuffmanCode returns the code lengths and codes for the frequencies of at most 129 symbols, the last of which
+// must be used, and the tree description that stores the weights of all symbols but the last as 4-bit numbers.
+func zstdHuffmanCode(freqs []int) ([]uint8, []uint64, []byte) {
+	lengths := huffmanCodeLengths(freqs, zstdHuffmanMaxBits)
+	maxBits := 0
+	for _, length := range lengths {
+		if int(length) > maxBits {
+			maxBits = int(length)
+		}
+	}
+	weights := make([]int, len(lengths))
+	for s, length := range lengths {
+		if length > 0 {
+			weights[s] = maxBits + 1 - int(length)
+		}
+	}
+	codes := make([]uint64, len(lengths))
+	pos := 0
+	for w := 1; w <= maxBits; w++ {
+		for s, weight := range weights {
+			if weight == w {
+				codes[s] = uint64(pos >> (w - 1))
+				pos += 1 << (w - 1)
+			}
+		}
+	}
+
+	last := len(freqs) - 1
+	description := []byte{byte(127 + last)}
+	for i := 0; i < last; i += 2 {
+		b := byte(weights[i]) << 4
+		if i+1 < last {
+			b |= byte(weights[i+1])
+		}
+		description = append(description, b)
+	}
+	return lengths, codes, description
+}
+
+// zstdAppendFSETable appends the description of the FSE table `t`, as zstdReadFSETable reads it, to `dst`.
+func zstdAppendFSETable(dst []byte, t *fseTable) []byte {
+	w := &zstdBitWriter{out: dst}
+	w.addBits(uint64(t.tableLog-fseMinTableLog), 4)
+	remaining := 1<<t.tableLog + 1
+	threshold := 1 << t.tableLog
+	nbBits := uint(t.tableLog + 1)
+	for symbol := 0; remaining > 1; symbol++ {
+		count := t.counts[symbol]
+		max := 2*threshold - 1 - remaining
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+
+		value := count + 1 // 0 is a "less than one" probability
+		if value >= threshold {
+			value += max
+		}
+		if value < max {
+			w.addBits(uint64(value), nbBits-1)
+		} else {
+			w.addBits(uint64(value), nbBits)
+		}
+		for remaining < threshold && threshold > 1 {
+			nbBits--
+			threshold >>= 1
+		}
+
+		if count == 0 { // The number of further zero counts, 2 bits at a time
+			zeros := 0
+			for symbol+1+zeros < len(t.counts) && t.counts[symbol+1+zeros] == 0 {
+				zeros++
+			}
+			symbol += zeros
+			for ; zeros >= 3; zeros -= 3 {
+				w.addBits(3, 2)
+			}
+			w.addBits(uint64(zeros), 2)
+		}
+	}
+	if w.nbits > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+// zstdDictionaryHeader returns what comes before the content of a dictionary in the zstd format: the magic number,
+// the ID, a Huffman table for the literals (from the ASCII bytes of `content`), the predefined sequence tables and
+// the initial repeat offsets. Its size does not depend on the content, which must be at least 8 bytes long to use.
+func zstdDictionaryHeader(content []byte, id uint32) []byte {
+	freqs := make([]int, 129)
+	for i := range freqs {
+		freqs[i] = 1 // Every ASCII byte gets a code
+	}
+	for _, b := range content {
+		if int(b) < len(freqs) {
+			freqs[b]++
+		}
+	}
+	_, _, description := zstdHuffmanCode(freqs)
+
+	header := binary.LittleEndian.AppendUint32(nil, zstdDictionaryMagic)
+	header = binary.LittleEndian.AppendUint32(header, id)
+	header = append(header, description...)
+	header = zstdAppendFSETable(header, zstdOFDefault)
+	header = zstdAppendFSETable(header, zstdMLDefault)
+	header = zstdAppendFSETable(header, zstdLLDefault)
+	for _, rep := range zstdInitialReps {
+		header = binary.LittleEndian.AppendUint32(header, uint32(rep))
+	}
+	return header
+}
+
+// zstdRawLiteralsHeader returns the header of a raw or RLE literals section with `size` literals.
+func zstdRawLiteralsHeader(literalsType int, size int) []byte {
+	switch {
+	case size < 1<<5:
+		return []byte{byte(literalsType | size<<3)}
+	case size < 1<<12:
+		return []byte{byte(literalsType | 1<<2 | size<<4), byte(size >> 4)}
+	default:
+		return []byte{byte(literalsType | 3<<2 | size<<4), byte(size >> 4), byte(size >> 12)}
+	}
+}
+
+// zstdEncodeSequences appends the sequences section, coded with the predefined tables, to `out`.
+func zstdEncodeSequences(out []byte, sequences []zstdSequence) []byte {
+	count := len(sequences)
+	switch {
+	case count < 128:
+		out = append(out, byte(count))
+	case count < 0x7F00:
+		out = append(out, byte(count>>8+128), byte(count))
+	default:
+		out = append(out, 255)
+		out = binary.LittleEndian.AppendUint16(out, uint16(count-0x7F00))
+	}
+	if count == 0 {
+		return out
+	}
+	out = append(out, zstdModePredefined<<6|zstdModePredefined<<4|zstdModePredefined<<2)
+
+	type codes struct {
+		ll, of, ml int
+	}
+	symbols := make([]codes, count)
+	for i, s := range sequences {
+		symbols[i] = codes{
+			ll: zstdLiteralLengthCode(s.literalLength),
+			of: bits.Len(uint(s.offsetValue)) - 1,
+			ml: zstdMatchLengthCode(s.matchLength),
+		}
+	}
+
+	// The decoder reads the stream backwards, so the sequences are written from the last one,
+	// each with its state transitions first and its extra bits after them
+	ll, of, ml := zstdLLDefault, zstdOFDefault, zstdMLDefault
+	w := &zstdBitWriter{}
+	addExtraBits := func(i int) {
+		s, c := sequences[i], symbols[i]
+		w.addBits(uint64(s.literalLength)-uint64(zstdLLBase[c.ll]), uint(zstdLLBits[c.ll]))
+		w.addBits(uint64(s.matchLength)-uint64(zstdMLBase[c.ml]), uint(zstdMLBits[c.ml]))
+		w.addBits(uint64(s.offsetValue)-1<<c.of, uint(c.of))
+	}
+	lastCodes := symbols[count-1]
+	llState := ll.encodeState[lastCodes.ll][0]
+	ofState := of.encodeState[lastCodes.of][0]
+	mlState := ml.encodeState[lastCodes.ml][0]
+	addExtraBits(count - 1)
+	for i := count - 2; i >= 0; i-- {
+		ofState = zstdEncodeSymbol(w, of, ofState, symbols[i].of)
+		mlState = zstdEncodeSymbol(w, ml, mlState, symbols[i].ml)
+		llState = zstdEncodeSymbol(w, ll, llState, symbols[i].ll)
+		addExtraBits(i)
+	}
+	w.addBits(uint64(mlState)-1<<ml.tableLog, uint(ml.tableLog))
+	w.addBits(uint64(ofState)-1<<of.tableLog, uint(of.tableLog))
+	w.addBits(uint64(llState)-1<<ll.tableLog, uint(ll.tableLog))
+	return append(out, w.close()...)
+}
@@ -0,0 +1,510 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+const (
+	archiveVersion    = 1    // Format version written into archives
+	archiveFooterSize = 12   // Length of the index (8 bytes, little-endian) and archiveIndexMagic
+	archiveMaxPath    = 4096 // Longest path accepted in an index
+)
+
+const ( // File type bits of the Unix mode recorded for an entry
+	archiveTypeDir  = 0o040000
+	archiveTypeFile = 0o100000
+)
+
+var archiveMagic = []byte{'G', 'C', 'A'}           // Start of an archive, followed by the format version
+var archiveIndexMagic = []byte{'G', 'C', 'A', 'I'} // End of an archive, after the length of the index
+
+// An archive bundles files and directories, each file compressed on its own. It is made of:
+//
+//   - the header: the magic "GCA" and the format version;
+//   - the compressed data of every file, one after another;
+//   - the index: the number of entries (uvarint) and, for every entry, the uvarint length of its path and the path,
+//     its Unix mode with the file type bits (uvarint), its mtime (varint seconds and uvarint nanoseconds since 1970),
+//     the number of stages and the algorithm ID of every stage (none if it is stored as is, and for directories),
+//     the size of its compressed data (uvarint) and, for files, the length and checksum of the uncompressed data
+//     (see algorithms.Integrity);
+//   - the footer: the length of the index (8 bytes, little-endian) and the magic "GCAI".
+//
+// Like in the seekable format, the footer has a fixed size, so a reader finds the index from the end of the file, and
+// the data of an entry can be read without reading the others.
+
+// ArchiveEntry is a file or directory of an archive.
+type ArchiveEntry struct {
+	Path           string      // Slash-separated path, relative to the directory the archive is extracted to
+	Mode           os.FileMode // Permission bits, with the setuid, setgid and sticky bits, and os.ModeDir for directories
+	ModTime        time.Time   // Modification time
+	Size           int64       // Size of the file
+	CompressedSize int64       // Size of the compressed data of the file
+	Stages         []int       // Algorithms the file was compressed with, in order; none if it is stored as is
+
+	offset    int64                // Offset of the compressed data in the archive
+	integrity algorithms.Integrity // Length and checksum of the file
+}
+
+// Algorithm returns the algorithm (or pipeline spec) the entry was compressed with, "stored" if it was not, and "-" for directories.
+func (e *ArchiveEntry) Algorithm() string {
+	switch {
+	case e.Mode.IsDir():
+		return "-"
+	case len(e.Stages) == 0:
+		return "stored"
+	default:
+		return (&Pipeline { Stages: e.Stages }).String()
+	}
+}
+
+// append appends the index entry of the ArchiveEntry to `dst`.
+func (e *ArchiveEntry) append(dst []byte) []byte {
+	mode := unixMode(e.Mode) | archiveTypeFile
+	if e.Mode.IsDir() {
+		mode = unixMode(e.Mode) | archiveTypeDir
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(e.Path)))
+	dst = append(dst, e.Path...)
+	dst = binary.AppendUvarint(dst, uint64(mode))
+	dst = binary.AppendVarint(dst, e.ModTime.Unix())
+	dst = binary.AppendUvarint(dst, uint64(e.ModTime.Nanosecond()))
+	dst = appendStages(dst, e.Stages)
+	dst = binary.AppendUvarint(dst, uint64(e.CompressedSize))
+	if !e.Mode.IsDir() {
+		dst = e.integrity.Append(dst)
+	}
+	return dst
+}
+
+// parseArchiveEntry reads an index entry from the start of `data`, returning it and the number of bytes it took.
+func parseArchiveEntry(data []byte) (*ArchiveEntry, int, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > archiveMaxPath || size > uint64(len(data)-n) {
+		return nil, 0, fmt.Errorf("invalid path length")
+	}
+	entry := &ArchiveEntry { Path: string(data[n : n+int(size)]) }
+	pos := n + int(size)
+	if !validArchivePath(entry.Path) {
+		return nil, 0, fmt.Errorf("invalid path %q", entry.Path)
+	}
+
+	mode, n := binary.Uvarint(data[pos:])
+	if n <= 0 || (mode&^0o7777 != archiveTypeFile && mode&^0o7777 != archiveTypeDir) {
+		return nil, 0, fmt.Errorf("%s: invalid mode", entry.Path)
+	}
+	entry.Mode = fileMode(uint32(mode & 0o7777))
+	if mode&^0o7777 == archiveTypeDir {
+		entry.Mode |= os.ModeDir
+	}
+	pos += n
+	seconds, n := binary.Varint(data[pos:])
+	if n <= 0 {
+		return nil, 0, fmt.Errorf("%s: invalid mtime", entry.Path)
+	}
+	pos += n
+	nanoseconds, n := binary.Uvarint(data[pos:])
+	if n <= 0 || nanoseconds >= uint64(time.Second) {
+		return nil, 0, fmt.Errorf("%s: invalid mtime", entry.Path)
+	}
+	entry.ModTime = time.Unix(seconds, int64(nanoseconds))
+	pos += n
+
+	// Stored entries and directories have no stages, which readStages does not accept
+	if pos >= len(data) {
+		return nil, 0, fmt.Errorf("%s: missing number of stages", entry.Path)
+	}
+	if data[pos] == 0 {
+		pos++
+	} else {
+		stages, rest, err := readStages(data[pos:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		entry.Stages, pos = stages, len(data)-len(rest)
+	}
+
+	compressedSize, n := binary.Uvarint(data[pos:])
+	if n <= 0 || compressedSize > 1<<62 || (entry.Mode.IsDir() && (compressedSize != 0 || entry.Stages != nil)) {
+		return nil, 0, fmt.Errorf("%s: invalid compressed size", entry.Path)
+	}
+	entry.CompressedSize = int64(compressedSize)
+	pos += n
+	if !entry.Mode.IsDir() {
+		integrity, n, err := algorithms.ParseIntegrity(data[pos:])
+		if err != nil || integrity.Length > 1<<62 {
+			return nil, 0, fmt.Errorf("%s: invalid size or checksum", entry.Path)
+		}
+		entry.integrity, entry.Size = integrity, int64(integrity.Length)
+		pos += n
+	}
+	return entry, pos, nil
+}
+
+// validArchivePath reports whether `name` is a clean relative slash-separated path that stays inside the directory it is
+// extracted to, so extracting cannot write anywhere else.
+func validArchivePath(name string) bool {
+	return name != "" && name != "." && name != ".." && path.Clean(name) == name && !path.IsAbs(name) &&
+		!strings.HasPrefix(name, "../") && !strings.ContainsAny(name, "\\\x00")
+}
+
+// --- // Archive Writer
+
+// ArchiveWriter writes an archive. Close must be called to write the index.
+type ArchiveWriter struct {
+	writer       io.Writer
+	checksumType int
+	offset       int64 // Offset of the next compressed data
+	entries      []*ArchiveEntry
+	paths        map[string]bool
+	closed       bool
+	Output       os.FileInfo // The file the archive is written to, which AddPath leaves out; nil if it is not a file
+	Skipped      []string    // Paths AddPath left out: the Output, and what is neither a regular file nor a directory (symlinks, devices...)
+}
+
+// NewArchiveWriter creates an ArchiveWriter that writes to `w`. The files carry the checksum selected by
+// algorithms.ChecksumType, or a CRC-32 if that is algorithms.ChecksumNone.
+func NewArchiveWriter(w io.Writer) (*ArchiveWriter, error) {
+	checksumType := algorithms.ChecksumType
+	if checksumType == algorithms.ChecksumNone {
+		checksumType = algorithms.ChecksumCRC32
+	}
+	header := append(append([]byte(nil), archiveMagic...), archiveVersion)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &ArchiveWriter { writer: w, checksumType: checksumType, offset: int64(len(header)), paths: map[string]bool{} }, nil
+}
+
+// AddFile adds a file with the given slash-separated path, mode and mtime, compressed with the stages (ints meant for
+// the `Algorithms` array in `implemented.go`). A file the stages do not make smaller is stored as is.
+func (a *ArchiveWriter) AddFile(name string, mode os.FileMode, modTime time.Time, data []byte, stages ...int) error {
+	if _, err := NewContainer(stages...); err != nil {
+		return err
+	}
+	integrity, err := algorithms.NewIntegrity(data, a.checksumType)
+	if err != nil {
+		return err
+	}
+	compressed, err := (&Pipeline { Stages: stages }).compressStages(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if len(compressed) >= len(data) {
+		compressed, stages = data, nil
+	}
+
+	entry := &ArchiveEntry { Path: name, Mode: mode & fileMetadataModeMask, ModTime: modTime, Size: int64(len(data)), CompressedSize: int64(len(compressed)), Stages: stages, integrity: integrity }
+	if err := a.add(entry); err != nil {
+		return err
+	}
+	if _, err := a.writer.Write(compressed); err != nil {
+		return err
+	}
+	a.offset += entry.CompressedSize
+	return nil
+}
+
+// AddDir adds a directory with the given slash-separated path, mode and mtime.
+func (a *ArchiveWriter) AddDir(name string, mode os.FileMode, modTime time.Time) error {
+	return a.add(&ArchiveEntry { Path: name, Mode: mode&fileMetadataModeMask | os.ModeDir, ModTime: modTime })
+}
+
+// AddPath adds the file or directory at `root`, with everything inside it, under its base name (or, for "." and "..",
+// adds what is inside it without a prefix). Files are compressed with the stages, as with AddFile. Symlinks and special
+// files are not followed or stored, but added to Skipped, like the archive itself (see Output).
+func (a *ArchiveWriter) AddPath(root string, stages ...int) error {
+	root = filepath.Clean(root)
+	prefix := filepath.Base(root)
+	if prefix == "." || prefix == ".." || prefix == string(filepath.Separator) {
+		prefix = ""
+	}
+
+	return filepath.WalkDir(root, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		relative, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		name := path.Join(prefix, filepath.ToSlash(relative))
+		if name == "." {
+			return nil // The root itself, without a prefix
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		switch {
+		case a.Output != nil && os.SameFile(info, a.Output):
+			a.Skipped = append(a.Skipped, current)
+			return nil
+		case info.IsDir():
+			return a.AddDir(name, info.Mode(), info.ModTime())
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(current)
+			if err != nil {
+				return fmt.Errorf("failed to read input file: %w", err)
+			}
+			return a.AddFile(name, info.Mode(), info.ModTime(), data, stages...)
+		default:
+			a.Skipped = append(a.Skipped, current)
+			return nil
+		}
+	})
+}
+
+// Close implements io.Closer, writing the index and the footer. It does not close the underlying writer.
+func (a *ArchiveWriter) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	index := binary.AppendUvarint(nil, uint64(len(a.entries)))
+	for _, entry := range a.entries {
+		index = entry.append(index)
+	}
+	index = binary.LittleEndian.AppendUint64(index, uint64(len(index)))
+	index = append(index, archiveIndexMagic...)
+	_, err := a.writer.Write(index)
+	return err
+}
+
+// add checks the path of an entry and records it for the index.
+func (a *ArchiveWriter) add(entry *ArchiveEntry) error {
+	if a.closed {
+		return fmt.Errorf("add to a closed ArchiveWriter")
+	}
+	if !validArchivePath(entry.Path) || len(entry.Path) > archiveMaxPath {
+		return fmt.Errorf("invalid archive path %q (must be a clean relative path with forward slashes)", entry.Path)
+	}
+	if a.paths[entry.Path] {
+		return fmt.Errorf("%s is already in the archive", entry.Path)
+	}
+	a.paths[entry.Path] = true
+	entry.offset = a.offset
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+// --- // Archive Reader
+
+// ArchiveReader reads the entries of an archive. The data of an entry is only read when it is asked for.
+type ArchiveReader struct {
+	source  io.ReaderAt
+	entries []*ArchiveEntry
+	file    *os.File // File opened by OpenArchive, closed by Close
+}
+
+// OpenArchive opens the archive at `path`. Close must be called when done with it.
+func OpenArchive(path string) (*ArchiveReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	reader, err := NewArchiveReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.file = file
+	return reader, nil
+}
+
+// NewArchiveReader creates an ArchiveReader for the archive of `size` bytes that `r` reads.
+// Only the header, the index and the footer are read here.
+func NewArchiveReader(r io.ReaderAt, size int64) (*ArchiveReader, error) {
+	headerSize := int64(len(archiveMagic) + 1)
+	if size < headerSize+archiveFooterSize {
+		return nil, fmt.Errorf("malformed archive: too short")
+	}
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, archiveMagic) {
+		return nil, fmt.Errorf("malformed archive: missing header")
+	}
+	if version := header[len(archiveMagic)]; version != archiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (only version %d is supported)", version, archiveVersion)
+	}
+
+	footer := make([]byte, archiveFooterSize)
+	if _, err := r.ReadAt(footer, size-archiveFooterSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[8:], archiveIndexMagic) {
+		return nil, fmt.Errorf("malformed archive: missing index")
+	}
+	indexSize := binary.LittleEndian.Uint64(footer)
+	if indexSize > uint64(size-archiveFooterSize-headerSize) {
+		return nil, fmt.Errorf("malformed archive: index of %d bytes does not fit", indexSize)
+	}
+	indexStart := size - archiveFooterSize - int64(indexSize)
+	index := make([]byte, indexSize)
+	if _, err := r.ReadAt(index, indexStart); err != nil {
+		return nil, err
+	}
+
+	count, n := binary.Uvarint(index)
+	if n <= 0 || count > indexSize {
+		return nil, fmt.Errorf("malformed archive: invalid number of entries")
+	}
+	index = index[n:]
+	reader := &ArchiveReader { source: r, entries: make([]*ArchiveEntry, count) }
+	paths := map[string]bool{}
+	offset := headerSize
+	for i := range reader.entries {
+		entry, n, err := parseArchiveEntry(index)
+		if err != nil {
+			return nil, fmt.Errorf("malformed archive: entry %d: %w", i, err)
+		}
+		if paths[entry.Path] {
+			return nil, fmt.Errorf("malformed archive: %s appears twice", entry.Path)
+		}
+		if entry.CompressedSize > indexStart-offset {
+			return nil, fmt.Errorf("malformed archive: the data of %s does not fit", entry.Path)
+		}
+		if entry.Stages == nil && entry.CompressedSize != entry.Size {
+			return nil, fmt.Errorf("malformed archive: %s is stored with the wrong size", entry.Path)
+		}
+		paths[entry.Path] = true
+		entry.offset = offset
+		offset += entry.CompressedSize
+		reader.entries[i] = entry
+		index = index[n:]
+	}
+	if len(index) != 0 || offset != indexStart {
+		return nil, fmt.Errorf("malformed archive: the index does not match the data")
+	}
+	return reader, nil
+}
+
+// Entries returns the entries of the archive, in the order they were added.
+func (a *ArchiveReader) Entries() []*ArchiveEntry {
+	return a.entries
+}
+
+// ReadFile returns the uncompressed data of a file entry, after checking its length and checksum.
+func (a *ArchiveReader) ReadFile(entry *ArchiveEntry) ([]byte, error) {
+	if entry.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", entry.Path)
+	}
+	data := make([]byte, entry.CompressedSize)
+	if _, err := a.source.ReadAt(data, entry.offset); err != nil && !(err == io.EOF && len(data) == 0) {
+		return nil, err
+	}
+	data, err := (&Pipeline { Stages: entry.Stages }).decompressStages(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+	if err := entry.integrity.Verify(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+	return data, nil
+}
+
+// Match returns the entries whose path, or the path of a directory they are in, matches one of the patterns (see path.Match),
+// and all of them without patterns. Every pattern must match something.
+func (a *ArchiveReader) Match(patterns ...string) ([]*ArchiveEntry, error) {
+	if len(patterns) == 0 {
+		return a.entries, nil
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var matched []*ArchiveEntry
+	used := make([]bool, len(patterns))
+	for _, entry := range a.entries {
+		found := false
+		for i, pattern := range patterns {
+			for name := entry.Path; name != "."; name = path.Dir(name) {
+				if ok, _ := path.Match(pattern, name); ok {
+					found, used[i] = true, true
+					break
+				}
+			}
+		}
+		if found {
+			matched = append(matched, entry)
+		}
+	}
+	for i, pattern := range patterns {
+		if !used[i] {
+			return nil, fmt.Errorf("%q matches nothing in the archive", pattern)
+		}
+	}
+	return matched, nil
+}
+
+// Extract writes the entries that match the patterns (all of them without patterns, see Match) into `dir`, with their
+// mode and mtime, and returns them.
+func (a *ArchiveReader) Extract(dir string, patterns ...string) ([]*ArchiveEntry, error) {
+	entries, err := a.Match(patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		target := filepath.Join(dir, filepath.FromSlash(entry.Path))
+		if entry.Mode.IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create output directory: %w", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		data, err := a.ReadFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := (&FileMetadata { Name: filepath.Base(target), Mode: entry.Mode, ModTime: entry.ModTime }).Restore(filepath.Dir(target), data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Directories get their mode and mtime last, since writing the files inside them changes the mtime (and a read-only mode would prevent it)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entry := entries[i]; entry.Mode.IsDir() {
+			target := filepath.Join(dir, filepath.FromSlash(entry.Path))
+			if err := os.Chmod(target, entry.Mode.Perm()); err != nil { // Never the setuid, setgid or sticky bits, as with files
+				return nil, fmt.Errorf("failed to restore the mode of the output directory: %w", err)
+			}
+			if err := os.Chtimes(target, entry.ModTime, entry.ModTime); err != nil {
+				return nil, fmt.Errorf("failed to restore the mtime of the output directory: %w", err)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Close closes the file opened by OpenArchive, if any.
+func (a *ArchiveReader) Close() error {
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// writeTestArchive writes an archive with a directory, a compressible file, a file stored as is and an empty file.
+func writeTestArchive(t *testing.T) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	archive, err := NewArchiveWriter(&buffer)
+	if err != nil {
+		t.Fatalf("NewArchiveWriter returned unexpected error: %v", err)
+	}
+	mtime := time.Unix(1700000000, 0)
+	if err := archive.AddDir("docs", 0750, mtime); err != nil {
+		t.Fatalf("AddDir returned unexpected error: %v", err)
+	}
+	if err := archive.AddFile("docs/readme.md", 0644, mtime, []byte(strings.Repeat("read me, ", 100)), algorithms.HuffmanAlgorithm); err != nil {
+		t.Fatalf("AddFile returned unexpected error: %v", err)
+	}
+	if err := archive.AddFile("docs/tiny.txt", 0600, mtime, []byte("tiny"), algorithms.DeltaAlgorithm, algorithms.ZstdAlgorithm); err != nil {
+		t.Fatalf("AddFile returned unexpected error: %v", err)
+	}
+	if err := archive.AddFile("empty", 0644, mtime, nil, algorithms.RLEAlgorithm); err != nil {
+		t.Fatalf("AddFile returned unexpected error: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	data := writeTestArchive(t)
+	archive, err := NewArchiveReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchiveReader returned unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		mode      os.FileMode
+		algorithm string
+		content   string
+	} {
+		{path: "docs", mode: 0750 | os.ModeDir, algorithm: "-"},
+		{path: "docs/readme.md", mode: 0644, algorithm: "huffman", content: strings.Repeat("read me, ", 100)},
+		{path: "docs/tiny.txt", mode: 0600, algorithm: "stored", content: "tiny"}, // Compressing would make it larger
+		{path: "empty", mode: 0644, algorithm: "stored"},
+	}
+	entries := archive.Entries()
+	if len(entries) != len(tests) {
+		t.Fatalf("Entries() returned %d entries, want %d", len(entries), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			entry := entries[i]
+			if entry.Path != tt.path || entry.Mode != tt.mode || entry.Algorithm() != tt.algorithm || entry.Size != int64(len(tt.content)) {
+				t.Errorf("entry %d = {%q, %v, %q, %d}, want {%q, %v, %q, %d}", i, entry.Path, entry.Mode, entry.Algorithm(), entry.Size, tt.path, tt.mode, tt.algorithm, len(tt.content))
+			}
+			if !entry.ModTime.Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("entry %d has mtime %v", i, entry.ModTime)
+			}
+			if entry.Mode.IsDir() {
+				return
+			}
+			got, err := archive.ReadFile(entry)
+			if err != nil || string(got) != tt.content {
+				t.Errorf("ReadFile = %q, %v, want %q", got, err, tt.content)
+			}
+		})
+	}
+}
+
+func TestArchiveMatch(t *testing.T) {
+	data := writeTestArchive(t)
+	archive, err := NewArchiveReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchiveReader returned unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	} {
+		{name: "all", want: []string{"docs", "docs/readme.md", "docs/tiny.txt", "empty"}},
+		{name: "glob", patterns: []string{"docs/*.md"}, want: []string{"docs/readme.md"}},
+		{name: "directory", patterns: []string{"docs"}, want: []string{"docs", "docs/readme.md", "docs/tiny.txt"}},
+		{name: "several", patterns: []string{"empty", "*/tiny.*"}, want: []string{"docs/tiny.txt", "empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := archive.Match(tt.patterns...)
+			if err != nil {
+				t.Fatalf("Match returned unexpected error: %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Match(%q) = %q, want %q", tt.patterns, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := archive.Match("docs/*.md", "missing*"); err == nil {
+		t.Errorf("Match with a pattern that matches nothing expected an error")
+	}
+	if _, err := archive.Match("[docs"); err == nil {
+		t.Errorf("Match with an invalid pattern expected an error")
+	}
+}
+
+func TestArchiveInvalid(t *testing.T) {
+	archive, _ := NewArchiveWriter(&bytes.Buffer{})
+	for _, name := range []string{"", ".", "..", "../evil", "/etc/passwd", "a/../b", "a//b", "dir/", `dir\file`} {
+		if err := archive.AddFile(name, 0644, time.Unix(0, 0), []byte("data"), algorithms.RLEAlgorithm); err == nil {
+			t.Errorf("AddFile with the path %q expected an error", name)
+		}
+	}
+	if err := archive.AddDir("dir", 0755, time.Unix(0, 0)); err != nil {
+		t.Fatalf("AddDir returned unexpected error: %v", err)
+	}
+	if err := archive.AddFile("dir", 0644, time.Unix(0, 0), []byte("data"), algorithms.RLEAlgorithm); err == nil {
+		t.Errorf("AddFile with a path already in the archive expected an error")
+	}
+
+	data := writeTestArchive(t)
+	reader, err := NewArchiveReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchiveReader returned unexpected error: %v", err)
+	}
+
+	// A changed byte in the data of a file is caught by its checksum
+	corrupt := append([]byte(nil), data...)
+	corrupt[reader.Entries()[2].offset+1] ^= 0x20
+	reader, err = NewArchiveReader(bytes.NewReader(corrupt), int64(len(corrupt)))
+	if err != nil {
+		t.Fatalf("NewArchiveReader returned unexpected error: %v", err)
+	}
+	if _, err := reader.ReadFile(reader.Entries()[2]); !errors.Is(err, &algorithms.ErrChecksumMismatch{}) {
+		t.Errorf("ReadFile of a changed file returned %v, want an ErrChecksumMismatch", err)
+	}
+
+	// An index that names a path outside the directory is refused
+	evil := bytes.Replace(data, []byte("docs/tiny.txt"), []byte("../../tiny.tx"), 1)
+	malformed := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "truncated", input: data[:len(data)-1]},
+		{name: "unknown version", input: append([]byte{'G', 'C', 'A', 9}, data[len(archiveMagic)+1:]...)},
+		{name: "extra data", input: append(append(data[:4:4], 0), data[4:]...)},
+		{name: "path outside", input: evil},
+		{name: "too short", input: data[:archiveFooterSize]},
+	}
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewArchiveReader(bytes.NewReader(tt.input), int64(len(tt.input))); err == nil {
+				t.Errorf("NewArchiveReader expected an error")
+			}
+		})
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "project")
+	input := []byte(strings.Repeat("package main\n", 100))
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, sub := range []string{"cmd", "empty"} {
+		if err := os.MkdirAll(filepath.Join(source, sub), 0755); err != nil {
+			t.Fatalf("MkdirAll returned unexpected error: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(source, "cmd", "main.go"), input, 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("main.go", filepath.Join(source, "cmd", "link.go")); err != nil {
+			t.Fatalf("Symlink returned unexpected error: %v", err)
+		}
+		if err := os.Chmod(filepath.Join(source, "empty"), 0755|os.ModeSetgid); err != nil {
+			t.Fatalf("Chmod returned unexpected error: %v", err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(source, "cmd"), mtime, mtime); err != nil {
+		t.Fatalf("Chtimes returned unexpected error: %v", err)
+	}
+
+	archivePath := filepath.Join(dir, "project.gca")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Create returned unexpected error: %v", err)
+	}
+	writer, _ := NewArchiveWriter(file)
+	if err := writer.AddPath(source, algorithms.LZ4Algorithm); err != nil {
+		t.Fatalf("AddPath returned unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+	file.Close()
+
+	archive, err := OpenArchive(archivePath)
+	if err != nil {
+		t.Fatalf("OpenArchive returned unexpected error: %v", err)
+	}
+	defer archive.Close()
+	var paths []string
+	for _, entry := range archive.Entries() {
+		paths = append(paths, entry.Path)
+	}
+	if got := strings.Join(paths, ","); got != "project,project/cmd,project/cmd/main.go,project/empty" {
+		t.Errorf("AddPath added %s", got)
+	}
+	if runtime.GOOS != "windows" && (len(writer.Skipped) != 1 || filepath.Base(writer.Skipped[0]) != "link.go") {
+		t.Errorf("AddPath skipped %q, want the symlink", writer.Skipped)
+	}
+
+	// Extracting recreates the tree, empty directories and mtimes included
+	output := filepath.Join(dir, "output")
+	if _, err := archive.Extract(output); err != nil {
+		t.Fatalf("Extract returned unexpected error: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(output, "project", "cmd", "main.go")); err != nil || !bytes.Equal(got, input) {
+		t.Errorf("Extract wrote %d bytes, %v, want %d", len(got), err, len(input))
+	}
+	if info, err := os.Stat(filepath.Join(output, "project", "empty")); err != nil || !info.IsDir() {
+		t.Errorf("Extract did not create the empty directory: %v", err)
+	} else if runtime.GOOS != "windows" && info.Mode() != os.ModeDir|0755 {
+		t.Errorf("restored directory mode = %v, want %v", info.Mode(), os.ModeDir|0755)
+	}
+	if info, err := os.Stat(filepath.Join(output, "project", "cmd")); err != nil || !info.ModTime().Equal(mtime) {
+		t.Errorf("Extract did not restore the mtime of the directory: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(filepath.Join(output, "project", "cmd", "main.go")); info.Mode().Perm() != 0644 {
+			t.Errorf("restored mode = %v, want %v", info.Mode().Perm(), os.FileMode(0644))
+		}
+	}
+}
+
+func TestArchiveSkipsItself(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+	archivePath := filepath.Join(dir, "self.gca")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Create returned unexpected error: %v", err)
+	}
+	defer file.Close()
+	info, _ := file.Stat()
+
+	writer, _ := NewArchiveWriter(file)
+	writer.Output = info
+	if err := writer.AddPath(dir, algorithms.LZ4Algorithm); err != nil {
+		t.Fatalf("AddPath returned unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned unexpected error: %v", err)
+	}
+	file.Close()
+	if len(writer.Skipped) != 1 || writer.Skipped[0] != archivePath {
+		t.Errorf("AddPath skipped %q, want the archive", writer.Skipped)
+	}
+
+	archive, err := OpenArchive(archivePath)
+	if err != nil {
+		t.Fatalf("OpenArchive returned unexpected error: %v", err)
+	}
+	defer archive.Close()
+	for _, entry := range archive.Entries() {
+		if strings.HasSuffix(entry.Path, "self.gca") {
+			t.Errorf("AddPath added the archive to itself as %s", entry.Path)
+		}
+	}
+}
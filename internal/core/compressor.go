@@ -52,6 +52,48 @@ func NewCompressor(algorithm int) (Compressor, error) { // Factory function for
 	switch algorithm {
 	case algorithms.RLEAlgorithm:
 		return algorithms.NewRLECompressor(), nil
+	case algorithms.HuffmanAlgorithm:
+		return algorithms.NewHuffmanCompressor(), nil
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSCompressor(), nil
+	case algorithms.GzipAlgorithm:
+		return algorithms.NewGzipCompressor(), nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibCompressor(), nil
+	case algorithms.DeflateAlgorithm:
+		return algorithms.NewDeflateCompressor(), nil
+	case algorithms.LZWAlgorithm:
+		return algorithms.NewLZWCompressor(), nil
+	case algorithms.BWTAlgorithm:
+		return algorithms.NewBWTCompressor(), nil
+	case algorithms.PackBitsAlgorithm:
+		return algorithms.NewPackBitsCompressor(), nil
+	case algorithms.BitRLEAlgorithm:
+		return algorithms.NewBitRLECompressor(), nil
+	case algorithms.RangeAlgorithm:
+		return algorithms.NewRangeCompressor(), nil
+	case algorithms.FSEAlgorithm:
+		return algorithms.NewFSECompressor(), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4Compressor(), nil
+	case algorithms.SnappyAlgorithm:
+		return algorithms.NewSnappyCompressor(), nil
+	case algorithms.XZAlgorithm:
+		return algorithms.NewXZCompressor(), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdCompressor(), nil
+	case algorithms.DeltaAlgorithm:
+		return algorithms.NewDeltaCompressor(), nil
+	case algorithms.XORAlgorithm:
+		return algorithms.NewXORCompressor(), nil
+	case algorithms.BCJX86Algorithm:
+		return algorithms.NewBCJX86Compressor(), nil
+	case algorithms.BCJARM64Algorithm:
+		return algorithms.NewBCJARM64Compressor(), nil
+	case algorithms.BCJARMThumbAlgorithm:
+		return algorithms.NewBCJARMThumbCompressor(), nil
+	case algorithms.PPMAlgorithm:
+		return algorithms.NewPPMCompressor(), nil
 	default:
 		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.Algorithms[algorithm] }
 	}
@@ -62,26 +104,171 @@ func NewDecompressor(algorithm int) (Decompressor, error) { // Factory function
 	switch algorithm {
 	case algorithms.RLEAlgorithm:
 		return algorithms.NewRLEDecompressor(), nil
+	case algorithms.HuffmanAlgorithm:
+		return algorithms.NewHuffmanDecompressor(), nil
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSDecompressor(), nil
+	case algorithms.GzipAlgorithm:
+		return algorithms.NewGzipDecompressor(), nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibDecompressor(), nil
+	case algorithms.DeflateAlgorithm:
+		return algorithms.NewDeflateDecompressor(), nil
+	case algorithms.LZWAlgorithm:
+		return algorithms.NewLZWDecompressor(), nil
+	case algorithms.BWTAlgorithm:
+		return algorithms.NewBWTDecompressor(), nil
+	case algorithms.PackBitsAlgorithm:
+		return algorithms.NewPackBitsDecompressor(), nil
+	case algorithms.BitRLEAlgorithm:
+		return algorithms.NewBitRLEDecompressor(), nil
+	case algorithms.RangeAlgorithm:
+		return algorithms.NewRangeDecompressor(), nil
+	case algorithms.FSEAlgorithm:
+		return algorithms.NewFSEDecompressor(), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4Decompressor(), nil
+	case algorithms.SnappyAlgorithm:
+		return algorithms.NewSnappyDecompressor(), nil
+	case algorithms.XZAlgorithm:
+		return algorithms.NewXZDecompressor(), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdDecompressor(), nil
+	case algorithms.DeltaAlgorithm:
+		return algorithms.NewDeltaDecompressor(), nil
+	case algorithms.XORAlgorithm:
+		return algorithms.NewXORDecompressor(), nil
+	case algorithms.BCJX86Algorithm:
+		return algorithms.NewBCJX86Compressor(), nil
+	case algorithms.BCJARM64Algorithm:
+		return algorithms.NewBCJARM64Compressor(), nil
+	case algorithms.BCJARMThumbAlgorithm:
+		return algorithms.NewBCJARMThumbCompressor(), nil
+	case algorithms.PPMAlgorithm:
+		return algorithms.NewPPMDecompressor(), nil
 	default:
 		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.Algorithms[algorithm] }
 	}
 }
 
 // NewFileToFileCompressor creates a new FileToFileCompressor based on the specified algorithm type, which is an int meant for the `Algorithms` array in `implemented.go`.
+// Algorithms with a standard format that starts with a signature (see algorithms.HasSignature) write that format as is, so other tools
+// can read it; the others write Containers, which record the algorithm. Either way, decompressing does not need to be told the algorithm.
 func NewFileToFileCompressor(algorithm int) (FileToFileCompressor, error) { // Factory function for file-to-file compressors
+	if algorithms.HasSignature(algorithm) {
+		return NewRawFileToFileCompressor(algorithm)
+	}
+	return NewContainer(algorithm)
+}
+
+// NewFileToFileDecompressor creates a new FileToFileDecompressor based on the specified algorithm type, which is an int meant for the `Algorithms` array in `implemented.go`.
+// It reads files written by NewFileToFileCompressor with the same algorithm (and files in that algorithm's standard format, if it has a signature), and rejects others with an ErrFormatMismatch.
+// Files that are neither containers nor in a detectable format are taken as the bare output of the algorithm, such as files written before containers.
+func NewFileToFileDecompressor(algorithm int) (FileToFileDecompressor, error) { // Factory function for file-to-file decompressors
+	return NewContainer(algorithm)
+}
+
+// NewRawFileToFileCompressor creates a FileToFileCompressor that writes the bare output of the algorithm, without a container (for instance so that `gunzip` can open it).
+func NewRawFileToFileCompressor(algorithm int) (FileToFileCompressor, error) { // Factory function for raw file-to-file compressors
 	switch algorithm {
 	case algorithms.RLEAlgorithm:
 		return algorithms.NewRLEFileToFileCompressor(), nil
+	case algorithms.HuffmanAlgorithm:
+		return algorithms.NewHuffmanCompressor(), nil
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSCompressor(), nil
+	case algorithms.GzipAlgorithm:
+		compressor := algorithms.NewGzipCompressor()
+		compressor.SaveName = SaveFileMetadata // The gzip header has fields for the name and mtime
+		return compressor, nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibCompressor(), nil
+	case algorithms.DeflateAlgorithm:
+		return algorithms.NewDeflateCompressor(), nil
+	case algorithms.LZWAlgorithm:
+		return algorithms.NewLZWCompressor(), nil
+	case algorithms.BWTAlgorithm:
+		return algorithms.NewBWTCompressor(), nil
+	case algorithms.PackBitsAlgorithm:
+		return algorithms.NewPackBitsCompressor(), nil
+	case algorithms.BitRLEAlgorithm:
+		return algorithms.NewBitRLECompressor(), nil
+	case algorithms.RangeAlgorithm:
+		return algorithms.NewRangeCompressor(), nil
+	case algorithms.FSEAlgorithm:
+		return algorithms.NewFSECompressor(), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4Compressor(), nil
+	case algorithms.SnappyAlgorithm:
+		return algorithms.NewSnappyCompressor(), nil
+	case algorithms.XZAlgorithm:
+		return algorithms.NewXZCompressor(), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdCompressor(), nil
+	case algorithms.DeltaAlgorithm:
+		return algorithms.NewDeltaCompressor(), nil
+	case algorithms.XORAlgorithm:
+		return algorithms.NewXORCompressor(), nil
+	case algorithms.BCJX86Algorithm:
+		return algorithms.NewBCJX86Compressor(), nil
+	case algorithms.BCJARM64Algorithm:
+		return algorithms.NewBCJARM64Compressor(), nil
+	case algorithms.BCJARMThumbAlgorithm:
+		return algorithms.NewBCJARMThumbCompressor(), nil
+	case algorithms.PPMAlgorithm:
+		return algorithms.NewPPMCompressor(), nil
 	default:
 		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.Algorithms[algorithm] }
 	}
 }
 
-// NewFileToFileDecompressor creates a new FileToFileDecompressor based on the specified algorithm type, which is an int meant for the `Algorithms` array in `implemented.go`.
-func NewFileToFileDecompressor(algorithm int) (FileToFileDecompressor, error) { // Factory function for file-to-file decompressors
+// NewRawFileToFileDecompressor creates a FileToFileDecompressor for the bare output of the algorithm, as written by NewRawFileToFileCompressor.
+func NewRawFileToFileDecompressor(algorithm int) (FileToFileDecompressor, error) { // Factory function for raw file-to-file decompressors
 	switch algorithm {
 	case algorithms.RLEAlgorithm:
 		return algorithms.NewRLEFileToFileDecompressor(), nil
+	case algorithms.HuffmanAlgorithm:
+		return algorithms.NewHuffmanDecompressor(), nil
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSDecompressor(), nil
+	case algorithms.GzipAlgorithm:
+		return algorithms.NewGzipDecompressor(), nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibDecompressor(), nil
+	case algorithms.DeflateAlgorithm:
+		return algorithms.NewDeflateDecompressor(), nil
+	case algorithms.LZWAlgorithm:
+		return algorithms.NewLZWDecompressor(), nil
+	case algorithms.BWTAlgorithm:
+		return algorithms.NewBWTDecompressor(), nil
+	case algorithms.PackBitsAlgorithm:
+		return algorithms.NewPackBitsDecompressor(), nil
+	case algorithms.BitRLEAlgorithm:
+		return algorithms.NewBitRLEDecompressor(), nil
+	case algorithms.RangeAlgorithm:
+		return algorithms.NewRangeDecompressor(), nil
+	case algorithms.FSEAlgorithm:
+		return algorithms.NewFSEDecompressor(), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4Decompressor(), nil
+	case algorithms.SnappyAlgorithm:
+		return algorithms.NewSnappyDecompressor(), nil
+	case algorithms.XZAlgorithm:
+		return algorithms.NewXZDecompressor(), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdDecompressor(), nil
+	case algorithms.DeltaAlgorithm:
+		return algorithms.NewDeltaDecompressor(), nil
+	case algorithms.XORAlgorithm:
+		return algorithms.NewXORDecompressor(), nil
+	case algorithms.BCJX86Algorithm:
+		return algorithms.NewBCJX86Compressor(), nil
+	case algorithms.BCJARM64Algorithm:
+		return algorithms.NewBCJARM64Compressor(), nil
+	case algorithms.BCJARMThumbAlgorithm:
+		return algorithms.NewBCJARMThumbCompressor(), nil
+	case algorithms.PPMAlgorithm:
+		return algorithms.NewPPMDecompressor(), nil
 	default:
 		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.Algorithms[algorithm] }
 	}
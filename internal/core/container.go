@@ -0,0 +1,316 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+const (
+	containerVersion    = 1                 // Format version written into containers
+	containerHeaderSize = 6                 // Magic, version, flags and number of stages, before the stages themselves
+	containerMaxStages  = pipelineMaxStages // Largest number of stages recorded in a container
+)
+
+const ( // Bits of the flags byte of a container
+	containerFlagIntegrity = 0x01 // The stages are followed by the length and checksum of the uncompressed data (see algorithms.Integrity)
+	containerFlagMetadata  = 0x02 // Then come the name, mode and mtime of the file the data was compressed from (see FileMetadata)
+	containerKnownFlags    = containerFlagIntegrity | containerFlagMetadata
+)
+
+var containerMagic = []byte{'G', 'C', 'Z'} // Start of a container, followed by the format version
+
+// ErrFormatMismatch is returned when data was not compressed with the algorithms it is decompressed with,
+// or when no algorithm was named and it is neither a container nor in a format whose algorithm can be detected.
+type ErrFormatMismatch struct {
+	Expected string // Algorithm (or pipeline spec) the data was decompressed with, "" if any would do
+	Found    string // Algorithm (or pipeline spec) recorded in the data, "" if none was found
+}
+
+// Format the ErrFormatMismatch error message.
+func (e *ErrFormatMismatch) Error() string {
+	switch {
+	case e.Found == "":
+		return "data is not a container and its format could not be detected"
+	default:
+		return fmt.Sprintf("data was compressed with %s, not %s", e.Found, e.Expected)
+	}
+}
+
+// Container wraps the output of one or more algorithms (the stages, applied in order like those of a Pipeline) in a
+// header that records them: the magic "GCZ", the format version, a flags byte, the number of stages and the algorithm
+// ID of every stage, followed by the length and checksum of the uncompressed data if the containerFlagIntegrity flag
+// is set, and by the FileMetadata of the input file if the containerFlagMetadata flag is set. Decompressing reads the stages from the header, so the algorithm never has to be named, rejects data
+// compressed with other algorithms with an ErrFormatMismatch, and corrupt data with an algorithms.ErrLengthMismatch
+// or algorithms.ErrChecksumMismatch. Data without a header is accepted if it starts with the signature of a standard
+// format (see algorithms.DetectAlgorithm), or else, if the Stages are set, decompressed as their bare output.
+type Container struct {
+	Stages          []int // Algorithms applied when compressing; when decompressing, the ones the data must record, or nil for any
+	Checksum        int   // Checksum stored when compressing (see algorithms.ChecksumCRC32); algorithms.ChecksumNone stores no length or checksum
+	SaveMetadata    bool  // Whether CompressFileToFile records the name, mode and mtime of the input file
+	RestoreMetadata bool  // Whether DecompressFileToFile restores the recorded name, mode and mtime (see FileMetadata.Restore)
+}
+
+// NewContainer creates a Container for the given algorithms, which are ints meant for the `Algorithms` array in `implemented.go`,
+// with the checksum selected by algorithms.ChecksumType and the metadata options SaveFileMetadata and RestoreFileMetadata.
+func NewContainer(stages ...int) (*Container, error) {
+	if len(stages) == 0 || len(stages) > containerMaxStages {
+		return nil, fmt.Errorf("container has %d stages, 1 to %d are supported", len(stages), containerMaxStages)
+	}
+	for _, stage := range stages {
+		if stage < 0 || stage >= len(algorithms.Algorithms) {
+			return nil, &ErrUnsupportedAlgorithmType { Algorithm: fmt.Sprintf("#%d", stage) }
+		}
+	}
+	return newFileContainer(stages), nil
+}
+
+// NewContainerDecompressor creates a Container that decompresses data made with any algorithm, read from the header.
+func NewContainerDecompressor() *Container {
+	return newFileContainer(nil)
+}
+
+// newFileContainer creates a Container for the stages with the options of the file-to-file compressors.
+func newFileContainer(stages []int) *Container {
+	return &Container { Stages: stages, Checksum: algorithms.ChecksumType, SaveMetadata: SaveFileMetadata, RestoreMetadata: RestoreFileMetadata }
+}
+
+// String returns the algorithm (or pipeline spec) of the container.
+func (c *Container) String() string {
+	return (&Pipeline { Stages: c.Stages }).String()
+}
+
+// Compress implements Compressor.
+func (c *Container) Compress(data []byte) ([]byte, error) {
+	return c.CompressWithMetadata(data, nil)
+}
+
+// CompressWithMetadata compresses data like Compress, recording `metadata` in the header unless it is nil.
+func (c *Container) CompressWithMetadata(data []byte, metadata *FileMetadata) ([]byte, error) {
+	if len(c.Stages) == 0 || len(c.Stages) > containerMaxStages {
+		return nil, fmt.Errorf("container has %d stages, 1 to %d are supported", len(c.Stages), containerMaxStages)
+	}
+
+	var integrity []byte
+	flags := byte(0)
+	if c.Checksum != algorithms.ChecksumNone {
+		record, err := algorithms.NewIntegrity(data, c.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		integrity = record.Append(nil)
+		flags |= containerFlagIntegrity
+	}
+	if metadata != nil {
+		flags |= containerFlagMetadata
+	}
+
+	data, err := (&Pipeline { Stages: c.Stages }).compressStages(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(containerMagic)
+	buffer.WriteByte(containerVersion)
+	buffer.WriteByte(flags)
+	buffer.Write(appendStages(nil, c.Stages))
+	buffer.Write(integrity)
+	if metadata != nil {
+		buffer.Write(metadata.append(nil))
+	}
+	buffer.Write(data)
+	return buffer.Bytes(), nil
+}
+
+// Decompress implements Decompressor. If the Stages of `c` are set, the data must record exactly those.
+func (c *Container) Decompress(data []byte) ([]byte, error) {
+	data, _, err := c.DecompressWithMetadata(data)
+	return data, err
+}
+
+// DecompressWithMetadata decompresses data like Decompress, also returning the FileMetadata recorded in the header, or in
+// the header of a bare gzip file (nil if there is none).
+func (c *Container) DecompressWithMetadata(data []byte) ([]byte, *FileMetadata, error) {
+	if bytes.HasPrefix(data, seekableMagic) {
+		output, err := (&SeekableCompressor { Stages: c.Stages }).Decompress(data)
+		return output, nil, err
+	}
+
+	var header containerHeader
+	bare := !bytes.HasPrefix(data, containerMagic)
+	if !bare {
+		var err error
+		if header, data, err = parseContainerHeader(data); err != nil {
+			return nil, nil, err
+		}
+	} else if alg := algorithms.DetectAlgorithm(data); alg >= 0 {
+		header.stages = []int{alg}
+	} else if c.Stages != nil {
+		header.stages = c.Stages // The bare output of the stages, such as files written before containers
+	} else {
+		return nil, nil, &ErrFormatMismatch{}
+	}
+	if err := checkStages(c.Stages, header.stages); err != nil {
+		return nil, nil, err
+	}
+	if bare && equalStages(header.stages, []int{algorithms.GzipAlgorithm}) {
+		output, gzipHeader, err := algorithms.GzipDecodeWithHeader(data) // gzip records the name and mtime itself
+		if err != nil {
+			return nil, nil, err
+		}
+		return output, gzipFileMetadata(gzipHeader), nil
+	}
+
+	data, err := (&Pipeline { Stages: header.stages }).decompressStages(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.integrity != nil {
+		if err := header.integrity.Verify(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, header.metadata, nil
+}
+
+// CompressFileToFile implements FileToFileCompressor, recording the name, mode and mtime of the input file if SaveMetadata is set.
+func (c *Container) CompressFileToFile(inputPath, outputPath string) error {
+	if !c.SaveMetadata {
+		return algorithms.TransformFile("ContainerCompressFile", inputPath, outputPath, c.Compress)
+	}
+	metadata, err := ReadFileMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+	return algorithms.TransformFile("ContainerCompressFile", inputPath, outputPath, func(data []byte) ([]byte, error) {
+		return c.CompressWithMetadata(data, metadata)
+	})
+}
+
+// DecompressFileToFile implements FileToFileDecompressor. If RestoreMetadata is set and the file records its metadata,
+// the output is written under the recorded name in the directory of `outputPath`, with the recorded mode and mtime;
+// bare files in a format other than gzip, which have nowhere to record them, are refused.
+func (c *Container) DecompressFileToFile(inputPath, outputPath string) error {
+	if isSeekableFile(inputPath) {
+		return (&SeekableCompressor { Stages: c.Stages }).DecompressFileToFile(inputPath, outputPath)
+	}
+	if !c.RestoreMetadata {
+		return algorithms.TransformFile("ContainerDecompressFile", inputPath, outputPath, c.Decompress)
+	}
+	input, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	if !bytes.HasPrefix(input, containerMagic) { // Of the bare formats, only gzip has room for a name
+		if alg := algorithms.DetectAlgorithm(input); alg >= 0 && alg != algorithms.GzipAlgorithm {
+			return fmt.Errorf("%s files record no name, mode or mtime to restore", algorithms.Algorithms[alg])
+		} else if alg < 0 && c.Stages != nil {
+			return fmt.Errorf("%s files without a container record no name, mode or mtime to restore", c)
+		}
+	}
+	output, metadata, err := c.DecompressWithMetadata(input)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return algorithms.WriteFile("ContainerDecompressFile", outputPath, output)
+	}
+	return metadata.Restore(filepath.Dir(outputPath), output)
+}
+
+// containerHeader is what the header of a container records.
+type containerHeader struct {
+	stages    []int
+	integrity *algorithms.Integrity // nil without the containerFlagIntegrity flag
+	metadata  *FileMetadata         // nil without the containerFlagMetadata flag
+}
+
+// parseContainerHeader returns the header of a container and the data after it.
+func parseContainerHeader(data []byte) (containerHeader, []byte, error) {
+	var header containerHeader
+	if len(data) < containerHeaderSize {
+		return header, nil, fmt.Errorf("malformed container: truncated header")
+	}
+	if version := data[len(containerMagic)]; version != containerVersion {
+		return header, nil, fmt.Errorf("unsupported container version %d (only version %d is supported)", version, containerVersion)
+	}
+	flags := data[len(containerMagic)+1]
+	if flags&^containerKnownFlags != 0 {
+		return header, nil, fmt.Errorf("malformed container: unknown flags 0x%02X", flags)
+	}
+	var err error
+	if header.stages, data, err = readStages(data[containerHeaderSize-1:]); err != nil {
+		return header, nil, fmt.Errorf("malformed container: %w", err)
+	}
+
+	if flags&containerFlagIntegrity != 0 {
+		integrity, n, err := algorithms.ParseIntegrity(data)
+		if err != nil {
+			return header, nil, fmt.Errorf("malformed container: %w", err)
+		}
+		header.integrity, data = &integrity, data[n:]
+	}
+	if flags&containerFlagMetadata != 0 {
+		metadata, n, err := parseFileMetadata(data)
+		if err != nil {
+			return header, nil, fmt.Errorf("malformed container: %w", err)
+		}
+		header.metadata, data = metadata, data[n:]
+	}
+	return header, data, nil
+}
+
+// appendStages appends the number of stages and the algorithm ID of every stage to `dst`.
+func appendStages(dst []byte, stages []int) []byte {
+	dst = append(dst, byte(len(stages)))
+	for _, stage := range stages {
+		dst = append(dst, byte(stage))
+	}
+	return dst
+}
+
+// readStages reads the stages written by appendStages, returning them and the data after them.
+func readStages(data []byte) ([]int, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("missing number of stages")
+	}
+	count := int(data[0])
+	data = data[1:]
+	if count == 0 || count > containerMaxStages || count > len(data) {
+		return nil, nil, fmt.Errorf("invalid number of stages %d", count)
+	}
+
+	stages := make([]int, count)
+	for i := range stages {
+		stages[i] = int(data[i])
+		if stages[i] >= len(algorithms.Algorithms) {
+			return nil, nil, fmt.Errorf("unknown algorithm ID %d", stages[i])
+		}
+	}
+	return stages, data[count:], nil
+}
+
+// checkStages returns an ErrFormatMismatch unless the data records the `expected` stages (any will do if they are nil).
+func checkStages(expected []int, found []int) error {
+	if expected != nil && !equalStages(expected, found) {
+		return &ErrFormatMismatch { Expected: (&Pipeline { Stages: expected }).String(), Found: (&Pipeline { Stages: found }).String() }
+	}
+	return nil
+}
+
+// equalStages reports whether both lists name the same algorithms in the same order.
+func equalStages(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+func TestContainerRoundTrip(t *testing.T) {
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+
+	for algorithm := range algorithms.Algorithms {
+		name := algorithms.GetAlgorithmName(algorithm)
+		t.Run(name, func(t *testing.T) {
+			container, err := NewContainer(algorithm)
+			if err != nil {
+				t.Fatalf("NewContainer returned unexpected error: %v", err)
+			}
+			compressed, err := container.Compress(text)
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(compressed, containerMagic) || compressed[containerHeaderSize] != byte(algorithm) {
+				t.Errorf("Compress output does not start with a header for %s: % x", name, compressed[:containerHeaderSize+1])
+			}
+
+			// Both the decompressor for the algorithm and the detecting one read it
+			for _, decompressor := range []*Container{container, NewContainerDecompressor()} {
+				got, err := decompressor.Decompress(compressed)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, text) {
+					t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(text))
+				}
+			}
+		})
+	}
+}
+
+func TestContainerPipeline(t *testing.T) {
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+	container, err := NewContainer(algorithms.DeltaAlgorithm, algorithms.RLEAlgorithm, algorithms.HuffmanAlgorithm)
+	if err != nil {
+		t.Fatalf("NewContainer returned unexpected error: %v", err)
+	}
+	if got := container.String(); got != "delta+rle+huffman" {
+		t.Errorf("String() = %q, want %q", got, "delta+rle+huffman")
+	}
+	compressed, err := container.Compress(text)
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	got, err := NewContainerDecompressor().Decompress(compressed)
+	if err != nil {
+		t.Fatalf("Decompress returned unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, text) {
+		t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(text))
+	}
+}
+
+func TestContainerDetectsStandardFormats(t *testing.T) {
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+
+	for _, algorithm := range []int{algorithms.GzipAlgorithm, algorithms.ZlibAlgorithm, algorithms.LZWAlgorithm, algorithms.LZ4Algorithm, algorithms.SnappyAlgorithm, algorithms.XZAlgorithm, algorithms.ZstdAlgorithm} {
+		name := algorithms.GetAlgorithmName(algorithm)
+		t.Run(name, func(t *testing.T) {
+			compressor, err := NewCompressor(algorithm)
+			if err != nil {
+				t.Fatalf("NewCompressor returned unexpected error: %v", err)
+			}
+			compressed, err := compressor.Compress(text) // No container
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+			got, err := NewContainerDecompressor().Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, text) {
+				t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(text))
+			}
+		})
+	}
+}
+
+func TestContainerMismatch(t *testing.T) {
+	huffman, _ := NewContainer(algorithms.HuffmanAlgorithm)
+	compressed, err := huffman.Compress([]byte("aaaaabbbbbccccc"))
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	gzipped, err := algorithms.Gzip([]byte("aaaaabbbbbccccc"), algorithms.DeflateLevel)
+	if err != nil {
+		t.Fatalf("Gzip returned unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		stages   []int
+		input    []byte
+		expected string
+		found    string
+	} {
+		{name: "other algorithm", stages: []int{algorithms.RLEAlgorithm}, input: compressed, expected: "rle", found: "huffman"},
+		{name: "other pipeline", stages: []int{algorithms.DeltaAlgorithm, algorithms.HuffmanAlgorithm}, input: compressed, expected: "delta+huffman", found: "huffman"},
+		{name: "detected format", stages: []int{algorithms.ZstdAlgorithm}, input: gzipped, expected: "zstd", found: "gzip"},
+		{name: "plain data, any algorithm", input: []byte("not compressed at all")},
+		{name: "empty data, any algorithm", input: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mismatch *ErrFormatMismatch
+			_, err := (&Container { Stages: tt.stages }).Decompress(tt.input)
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("Decompress returned %v, want an ErrFormatMismatch", err)
+			}
+			if mismatch.Expected != tt.expected || mismatch.Found != tt.found {
+				t.Errorf("Decompress returned ErrFormatMismatch{%q, %q}, want {%q, %q}", mismatch.Expected, mismatch.Found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestContainerBareData(t *testing.T) {
+	input := []byte("aaaaabbbbbccccc")
+	legacy, err := algorithms.Rle(input) // The format of .rle files written before containers
+	if err != nil {
+		t.Fatalf("Rle returned unexpected error: %v", err)
+	}
+
+	// With the algorithm named, data that is neither a container nor detectable is taken as its bare output
+	container, _ := NewContainer(algorithms.RLEAlgorithm)
+	if got, err := container.Decompress(legacy); err != nil || !bytes.Equal(got, input) {
+		t.Errorf("Decompress of bare rle data = %q, %v, want %q", got, err, input)
+	}
+	if got, err := container.Decompress(nil); err != nil || len(got) != 0 {
+		t.Errorf("Decompress of empty data = %q, %v, want nothing", got, err)
+	}
+	if _, err := container.Decompress([]byte("not compressed at all")); err == nil || errors.As(err, new(*ErrFormatMismatch)) {
+		t.Errorf("Decompress of plain data returned %v, want an rle error", err)
+	}
+
+	// Without one, it is refused
+	if _, err := NewContainerDecompressor().Decompress(legacy); !errors.As(err, new(*ErrFormatMismatch)) {
+		t.Errorf("Decompress with any algorithm returned %v, want an ErrFormatMismatch", err)
+	}
+}
+
+func TestContainerInvalid(t *testing.T) {
+	if _, err := NewContainer(); err == nil {
+		t.Errorf("NewContainer without stages expected an error")
+	}
+	if _, err := NewContainer(len(algorithms.Algorithms)); !errors.As(err, new(*ErrUnsupportedAlgorithmType)) {
+		t.Errorf("NewContainer with an unknown algorithm returned %v, want an ErrUnsupportedAlgorithmType", err)
+	}
+
+	container, _ := NewContainer(algorithms.RLEAlgorithm)
+	compressed, err := container.Compress([]byte("aaaaabbbbb"))
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	malformed := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "truncated header", input: compressed[:containerHeaderSize-1]},
+		{name: "missing stages", input: compressed[:containerHeaderSize]},
+		{name: "unknown version", input: append([]byte{'G', 'C', 'Z', 9}, compressed[len(containerMagic)+1:]...)},
+		{name: "unknown flags", input: append([]byte{'G', 'C', 'Z', containerVersion, 0x80}, compressed[len(containerMagic)+2:]...)},
+		{name: "no stages", input: append([]byte{'G', 'C', 'Z', containerVersion, 0, 0}, compressed[containerHeaderSize:]...)},
+		{name: "unknown algorithm", input: append([]byte{'G', 'C', 'Z', containerVersion, 0, 1, 0xFF}, compressed[containerHeaderSize+1:]...)},
+	}
+
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewContainerDecompressor().Decompress(tt.input); err == nil {
+				t.Errorf("Decompress expected an error")
+			}
+		})
+	}
+}
+
+func TestFileToFileContainer(t *testing.T) {
+	dir := t.TempDir()
+	input := []byte(strings.Repeat("aaaaaaaaaabbbbbbbbbb", 50))
+	inputPath := filepath.Join(dir, "input.txt")
+	compressedPath := filepath.Join(dir, "input.rle")
+	outputPath := filepath.Join(dir, "output.txt")
+	if err := os.WriteFile(inputPath, input, 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+
+	compressor, err := NewFileToFileCompressor(algorithms.RLEAlgorithm)
+	if err != nil {
+		t.Fatalf("NewFileToFileCompressor returned unexpected error: %v", err)
+	}
+	if err := compressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+
+	// The matching decompressor reads the file, another algorithm's refuses it
+	decompressor, _ := NewFileToFileDecompressor(algorithms.RLEAlgorithm)
+	if err := decompressor.DecompressFileToFile(compressedPath, outputPath); err != nil {
+		t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(outputPath); !bytes.Equal(got, input) {
+		t.Errorf("DecompressFileToFile wrote %q, want %q", got, input)
+	}
+	other, _ := NewFileToFileDecompressor(algorithms.PackBitsAlgorithm)
+	if err := other.DecompressFileToFile(compressedPath, outputPath); !errors.As(err, new(*ErrFormatMismatch)) {
+		t.Errorf("DecompressFileToFile with packbits returned %v, want an ErrFormatMismatch", err)
+	}
+
+	// A raw file (like the .rle files written before containers) needs the algorithm named, the detecting one refuses it
+	raw, _ := NewRawFileToFileCompressor(algorithms.RLEAlgorithm)
+	if err := raw.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+	if err := decompressor.DecompressFileToFile(compressedPath, outputPath); err != nil {
+		t.Fatalf("DecompressFileToFile of a raw rle file returned unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(outputPath); !bytes.Equal(got, input) {
+		t.Errorf("DecompressFileToFile of a raw rle file wrote %q, want %q", got, input)
+	}
+	if err := NewContainerDecompressor().DecompressFileToFile(compressedPath, outputPath); !errors.As(err, new(*ErrFormatMismatch)) {
+		t.Errorf("DecompressFileToFile of a raw rle file returned %v, want an ErrFormatMismatch", err)
+	}
+
+	// Formats with a signature are written bare, so other tools can read them, and are still detected
+	gzipCompressor, _ := NewFileToFileCompressor(algorithms.GzipAlgorithm)
+	if err := gzipCompressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(compressedPath); !bytes.HasPrefix(got, []byte{0x1F, 0x8B}) {
+		t.Errorf("gzip CompressFileToFile wrote % .4x, want a bare gzip member", got)
+	}
+	gzipDecompressor, _ := NewFileToFileDecompressor(algorithms.GzipAlgorithm)
+	for _, decompressor := range []FileToFileDecompressor{gzipDecompressor, NewContainerDecompressor()} {
+		if err := decompressor.DecompressFileToFile(compressedPath, outputPath); err != nil {
+			t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+		}
+		if got, _ := os.ReadFile(outputPath); !bytes.Equal(got, input) {
+			t.Errorf("DecompressFileToFile wrote %q, want %q", got, input)
+		}
+	}
+}
+
+func TestContainerIntegrity(t *testing.T) {
+	input := []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	for _, checksumType := range []int{algorithms.ChecksumCRC32, algorithms.ChecksumCRC32C} {
+		t.Run(algorithms.ChecksumNames[checksumType], func(t *testing.T) {
+			container := &Container { Stages: []int{algorithms.PackBitsAlgorithm}, Checksum: checksumType }
+			compressed, err := container.Compress(input)
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+			if compressed[len(containerMagic)+1]&containerFlagIntegrity == 0 {
+				t.Errorf("Compress did not set the integrity flag")
+			}
+			if got, err := NewContainerDecompressor().Decompress(compressed); err != nil || !bytes.Equal(got, input) {
+				t.Fatalf("Decompress = %q, %v, want %q", got, err, input)
+			}
+
+			// PackBits decodes a changed literal without complaint, so only the checksum catches it
+			corrupt := append([]byte(nil), compressed...)
+			corrupt[len(corrupt)-1] ^= 0x20
+			if _, err := NewContainerDecompressor().Decompress(corrupt); !errors.Is(err, &algorithms.ErrChecksumMismatch{}) {
+				t.Errorf("Decompress of a changed literal returned %v, want an ErrChecksumMismatch", err)
+			}
+
+			// A literal run that lost its last byte makes the length wrong
+			headerSize := containerHeaderSize + 1 + 6 // One stage, then a one byte length, the checksum type and the checksum
+			shorter, _ := container.Compress(input[:len(input)-1])
+			corrupt = append(compressed[:headerSize:headerSize], shorter[headerSize:]...)
+			if _, err := NewContainerDecompressor().Decompress(corrupt); !errors.Is(err, &algorithms.ErrLengthMismatch{}) {
+				t.Errorf("Decompress of a shorter literal run returned %v, want an ErrLengthMismatch", err)
+			}
+		})
+	}
+
+	plain := &Container { Stages: []int{algorithms.PackBitsAlgorithm}, Checksum: algorithms.ChecksumNone }
+	compressed, err := plain.Compress(input)
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	if compressed[len(containerMagic)+1] != 0 {
+		t.Errorf("Compress without a checksum set flags 0x%02X", compressed[len(containerMagic)+1])
+	}
+	if got, err := NewContainerDecompressor().Decompress(compressed); err != nil || !bytes.Equal(got, input) {
+		t.Errorf("Decompress = %q, %v, want %q", got, err, input)
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+import "github.com/superiden3/go_compress/internal/core/algorithms"
+
+// NewCompressorWithDictionary creates a compressor for `algorithm` (an int meant for the `Algorithms` array in
+// `implemented.go`) that uses a preset dictionary. Only the algorithms for which algorithms.SupportsDictionary
+// is true take one.
+func NewCompressorWithDictionary(algorithm int, dictionary []byte) (GeneralCompressor, error) {
+	switch algorithm {
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSCompressorWithDictionary(dictionary), nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibCompressorWithDictionary(dictionary), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4CompressorWithDictionary(dictionary), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdCompressorWithDictionary(dictionary), nil
+	default:
+		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.GetAlgorithmName(algorithm) + " (no dictionary support)" }
+	}
+}
+
+// NewDecompressorWithDictionary creates the decompressor for the output of NewCompressorWithDictionary with the same
+// `algorithm` and `dictionary`. Decompressing data that was compressed with another dictionary fails with an
+// algorithms.ErrDictionaryMismatch.
+func NewDecompressorWithDictionary(algorithm int, dictionary []byte) (GeneralDecompressor, error) {
+	switch algorithm {
+	case algorithms.LZSSAlgorithm:
+		return algorithms.NewLZSSCompressorWithDictionary(dictionary), nil
+	case algorithms.ZlibAlgorithm:
+		return algorithms.NewZlibCompressorWithDictionary(dictionary), nil
+	case algorithms.LZ4Algorithm:
+		return algorithms.NewLZ4CompressorWithDictionary(dictionary), nil
+	case algorithms.ZstdAlgorithm:
+		return algorithms.NewZstdCompressorWithDictionary(dictionary), nil
+	default:
+		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.GetAlgorithmName(algorithm) + " (no dictionary support)" }
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+func TestDictionaryCompressors(t *testing.T) {
+	// A trained dictionary is in the zstd format, so zstd records its ID as well
+	var samples [][]byte
+	for _, name := range []string{"ada", "alan", "grace", "edsger", "barbara", "donald", "ken", "dennis"} {
+		samples = append(samples, []byte(`{"name":"`+name+`","email":"`+name+`@example.com","active":true,"tags":["admin","staff"]}`))
+	}
+	dictionary, err := algorithms.TrainDictionary(samples, 1024)
+	if err != nil {
+		t.Fatalf("TrainDictionary returned unexpected error: %v", err)
+	}
+	record := []byte(`{"name":"ada","email":"ada@example.com","active":true,"tags":["admin","staff"]}`)
+
+	for _, algorithm := range []int{algorithms.LZSSAlgorithm, algorithms.ZlibAlgorithm, algorithms.LZ4Algorithm, algorithms.ZstdAlgorithm} {
+		name := algorithms.GetAlgorithmName(algorithm)
+		t.Run(name, func(t *testing.T) {
+			compressor, err := NewCompressorWithDictionary(algorithm, dictionary)
+			if err != nil {
+				t.Fatalf("NewCompressorWithDictionary returned unexpected error: %v", err)
+			}
+			compressed, err := compressor.Compress(record)
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+
+			decompressor, err := NewDecompressorWithDictionary(algorithm, dictionary)
+			if err != nil {
+				t.Fatalf("NewDecompressorWithDictionary returned unexpected error: %v", err)
+			}
+			got, err := decompressor.Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, record) {
+				t.Errorf("Decompress(Compress(x)) = %q, want %q", got, record)
+			}
+
+			// The plain decompressor has no dictionary
+			plain, err := NewDecompressor(algorithm)
+			if err != nil {
+				t.Fatalf("NewDecompressor returned unexpected error: %v", err)
+			}
+			if _, err := plain.Decompress(compressed); !errors.As(err, new(*algorithms.ErrDictionaryMismatch)) {
+				t.Errorf("Decompress without the dictionary returned %v, want an ErrDictionaryMismatch", err)
+			}
+		})
+	}
+
+	if _, err := NewCompressorWithDictionary(algorithms.HuffmanAlgorithm, dictionary); err == nil {
+		t.Errorf("NewCompressorWithDictionary(huffman) expected an error")
+	}
+	if _, err := NewDecompressorWithDictionary(algorithms.GzipAlgorithm, dictionary); err == nil {
+		t.Errorf("NewDecompressorWithDictionary(gzip) expected an error")
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// FilteredCompressor runs the data through a filter (see algorithms.IsFilter) before compressing it with another algorithm.
+type FilteredCompressor struct {
+	filter     Compressor
+	compressor Compressor
+	stages     []int // Filter and algorithm, recorded in the container of the files
+}
+
+// Compress implements Compressor.
+func (f *FilteredCompressor) Compress(data []byte) ([]byte, error) {
+	filtered, err := f.filter.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.compressor.Compress(filtered)
+}
+
+// CompressFileToFile implements FileToFileCompressor, writing a Container that records the filter and the algorithm.
+func (f *FilteredCompressor) CompressFileToFile(inputPath, outputPath string) error {
+	return newFileContainer(f.stages).CompressFileToFile(inputPath, outputPath)
+}
+
+// FilteredDecompressor decompresses data written by a FilteredCompressor, undoing the filter after the algorithm.
+type FilteredDecompressor struct {
+	decompressor Decompressor
+	filter       Decompressor
+	stages       []int // Filter and algorithm, which the container of the files must record
+}
+
+// Decompress implements Decompressor.
+func (f *FilteredDecompressor) Decompress(data []byte) ([]byte, error) {
+	filtered, err := f.decompressor.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.filter.Decompress(filtered)
+}
+
+// DecompressFileToFile implements FileToFileDecompressor, reading a Container that must record the same filter and algorithm.
+func (f *FilteredDecompressor) DecompressFileToFile(inputPath, outputPath string) error {
+	return newFileContainer(f.stages).DecompressFileToFile(inputPath, outputPath)
+}
+
+// NewFilteredCompressor creates a compressor that runs the data through `filter` and then compresses it with `algorithm`.
+// Both are ints meant for the `Algorithms` array in `implemented.go`, and `filter` must be a filter such as delta or xor.
+func NewFilteredCompressor(filter int, algorithm int) (GeneralCompressor, error) {
+	if filter < 0 || filter >= len(algorithms.Algorithms) || !algorithms.IsFilter(filter) {
+		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.GetAlgorithmName(filter) + " (not a filter)" }
+	}
+	f, err := NewCompressor(filter)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCompressor(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &FilteredCompressor { filter: f, compressor: c, stages: []int{filter, algorithm} }, nil
+}
+
+// NewFilteredDecompressor creates the decompressor for the output of NewFilteredCompressor with the same `filter` and `algorithm`.
+func NewFilteredDecompressor(filter int, algorithm int) (GeneralDecompressor, error) {
+	if filter < 0 || filter >= len(algorithms.Algorithms) || !algorithms.IsFilter(filter) {
+		return nil, &ErrUnsupportedAlgorithmType { Algorithm: algorithms.GetAlgorithmName(filter) + " (not a filter)" }
+	}
+	f, err := NewDecompressor(filter)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDecompressor(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &FilteredDecompressor { decompressor: d, filter: f, stages: []int{filter, algorithm} }, nil
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// Options of the file-to-file compressors and decompressors, which behave like gzip's -N and -n flags
+var SaveFileMetadata = true     // Whether compressing records the name, mode and mtime of the input file
+var RestoreFileMetadata = false // Whether decompressing restores the recorded name, mode and mtime
+
+const (
+	fileMetadataMaxName  = 4096                                                         // Longest name accepted in a header
+	fileMetadataModeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky // Mode bits that are recorded
+)
+
+// FileMetadata is what a container records about the file it was compressed from. It is stored as the uvarint length
+// of the name and the name, the Unix mode bits (uvarint) and the mtime (varint seconds and uvarint nanoseconds since 1970).
+type FileMetadata struct {
+	Name    string      // Base name of the file
+	Mode    os.FileMode // Permission bits, with the setuid, setgid and sticky bits; 0 if not recorded (gzip headers have none)
+	ModTime time.Time   // Modification time; the zero time if not recorded
+}
+
+// ReadFileMetadata returns the FileMetadata of the file at `path`.
+func ReadFileMetadata(path string) (*FileMetadata, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	return &FileMetadata { Name: filepath.Base(path), Mode: info.Mode() & fileMetadataModeMask, ModTime: info.ModTime() }, nil
+}
+
+// gzipFileMetadata returns the FileMetadata recorded in the header of a gzip file (the name and mtime, as `gzip -N`
+// stores them), or nil if it has no name that can be restored.
+func gzipFileMetadata(header gzip.Header) *FileMetadata {
+	name := header.Name
+	if i := strings.LastIndexAny(name, "/\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, 0) {
+		return nil
+	}
+	return &FileMetadata { Name: name, ModTime: header.ModTime }
+}
+
+// Restore writes `data` to a file with the recorded name in `dir`, and gives it the recorded permission bits and mtime
+// (if they were recorded). The setuid, setgid and sticky bits come from untrusted data, so they are never restored.
+func (m *FileMetadata) Restore(dir string, data []byte) error {
+	path := filepath.Join(dir, m.Name)
+	if err := algorithms.WriteFile("FileMetadataRestore", path, data); err != nil {
+		return err
+	}
+	if m.Mode != 0 {
+		if err := os.Chmod(path, m.Mode.Perm()); err != nil {
+			return fmt.Errorf("failed to restore the mode of the output file: %w", err)
+		}
+	}
+	if !m.ModTime.IsZero() {
+		if err := os.Chtimes(path, m.ModTime, m.ModTime); err != nil {
+			return fmt.Errorf("failed to restore the mtime of the output file: %w", err)
+		}
+	}
+	return nil
+}
+
+// append appends the stored form of the FileMetadata to `dst`.
+func (m *FileMetadata) append(dst []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(m.Name)))
+	dst = append(dst, m.Name...)
+	dst = binary.AppendUvarint(dst, uint64(unixMode(m.Mode)))
+	dst = binary.AppendVarint(dst, m.ModTime.Unix())
+	return binary.AppendUvarint(dst, uint64(m.ModTime.Nanosecond()))
+}
+
+// parseFileMetadata reads a FileMetadata from the start of `data`, returning it and the number of bytes it took.
+// Names that are not a plain base name (such as "../x") are rejected, so restoring cannot write outside the directory.
+func parseFileMetadata(data []byte) (*FileMetadata, int, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > fileMetadataMaxName || size > uint64(len(data)-n) {
+		return nil, 0, fmt.Errorf("malformed file metadata: invalid name length")
+	}
+	name := string(data[n : n+int(size)])
+	pos := n + int(size)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return nil, 0, fmt.Errorf("malformed file metadata: invalid name %q", name)
+	}
+
+	mode, n := binary.Uvarint(data[pos:])
+	if n <= 0 || mode > 0o7777 {
+		return nil, 0, fmt.Errorf("malformed file metadata: invalid mode")
+	}
+	pos += n
+	seconds, n := binary.Varint(data[pos:])
+	if n <= 0 {
+		return nil, 0, fmt.Errorf("malformed file metadata: invalid mtime")
+	}
+	pos += n
+	nanoseconds, n := binary.Uvarint(data[pos:])
+	if n <= 0 || nanoseconds >= uint64(time.Second) {
+		return nil, 0, fmt.Errorf("malformed file metadata: invalid mtime")
+	}
+	pos += n
+
+	return &FileMetadata { Name: name, Mode: fileMode(uint32(mode)), ModTime: time.Unix(seconds, int64(nanoseconds)) }, pos, nil
+}
+
+// unixMode turns an os.FileMode into the mode bits of Unix (with setuid as 04000, setgid as 02000 and sticky as 01000).
+func unixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+// fileMode turns Unix mode bits into an os.FileMode.
+func fileMode(bits uint32) os.FileMode {
+	mode := os.FileMode(bits) & os.ModePerm
+	if bits&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
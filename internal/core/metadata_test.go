@@ -0,0 +1,203 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+func TestFileMetadataRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata FileMetadata
+	} {
+		{name: "plain file", metadata: FileMetadata { Name: "notes.txt", Mode: 0644, ModTime: time.Unix(1700000000, 123456789) }},
+		{name: "special bits", metadata: FileMetadata { Name: "tool", Mode: 0755 | os.ModeSetuid | os.ModeSetgid | os.ModeSticky, ModTime: time.Unix(0, 0) }},
+		{name: "before 1970", metadata: FileMetadata { Name: "old ñame.dat", Mode: 0400, ModTime: time.Unix(-86400, 5) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &Container { Stages: []int{algorithms.HuffmanAlgorithm}, Checksum: algorithms.ChecksumCRC32 }
+			compressed, err := container.CompressWithMetadata([]byte("some data, some data"), &tt.metadata)
+			if err != nil {
+				t.Fatalf("CompressWithMetadata returned unexpected error: %v", err)
+			}
+			got, metadata, err := NewContainerDecompressor().DecompressWithMetadata(compressed)
+			if err != nil {
+				t.Fatalf("DecompressWithMetadata returned unexpected error: %v", err)
+			}
+			if string(got) != "some data, some data" {
+				t.Errorf("DecompressWithMetadata returned %q, want %q", got, "some data, some data")
+			}
+			if metadata == nil || metadata.Name != tt.metadata.Name || metadata.Mode != tt.metadata.Mode || !metadata.ModTime.Equal(tt.metadata.ModTime) {
+				t.Errorf("DecompressWithMetadata returned metadata %+v, want %+v", metadata, tt.metadata)
+			}
+		})
+	}
+
+	// Without metadata there is none to return
+	compressed, _ := (&Container { Stages: []int{algorithms.HuffmanAlgorithm} }).Compress([]byte("data"))
+	if _, metadata, err := NewContainerDecompressor().DecompressWithMetadata(compressed); err != nil || metadata != nil {
+		t.Errorf("DecompressWithMetadata = %+v, %v, want no metadata", metadata, err)
+	}
+}
+
+func TestFileMetadataInvalidNames(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "../evil", "dir/file", `dir\file`, "nul\x00"} {
+		stored := (&FileMetadata { Name: name, Mode: 0644, ModTime: time.Unix(0, 0) }).append(nil)
+		if _, _, err := parseFileMetadata(stored); err == nil {
+			t.Errorf("parseFileMetadata with the name %q expected an error", name)
+		}
+	}
+
+	stored := (&FileMetadata { Name: "file", Mode: 0644, ModTime: time.Unix(0, 0) }).append(nil)
+	for i := 0; i < len(stored); i++ {
+		if _, _, err := parseFileMetadata(stored[:i]); err == nil {
+			t.Errorf("parseFileMetadata of %d of %d bytes expected an error", i, len(stored))
+		}
+	}
+}
+
+func TestFileToFileMetadata(t *testing.T) {
+	dir := t.TempDir()
+	input := []byte("aaaaaaaaaabbbbbbbbbbcccccccccc")
+	inputPath := filepath.Join(dir, "original.txt")
+	compressedPath := filepath.Join(dir, "compressed.gcz")
+	if err := os.WriteFile(inputPath, input, 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if err := os.Chmod(inputPath, 0751); err != nil {
+		t.Fatalf("Chmod returned unexpected error: %v", err)
+	}
+	if err := os.Chtimes(inputPath, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes returned unexpected error: %v", err)
+	}
+
+	compressor := &Container { Stages: []int{algorithms.RLEAlgorithm}, SaveMetadata: true }
+	if err := compressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+
+	// Without restoring, the output goes where it is told to
+	plainPath := filepath.Join(dir, "plain.txt")
+	if err := (&Container{}).DecompressFileToFile(compressedPath, plainPath); err != nil {
+		t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(plainPath); !bytes.Equal(got, input) {
+		t.Errorf("DecompressFileToFile wrote %q, want %q", got, input)
+	}
+
+	// Restoring writes the recorded name, mode and mtime into the directory of the output path
+	restoreDir := filepath.Join(dir, "restored")
+	if err := os.Mkdir(restoreDir, 0755); err != nil {
+		t.Fatalf("Mkdir returned unexpected error: %v", err)
+	}
+	if err := (&Container { RestoreMetadata: true }).DecompressFileToFile(compressedPath, filepath.Join(restoreDir, "ignored.txt")); err != nil {
+		t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+	}
+	restoredPath := filepath.Join(restoreDir, "original.txt")
+	if got, err := os.ReadFile(restoredPath); err != nil || !bytes.Equal(got, input) {
+		t.Fatalf("DecompressFileToFile wrote %q, %v to %s, want %q", got, err, restoredPath, input)
+	}
+	info, err := os.Stat(restoredPath)
+	if err != nil {
+		t.Fatalf("Stat returned unexpected error: %v", err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("restored mtime = %v, want %v", info.ModTime(), mtime)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0751 {
+		t.Errorf("restored mode = %v, want %v", info.Mode().Perm(), os.FileMode(0751))
+	}
+}
+
+func TestFileMetadataRestoreSpecialBits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no setuid, setgid or sticky bits")
+	}
+	dir := t.TempDir()
+	metadata := &FileMetadata { Name: "tool", Mode: 0750 | os.ModeSetuid | os.ModeSetgid | os.ModeSticky, ModTime: time.Unix(1700000000, 0) }
+	if err := metadata.Restore(dir, []byte("#!/bin/sh\n")); err != nil {
+		t.Fatalf("Restore returned unexpected error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "tool"))
+	if err != nil {
+		t.Fatalf("Stat returned unexpected error: %v", err)
+	}
+	if info.Mode() != 0750 {
+		t.Errorf("restored mode = %v, want %v", info.Mode(), os.FileMode(0750))
+	}
+}
+
+func TestFileToFileGzipMetadata(t *testing.T) {
+	dir := t.TempDir()
+	input := []byte("aaaaaaaaaabbbbbbbbbbcccccccccc")
+	inputPath := filepath.Join(dir, "original.txt")
+	compressedPath := filepath.Join(dir, "compressed.gz")
+	if err := os.WriteFile(inputPath, input, 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if err := os.Chtimes(inputPath, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes returned unexpected error: %v", err)
+	}
+
+	// Bare gzip files keep the name and mtime in the gzip header, like gzip -N
+	compressor, _ := NewFileToFileCompressor(algorithms.GzipAlgorithm)
+	if err := compressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+	restoreDir := filepath.Join(dir, "restored")
+	if err := os.Mkdir(restoreDir, 0755); err != nil {
+		t.Fatalf("Mkdir returned unexpected error: %v", err)
+	}
+	if err := (&Container { RestoreMetadata: true }).DecompressFileToFile(compressedPath, filepath.Join(restoreDir, "ignored.txt")); err != nil {
+		t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+	}
+	restoredPath := filepath.Join(restoreDir, "original.txt")
+	if got, err := os.ReadFile(restoredPath); err != nil || !bytes.Equal(got, input) {
+		t.Fatalf("DecompressFileToFile wrote %q, %v to %s, want %q", got, err, restoredPath, input)
+	}
+	if info, err := os.Stat(restoredPath); err != nil || !info.ModTime().Equal(mtime) {
+		t.Errorf("restored mtime = %v, %v, want %v", info.ModTime(), err, mtime)
+	}
+
+	// The other bare formats have nowhere to record them, so restoring is refused
+	zstdCompressor, _ := NewFileToFileCompressor(algorithms.ZstdAlgorithm)
+	if err := zstdCompressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+	if err := (&Container { RestoreMetadata: true }).DecompressFileToFile(compressedPath, filepath.Join(restoreDir, "ignored.txt")); err == nil {
+		t.Errorf("DecompressFileToFile restoring a bare zstd file expected an error")
+	}
+}
+
+func TestGzipFileMetadata(t *testing.T) {
+	tests := []struct {
+		stored   string
+		expected string
+	} {
+		{stored: "notes.txt", expected: "notes.txt"},
+		{stored: "dir/notes.txt", expected: "notes.txt"},
+		{stored: `C:\dir\notes.txt`, expected: "notes.txt"},
+		{stored: "", expected: ""},
+		{stored: "..", expected: ""},
+		{stored: "dir/", expected: ""},
+	}
+	for _, tt := range tests {
+		metadata := gzipFileMetadata(gzip.Header{Name: tt.stored})
+		if tt.expected == "" && metadata != nil {
+			t.Errorf("gzipFileMetadata(%q) = %+v, want nil", tt.stored, metadata)
+		} else if tt.expected != "" && (metadata == nil || metadata.Name != tt.expected) {
+			t.Errorf("gzipFileMetadata(%q) = %+v, want the name %q", tt.stored, metadata, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+const (
+	PipelineSeparator = "+" // Separates the stages of a pipeline spec, as in "delta+rle+huffman"
+	pipelineMaxStages = 16  // Largest number of stages accepted in a spec
+)
+
+// Pipeline chains several algorithms: compressing applies the stages in order, and decompressing undoes them in reverse.
+// Its output is a Container, whose header records the stages, so decompressing rebuilds them from the data.
+type Pipeline struct {
+	Stages []int // Algorithms of the stages, as ints meant for the `Algorithms` array in `implemented.go`
+}
+
+// IsPipelineSpec reports whether `spec` names more than one algorithm.
+func IsPipelineSpec(spec string) bool {
+	return strings.Contains(spec, PipelineSeparator)
+}
+
+// ParsePipelineSpec turns a spec such as "delta+rle+huffman" into the algorithms of its stages.
+func ParsePipelineSpec(spec string) ([]int, error) {
+	names := strings.Split(spec, PipelineSeparator)
+	if len(names) > pipelineMaxStages {
+		return nil, fmt.Errorf("pipeline has %d stages, at most %d are supported", len(names), pipelineMaxStages)
+	}
+	stages := make([]int, len(names))
+	for i, name := range names {
+		stages[i] = algorithms.GetAlgorithmID(name)
+		if stages[i] < 0 {
+			return nil, &ErrUnsupportedAlgorithmType { Algorithm: name }
+		}
+	}
+	return stages, nil
+}
+
+// NewPipeline creates a Pipeline from a spec such as "delta+rle+huffman".
+func NewPipeline(spec string) (*Pipeline, error) {
+	stages, err := ParsePipelineSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &Pipeline { Stages: stages }, nil
+}
+
+// NewPipelineDecompressor creates a Pipeline for decompressing; the stages are read from the data.
+func NewPipelineDecompressor() *Pipeline {
+	return &Pipeline{}
+}
+
+// String returns the spec of the pipeline.
+func (p *Pipeline) String() string {
+	names := make([]string, len(p.Stages))
+	for i, stage := range p.Stages {
+		names[i] = algorithms.GetAlgorithmName(stage)
+	}
+	return strings.Join(names, PipelineSeparator)
+}
+
+// Compress implements Compressor, writing a Container that records the stages.
+func (p *Pipeline) Compress(data []byte) ([]byte, error) {
+	return (&Container { Stages: p.Stages, Checksum: algorithms.ChecksumType }).Compress(data)
+}
+
+// Decompress implements Decompressor, reading a Container that must record the Stages of `p`
+// (or any stages, for a Pipeline from NewPipelineDecompressor).
+func (p *Pipeline) Decompress(data []byte) ([]byte, error) {
+	return (&Container { Stages: p.Stages }).Decompress(data)
+}
+
+// compressStages runs the data through the compressors of the stages, in order.
+func (p *Pipeline) compressStages(data []byte) ([]byte, error) {
+	for _, stage := range p.Stages {
+		compressor, err := NewCompressor(stage)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = compressor.Compress(data); err != nil {
+			return nil, p.stageError(stage, err)
+		}
+	}
+	return data, nil
+}
+
+// decompressStages undoes compressStages, running the data through the decompressors of the stages in reverse.
+func (p *Pipeline) decompressStages(data []byte) ([]byte, error) {
+	for i := len(p.Stages) - 1; i >= 0; i-- {
+		decompressor, err := NewDecompressor(p.Stages[i])
+		if err != nil {
+			return nil, err
+		}
+		if data, err = decompressor.Decompress(data); err != nil {
+			return nil, p.stageError(p.Stages[i], err)
+		}
+	}
+	return data, nil
+}
+
+// stageError names the stage that failed, unless it is the only one.
+func (p *Pipeline) stageError(stage int, err error) error {
+	if len(p.Stages) > 1 {
+		return fmt.Errorf("pipeline stage %s: %w", algorithms.GetAlgorithmName(stage), err)
+	}
+	return err
+}
+
+// CompressFileToFile implements FileToFileCompressor, writing a Container that records the stages.
+func (p *Pipeline) CompressFileToFile(inputPath, outputPath string) error {
+	return newFileContainer(p.Stages).CompressFileToFile(inputPath, outputPath)
+}
+
+// DecompressFileToFile implements FileToFileDecompressor, reading a Container that must record the Stages of `p`
+// (or any stages, for a Pipeline from NewPipelineDecompressor).
+func (p *Pipeline) DecompressFileToFile(inputPath, outputPath string) error {
+	return newFileContainer(p.Stages).DecompressFileToFile(inputPath, outputPath)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPipelineRoundTrip(t *testing.T) {
+	counter := make([]byte, 4*5000)
+	for i := 0; i < 5000; i++ {
+		binary.LittleEndian.PutUint32(counter[4*i:], uint32(1000+3*i))
+	}
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200))
+
+	tests := []struct {
+		spec  string
+		input []byte
+	} {
+		{spec: "delta+rle+huffman", input: counter},
+		{spec: "bwt+fse", input: text},
+		{spec: "lzss+range", input: text},
+		{spec: "xor+zstd", input: counter},
+		{spec: "lz4+snappy+gzip", input: text},
+		{spec: "huffman", input: text},
+		{spec: "rle+huffman", input: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			pipeline, err := NewPipeline(tt.spec)
+			if err != nil {
+				t.Fatalf("NewPipeline returned unexpected error: %v", err)
+			}
+			if got := pipeline.String(); got != tt.spec {
+				t.Errorf("String() = %q, want %q", got, tt.spec)
+			}
+
+			compressed, err := pipeline.Compress(tt.input)
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(compressed, containerMagic) || !bytes.HasPrefix(compressed[containerHeaderSize-1:], appendStages(nil, pipeline.Stages)) {
+				t.Errorf("Compress output does not start with a header for %q: % x", tt.spec, compressed[:containerHeaderSize])
+			}
+
+			// The stages come from the data, not from the decompressor
+			got, err := NewPipelineDecompressor().Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestPipelineInvalid(t *testing.T) {
+	var unsupported *ErrUnsupportedAlgorithmType
+	if _, err := NewPipeline("delta+nothing"); !errors.As(err, &unsupported) || unsupported.Algorithm != "nothing" {
+		t.Errorf("NewPipeline with an unknown stage returned %v, want an ErrUnsupportedAlgorithmType for \"nothing\"", err)
+	}
+	if _, err := NewPipeline("rle++huffman"); err == nil {
+		t.Errorf("NewPipeline with an empty stage expected an error")
+	}
+	if _, err := NewPipeline(strings.Repeat("rle+", pipelineMaxStages) + "rle"); err == nil {
+		t.Errorf("NewPipeline with %d stages expected an error", pipelineMaxStages+1)
+	}
+
+	pipeline, _ := NewPipeline("rle+huffman")
+	compressed, err := pipeline.Compress([]byte("aaaaabbbbb"))
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	malformed := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "Missing header", input: compressed[len(containerMagic):]},
+		{name: "Truncated stages", input: compressed[:containerHeaderSize+1]},
+		{name: "Unknown stage", input: append(append(compressed[:containerHeaderSize+1:containerHeaderSize+1], 0xFF), compressed[containerHeaderSize+2:]...)},
+		{name: "Corrupted stage data", input: compressed[:len(compressed)-1]},
+	}
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPipelineDecompressor().Decompress(tt.input); err == nil {
+				t.Errorf("Decompress expected an error")
+			}
+		})
+	}
+
+	// A pipeline with stages only reads data that records them
+	other, _ := NewPipeline("rle+packbits")
+	if _, err := other.Decompress(compressed); !errors.As(err, new(*ErrFormatMismatch)) {
+		t.Errorf("Decompress with other stages returned %v, want an ErrFormatMismatch", err)
+	}
+}
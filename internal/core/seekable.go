@@ -0,0 +1,486 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// Size of the blocks of the seekable format used by NewSeekableCompressor (and therefore by the -seekable flag)
+var SeekableBlockSize = 1 << 20
+
+const (
+	seekableVersion      = 1       // Format version written into seekable files
+	seekableMinBlockSize = 1 << 10 // Smallest accepted block size
+	seekableMaxBlockSize = 1 << 30 // Largest accepted block size
+	seekableFooterSize   = 12      // Length of the index (8 bytes, little-endian) and seekableIndexMagic
+)
+
+var seekableMagic = []byte{'G', 'C', 'S'}           // Start of a seekable file, followed by the format version
+var seekableIndexMagic = []byte{'G', 'C', 'S', 'I'} // End of a seekable file, after the length of the index
+
+// The seekable format splits the data into blocks of the same size (except the last one) and compresses every block on
+// its own with the stages, so a block can be decompressed without the ones before it. It is made of:
+//
+//   - the header: the magic "GCS", the format version, the number of stages, the algorithm ID of every stage
+//     and the block size (uvarint);
+//   - the compressed blocks, one after another;
+//   - the index: the number of blocks (uvarint) and, for every block, its compressed size (uvarint) followed by the
+//     length and checksum of the uncompressed block (see algorithms.Integrity);
+//   - the footer: the length of the index (8 bytes, little-endian) and the magic "GCSI".
+//
+// The footer has a fixed size, so a reader finds the index from the end of the file.
+
+// seekableBlock is an entry of the index of a seekable file.
+type seekableBlock struct {
+	offset    int64                // Offset of the compressed block in the file
+	size      int64                // Size of the compressed block
+	start     int64                // Offset of the uncompressed block in the uncompressed data
+	integrity algorithms.Integrity // Length and checksum of the uncompressed block
+}
+
+// --- // Seekable Writer
+
+// SeekableWriter compresses what is written to it into the seekable format. Close must be called to write the last
+// block and the index.
+type SeekableWriter struct {
+	writer       io.Writer
+	stages       []int
+	blockSize    int
+	checksumType int
+	pending      []byte // Data of the block being filled
+	index        []byte // Entries of the index so far
+	count        int    // Number of blocks written
+	closed       bool
+}
+
+// NewSeekableWriter creates a SeekableWriter that writes to `w`, compressing blocks of `blockSize` bytes with the
+// stages (ints meant for the `Algorithms` array in `implemented.go`). The blocks carry the checksum selected by
+// algorithms.ChecksumType, or a CRC-32 if that is algorithms.ChecksumNone.
+func NewSeekableWriter(w io.Writer, blockSize int, stages ...int) (*SeekableWriter, error) {
+	if err := checkSeekableWriter(blockSize, stages); err != nil {
+		return nil, err
+	}
+	checksumType := algorithms.ChecksumType
+	if checksumType == algorithms.ChecksumNone {
+		checksumType = algorithms.ChecksumCRC32
+	}
+
+	header := append([]byte(nil), seekableMagic...)
+	header = append(header, seekableVersion)
+	header = appendStages(header, stages)
+	header = binary.AppendUvarint(header, uint64(blockSize))
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &SeekableWriter { writer: w, stages: stages, blockSize: blockSize, checksumType: checksumType }, nil
+}
+
+// checkSeekableWriter returns the error NewSeekableWriter would return for the block size and stages.
+func checkSeekableWriter(blockSize int, stages []int) error {
+	if blockSize < seekableMinBlockSize || blockSize > seekableMaxBlockSize {
+		return fmt.Errorf("invalid seekable block size %d (must be between %d and %d)", blockSize, seekableMinBlockSize, seekableMaxBlockSize)
+	}
+	_, err := NewContainer(stages...)
+	return err
+}
+
+// Write implements io.Writer, compressing every block as soon as it is full.
+func (s *SeekableWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, fmt.Errorf("write to a closed SeekableWriter")
+	}
+	written := len(p)
+	for len(p) > 0 {
+		n := s.blockSize - len(s.pending)
+		if n > len(p) {
+			n = len(p)
+		}
+		s.pending = append(s.pending, p[:n]...)
+		p = p[n:]
+		if len(s.pending) == s.blockSize {
+			if err := s.flush(); err != nil {
+				return written - len(p), err // The block stays pending, so the bytes of `p` in it were consumed
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close implements io.Closer, writing the last block, the index and the footer. It does not close the underlying writer.
+func (s *SeekableWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if len(s.pending) > 0 {
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+
+	index := binary.AppendUvarint(nil, uint64(s.count))
+	index = append(index, s.index...)
+	index = binary.LittleEndian.AppendUint64(index, uint64(len(index)))
+	index = append(index, seekableIndexMagic...)
+	_, err := s.writer.Write(index)
+	return err
+}
+
+// flush compresses and writes the pending block.
+func (s *SeekableWriter) flush() error {
+	integrity, err := algorithms.NewIntegrity(s.pending, s.checksumType)
+	if err != nil {
+		return err
+	}
+	block, err := (&Pipeline { Stages: s.stages }).compressStages(s.pending)
+	if err != nil {
+		return err
+	}
+	if _, err := s.writer.Write(block); err != nil {
+		return err
+	}
+
+	s.index = binary.AppendUvarint(s.index, uint64(len(block)))
+	s.index = integrity.Append(s.index)
+	s.count++
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// --- // Seekable Reader
+
+// SeekableReader reads the uncompressed data of a seekable file, decompressing only the blocks that hold the bytes
+// asked for. It implements io.ReaderAt (safe for parallel calls) and io.ReadSeeker (which, like any reader with a
+// position, is not).
+type SeekableReader struct {
+	source    io.ReaderAt
+	stages    []int
+	blockSize int64
+	blocks    []seekableBlock
+	size      int64 // Size of the uncompressed data
+	position  int64 // Offset of the next Read
+
+	mutex  sync.Mutex
+	cached int    // Index of the block in `cache`, -1 for none
+	cache  []byte // Last decompressed block, since reads tend to continue where the last one stopped
+}
+
+// NewSeekableReader creates a SeekableReader for the seekable file of `size` bytes that `r` reads.
+// Only the header, the index and the footer are read here.
+func NewSeekableReader(r io.ReaderAt, size int64) (*SeekableReader, error) {
+	if size < seekableFooterSize {
+		return nil, fmt.Errorf("malformed seekable data: too short")
+	}
+
+	// Header, read with room for the longest one
+	header := make([]byte, len(seekableMagic)+2+containerMaxStages+binary.MaxVarintLen64)
+	if int64(len(header)) > size {
+		header = header[:size]
+	}
+	if _, err := r.ReadAt(header, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, seekableMagic) {
+		return nil, fmt.Errorf("malformed seekable data: missing header")
+	}
+	if version := header[len(seekableMagic)]; version != seekableVersion {
+		return nil, fmt.Errorf("unsupported seekable format version %d (only version %d is supported)", version, seekableVersion)
+	}
+	stages, rest, err := readStages(header[len(seekableMagic)+1:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed seekable data: %w", err)
+	}
+	blockSize, n := binary.Uvarint(rest)
+	if n <= 0 || blockSize < seekableMinBlockSize || blockSize > seekableMaxBlockSize {
+		return nil, fmt.Errorf("malformed seekable data: invalid block size")
+	}
+	offset := int64(len(header) - len(rest) + n) // Start of the first block
+
+	// Footer and index
+	footer := make([]byte, seekableFooterSize)
+	if _, err := r.ReadAt(footer, size-seekableFooterSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[8:], seekableIndexMagic) {
+		return nil, fmt.Errorf("malformed seekable data: missing index")
+	}
+	indexSize := binary.LittleEndian.Uint64(footer)
+	if indexSize > uint64(size-seekableFooterSize-offset) {
+		return nil, fmt.Errorf("malformed seekable data: index of %d bytes does not fit", indexSize)
+	}
+	indexStart := size - seekableFooterSize - int64(indexSize)
+	index := make([]byte, indexSize)
+	if _, err := r.ReadAt(index, indexStart); err != nil {
+		return nil, err
+	}
+
+	count, n := binary.Uvarint(index)
+	if n <= 0 || count > indexSize {
+		return nil, fmt.Errorf("malformed seekable data: invalid number of blocks")
+	}
+	index = index[n:]
+	reader := &SeekableReader { source: r, stages: stages, blockSize: int64(blockSize), blocks: make([]seekableBlock, count), cached: -1 }
+	for i := range reader.blocks {
+		compressedSize, n := binary.Uvarint(index)
+		if n <= 0 || compressedSize > uint64(indexStart-offset) {
+			return nil, fmt.Errorf("malformed seekable data: invalid size of block %d", i)
+		}
+		integrity, m, err := algorithms.ParseIntegrity(index[n:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed seekable data: block %d: %w", i, err)
+		}
+		index = index[n+m:]
+
+		// Every block but the last one is full
+		if integrity.Length > blockSize || integrity.Length == 0 || (i < len(reader.blocks)-1 && integrity.Length != blockSize) {
+			return nil, fmt.Errorf("malformed seekable data: invalid length %d of block %d", integrity.Length, i)
+		}
+		reader.blocks[i] = seekableBlock { offset: offset, size: int64(compressedSize), start: reader.size, integrity: integrity }
+		offset += int64(compressedSize)
+		reader.size += int64(integrity.Length)
+	}
+	if len(index) != 0 || offset != indexStart {
+		return nil, fmt.Errorf("malformed seekable data: the index does not match the blocks")
+	}
+	return reader, nil
+}
+
+// Size returns the size of the uncompressed data.
+func (s *SeekableReader) Size() int64 {
+	return s.size
+}
+
+// Stages returns the algorithms the blocks were compressed with.
+func (s *SeekableReader) Stages() []int {
+	return s.stages
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *SeekableReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	n := 0
+	for n < len(p) && off < s.size {
+		i := sort.Search(len(s.blocks), func(i int) bool { return s.blocks[i].start+int64(s.blocks[i].integrity.Length) > off })
+		block, err := s.block(i)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], block[off-s.blocks[i].start:])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read implements io.Reader.
+func (s *SeekableReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := s.ReadAt(p, s.position)
+	s.position += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil // Reported by the next Read
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (s *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.position
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	s.position = offset
+	return offset, nil
+}
+
+// block returns the decompressed block `i`, which is only decompressed if it is not the cached one.
+func (s *SeekableReader) block(i int) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cached == i {
+		return s.cache, nil
+	}
+
+	compressed := make([]byte, s.blocks[i].size)
+	if _, err := s.source.ReadAt(compressed, s.blocks[i].offset); err != nil && !(err == io.EOF && len(compressed) == 0) {
+		return nil, err
+	}
+	block, err := (&Pipeline { Stages: s.stages }).decompressStages(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %w", i, err)
+	}
+	if err := s.blocks[i].integrity.Verify(block); err != nil {
+		return nil, fmt.Errorf("block %d: %w", i, err)
+	}
+	s.cached, s.cache = i, block
+	return block, nil
+}
+
+// --- // Seekable Compressor
+
+// SeekableCompressor implements GeneralCompressor and GeneralDecompressor with the seekable format. Its file-to-file
+// methods stream the data, so files larger than the memory can be compressed and decompressed.
+type SeekableCompressor struct {
+	Stages    []int // Algorithms the blocks are compressed with; when decompressing, the ones the data must record, or nil for any
+	BlockSize int   // Size of the uncompressed blocks
+}
+
+// NewSeekableCompressor creates a SeekableCompressor for the given algorithms, with blocks of SeekableBlockSize bytes.
+func NewSeekableCompressor(stages ...int) (*SeekableCompressor, error) {
+	if _, err := NewContainer(stages...); err != nil {
+		return nil, err
+	}
+	return &SeekableCompressor { Stages: stages, BlockSize: SeekableBlockSize }, nil
+}
+
+// NewSeekableDecompressor creates a SeekableCompressor that decompresses data made with any algorithm.
+func NewSeekableDecompressor() *SeekableCompressor {
+	return &SeekableCompressor{}
+}
+
+// Compress implements Compressor.
+func (s *SeekableCompressor) Compress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := NewSeekableWriter(&buffer, s.BlockSize, s.Stages...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Decompress implements Decompressor.
+func (s *SeekableCompressor) Decompress(data []byte) ([]byte, error) {
+	reader, err := s.newReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	if reader.Size() == 0 {
+		return nil, nil // Return nil slice for empty output
+	}
+	output := make([]byte, reader.Size())
+	if _, err := reader.ReadAt(output, 0); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// CompressFileToFile implements FileToFileCompressor.
+func (s *SeekableCompressor) CompressFileToFile(inputPath, outputPath string) error {
+	if err := checkSeekableWriter(s.BlockSize, s.Stages); err != nil {
+		return err
+	}
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	defer input.Close()
+
+	return writeOutputFile(outputPath, func(w io.Writer) error {
+		writer, err := NewSeekableWriter(w, s.BlockSize, s.Stages...)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, input); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
+
+// DecompressFileToFile implements FileToFileDecompressor.
+func (s *SeekableCompressor) DecompressFileToFile(inputPath, outputPath string) error {
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	defer input.Close()
+	info, err := input.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	reader, err := s.newReader(input, info.Size())
+	if err != nil {
+		return err
+	}
+
+	return writeOutputFile(outputPath, func(w io.Writer) error {
+		_, err := io.Copy(w, io.NewSectionReader(reader, 0, reader.Size()))
+		return err
+	})
+}
+
+// writeOutputFile creates the file at `path` and streams the output of `write` into it, removing the file if
+// anything fails, so no partial output is left behind.
+func writeOutputFile(path string, write func(w io.Writer) error) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	buffered := bufio.NewWriter(output)
+	err = write(buffered)
+	if err == nil {
+		if err = buffered.Flush(); err != nil {
+			err = fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+	if closeErr := output.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write output file: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// newReader creates a SeekableReader and checks that the data records the Stages of `s`.
+func (s *SeekableCompressor) newReader(r io.ReaderAt, size int64) (*SeekableReader, error) {
+	reader, err := NewSeekableReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStages(s.Stages, reader.Stages()); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// isSeekableFile reports whether the file at `path` starts like a seekable file.
+func isSeekableFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	magic := make([]byte, len(seekableMagic))
+	_, err = io.ReadFull(file, magic)
+	return err == nil && bytes.Equal(magic, seekableMagic)
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/superiden3/go_compress/internal/core/algorithms"
+)
+
+// seekableTestData returns data that differs at every offset, so a read from the wrong place is noticed.
+func seekableTestData(size int) []byte {
+	var buffer bytes.Buffer
+	for i := 0; buffer.Len() < size; i++ {
+		fmt.Fprintf(&buffer, "line %d of the seekable test data\n", i)
+	}
+	return buffer.Bytes()[:size]
+}
+
+func TestSeekableRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []int
+		size   int
+	} {
+		{name: "several blocks", stages: []int{algorithms.HuffmanAlgorithm}, size: 10000},
+		{name: "whole blocks", stages: []int{algorithms.RLEAlgorithm}, size: 4096},
+		{name: "one short block", stages: []int{algorithms.ZstdAlgorithm}, size: 100},
+		{name: "pipeline", stages: []int{algorithms.DeltaAlgorithm, algorithms.LZSSAlgorithm, algorithms.HuffmanAlgorithm}, size: 5000},
+		{name: "empty", stages: []int{algorithms.GzipAlgorithm}, size: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := seekableTestData(tt.size)
+			compressor := &SeekableCompressor { Stages: tt.stages, BlockSize: 1024 }
+			compressed, err := compressor.Compress(input)
+			if err != nil {
+				t.Fatalf("Compress returned unexpected error: %v", err)
+			}
+
+			// The seekable decompressor and the detecting container both read it
+			for _, decompressor := range []Decompressor{compressor, NewSeekableDecompressor(), NewContainerDecompressor()} {
+				got, err := decompressor.Decompress(compressed)
+				if err != nil {
+					t.Fatalf("Decompress returned unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, input) {
+					t.Errorf("Decompress(Compress(x)) returned %d bytes, want %d", len(got), len(input))
+				}
+			}
+		})
+	}
+}
+
+func TestSeekableReaderAt(t *testing.T) {
+	input := seekableTestData(10000)
+	compressed, err := (&SeekableCompressor { Stages: []int{algorithms.LZ4Algorithm}, BlockSize: 1024 }).Compress(input)
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	reader, err := NewSeekableReader(bytes.NewReader(compressed), int64(len(compressed)))
+	if err != nil {
+		t.Fatalf("NewSeekableReader returned unexpected error: %v", err)
+	}
+	if reader.Size() != int64(len(input)) {
+		t.Errorf("Size() = %d, want %d", reader.Size(), len(input))
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		length int
+		want   int // Number of bytes read, which is less than length at the end
+	} {
+		{name: "start", offset: 0, length: 10, want: 10},
+		{name: "inside a block", offset: 2000, length: 24, want: 24},
+		{name: "across a block boundary", offset: 1020, length: 10, want: 10},
+		{name: "across several blocks", offset: 500, length: 3000, want: 3000},
+		{name: "last byte", offset: 9999, length: 1, want: 1},
+		{name: "past the end", offset: 9990, length: 20, want: 10},
+		{name: "at the end", offset: 10000, length: 5, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := make([]byte, tt.length)
+			n, err := reader.ReadAt(buffer, tt.offset)
+			if n != tt.want {
+				t.Errorf("ReadAt read %d bytes, want %d", n, tt.want)
+			}
+			if (n < tt.length) != (err == io.EOF) {
+				t.Errorf("ReadAt returned error %v after reading %d of %d bytes", err, n, tt.length)
+			}
+			if !bytes.Equal(buffer[:n], input[tt.offset:tt.offset+int64(n)]) {
+				t.Errorf("ReadAt returned %q, want %q", buffer[:n], input[tt.offset:tt.offset+int64(n)])
+			}
+		})
+	}
+}
+
+func TestSeekableReaderSeek(t *testing.T) {
+	input := seekableTestData(5000)
+	compressed, err := (&SeekableCompressor { Stages: []int{algorithms.HuffmanAlgorithm}, BlockSize: 1024 }).Compress(input)
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	reader, err := NewSeekableReader(bytes.NewReader(compressed), int64(len(compressed)))
+	if err != nil {
+		t.Fatalf("NewSeekableReader returned unexpected error: %v", err)
+	}
+
+	if position, err := reader.Seek(-100, io.SeekEnd); err != nil || position != 4900 {
+		t.Fatalf("Seek(-100, io.SeekEnd) = %d, %v, want 4900", position, err)
+	}
+	got, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, input[4900:]) {
+		t.Errorf("ReadAll after Seek = %q, %v, want %q", got, err, input[4900:])
+	}
+
+	if _, err := reader.Seek(1000, io.SeekStart); err != nil {
+		t.Fatalf("Seek(1000, io.SeekStart) returned unexpected error: %v", err)
+	}
+	if position, err := reader.Seek(50, io.SeekCurrent); err != nil || position != 1050 {
+		t.Fatalf("Seek(50, io.SeekCurrent) = %d, %v, want 1050", position, err)
+	}
+	buffer := make([]byte, 100)
+	if _, err := io.ReadFull(reader, buffer); err != nil || !bytes.Equal(buffer, input[1050:1150]) {
+		t.Errorf("ReadFull after Seek = %q, %v, want %q", buffer, err, input[1050:1150])
+	}
+
+	if _, err := reader.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Seek to a negative position expected an error")
+	}
+}
+
+func TestSeekableCorrupt(t *testing.T) {
+	input := seekableTestData(4000)
+	compressed, err := (&SeekableCompressor { Stages: []int{algorithms.PackBitsAlgorithm}, BlockSize: 1024 }).Compress(input)
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	reader, err := NewSeekableReader(bytes.NewReader(compressed), int64(len(compressed)))
+	if err != nil {
+		t.Fatalf("NewSeekableReader returned unexpected error: %v", err)
+	}
+
+	// PackBits decodes a changed literal without complaint, so only the checksum of the block catches it
+	corrupt := append([]byte(nil), compressed...)
+	corrupt[reader.blocks[2].offset+10] ^= 0x20
+	reader, err = NewSeekableReader(bytes.NewReader(corrupt), int64(len(corrupt)))
+	if err != nil {
+		t.Fatalf("NewSeekableReader returned unexpected error: %v", err)
+	}
+	if _, err := reader.ReadAt(make([]byte, 10), 2100); !errors.Is(err, &algorithms.ErrChecksumMismatch{}) {
+		t.Errorf("ReadAt of the corrupt block returned %v, want an ErrChecksumMismatch", err)
+	}
+	if _, err := reader.ReadAt(make([]byte, 10), 100); err != nil {
+		t.Errorf("ReadAt of another block returned unexpected error: %v", err)
+	}
+
+	malformed := []struct {
+		name  string
+		input []byte
+	} {
+		{name: "truncated", input: compressed[:len(compressed)-1]},
+		{name: "missing footer magic", input: append(compressed[:len(compressed)-1:len(compressed)-1], 'X')},
+		{name: "unknown version", input: append([]byte{'G', 'C', 'S', 9}, compressed[len(seekableMagic)+1:]...)},
+		{name: "extra block data", input: append(append(compressed[:10:10], 0), compressed[10:]...)},
+		{name: "too short", input: compressed[:seekableFooterSize-1]},
+	}
+	for _, tt := range malformed {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSeekableReader(bytes.NewReader(tt.input), int64(len(tt.input))); err == nil {
+				t.Errorf("NewSeekableReader expected an error")
+			}
+		})
+	}
+}
+
+func TestSeekableInvalid(t *testing.T) {
+	if _, err := NewSeekableWriter(io.Discard, 10, algorithms.RLEAlgorithm); err == nil {
+		t.Errorf("NewSeekableWriter with a tiny block size expected an error")
+	}
+	if _, err := NewSeekableCompressor(); err == nil {
+		t.Errorf("NewSeekableCompressor without stages expected an error")
+	}
+
+	compressed, err := (&SeekableCompressor { Stages: []int{algorithms.HuffmanAlgorithm}, BlockSize: 1024 }).Compress([]byte("some data"))
+	if err != nil {
+		t.Fatalf("Compress returned unexpected error: %v", err)
+	}
+	var mismatch *ErrFormatMismatch
+	if _, err := (&Container { Stages: []int{algorithms.RLEAlgorithm} }).Decompress(compressed); !errors.As(err, &mismatch) || mismatch.Found != "huffman" {
+		t.Errorf("Decompress with rle returned %v, want an ErrFormatMismatch with huffman", err)
+	}
+}
+
+// failingWriter accepts `left` more bytes, then fails every Write.
+type failingWriter struct {
+	left int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		return 0, errors.New("disk full")
+	}
+	w.left -= len(p)
+	return len(p), nil
+}
+
+func TestSeekableWriterError(t *testing.T) {
+	writer, err := NewSeekableWriter(&failingWriter { left: 100 }, 1024, algorithms.RLEAlgorithm)
+	if err != nil {
+		t.Fatalf("NewSeekableWriter returned unexpected error: %v", err)
+	}
+	input := seekableTestData(5000)
+	n, err := writer.Write(input)
+	if err == nil {
+		t.Fatalf("Write to a failing writer expected an error")
+	}
+	if n != 1024 {
+		t.Errorf("Write returned %d, want the 1024 bytes of the pending block", n)
+	}
+}
+
+func TestFileToFileSeekable(t *testing.T) {
+	dir := t.TempDir()
+	input := seekableTestData(50000)
+	inputPath := filepath.Join(dir, "input.txt")
+	compressedPath := filepath.Join(dir, "input.gcs")
+	outputPath := filepath.Join(dir, "output.txt")
+	if err := os.WriteFile(inputPath, input, 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+
+	compressor := &SeekableCompressor { Stages: []int{algorithms.ZstdAlgorithm}, BlockSize: 4096 }
+	if err := compressor.CompressFileToFile(inputPath, compressedPath); err != nil {
+		t.Fatalf("CompressFileToFile returned unexpected error: %v", err)
+	}
+
+	// The container decompressors recognize the format, so -decompress needs no extra flag
+	decompressor, _ := NewFileToFileDecompressor(algorithms.ZstdAlgorithm)
+	for _, decompressor := range []FileToFileDecompressor{decompressor, NewContainerDecompressor(), NewSeekableDecompressor()} {
+		if err := decompressor.DecompressFileToFile(compressedPath, outputPath); err != nil {
+			t.Fatalf("DecompressFileToFile returned unexpected error: %v", err)
+		}
+		if got, _ := os.ReadFile(outputPath); !bytes.Equal(got, input) {
+			t.Errorf("DecompressFileToFile wrote %d bytes, want %d", len(got), len(input))
+		}
+	}
+
+	// Reading a range from the file only needs the blocks it covers
+	file, err := os.Open(compressedPath)
+	if err != nil {
+		t.Fatalf("Open returned unexpected error: %v", err)
+	}
+	defer file.Close()
+	info, _ := file.Stat()
+	reader, err := NewSeekableReader(file, info.Size())
+	if err != nil {
+		t.Fatalf("NewSeekableReader returned unexpected error: %v", err)
+	}
+	buffer := make([]byte, 5000)
+	if _, err := reader.ReadAt(buffer, 30000); err != nil || !bytes.Equal(buffer, input[30000:35000]) {
+		t.Errorf("ReadAt = %v, want the bytes at 30000", err)
+	}
+}
+
+func TestFileToFileSeekableFailure(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputPath, seekableTestData(5000), 0644); err != nil {
+		t.Fatalf("WriteFile returned unexpected error: %v", err)
+	}
+
+	// A failure before or while writing leaves no output file behind
+	tests := []struct {
+		name       string
+		compressor *SeekableCompressor
+		input      string
+	} {
+		{name: "Block size of 0", compressor: &SeekableCompressor { Stages: []int{algorithms.RLEAlgorithm} }, input: inputPath},
+		{name: "No stages", compressor: &SeekableCompressor { BlockSize: 4096 }, input: inputPath},
+		{name: "Unreadable input", compressor: &SeekableCompressor { Stages: []int{algorithms.RLEAlgorithm}, BlockSize: 4096 }, input: dir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(dir, "output.gcs")
+			if err := tt.compressor.CompressFileToFile(tt.input, outputPath); err == nil {
+				t.Fatalf("CompressFileToFile expected an error")
+			}
+			if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+				t.Errorf("CompressFileToFile left the output file behind: %v", err)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@
 package compression
 
 import (
+	"io"
+
 	"github.com/superiden3/go_compress/internal/core"
+	"github.com/superiden3/go_compress/internal/core/algorithms"
 )
 
 // Custom error for unsupported algorithms
@@ -32,12 +35,137 @@ func NewDecompressor(algorithm int) (Decompressor, error) {
 	return core.NewDecompressor(algorithm)
 }
 
-// NewFileToFileCompressor creates a new FileToFileCompressor based on the specified algorithm type.
+// NewFileToFileCompressor creates a new FileToFileCompressor based on the specified algorithm type; its files record the algorithm,
+// in a container header unless the format of the algorithm starts with a signature of its own.
 func NewFileToFileCompressor(algorithm int) (FileToFileCompressor, error) {
 	return core.NewFileToFileCompressor(algorithm)
 }
 
 // NewFileToFileDecompressor creates a new FileToFileDecompressor based on the specified algorithm type.
+// Files compressed with another algorithm are rejected with an ErrFormatMismatch.
 func NewFileToFileDecompressor(algorithm int) (FileToFileDecompressor, error) {
 	return core.NewFileToFileDecompressor(algorithm)
 }
+
+// NewRawFileToFileCompressor creates a FileToFileCompressor that writes the bare output of the algorithm, without a container.
+func NewRawFileToFileCompressor(algorithm int) (FileToFileCompressor, error) {
+	return core.NewRawFileToFileCompressor(algorithm)
+}
+
+// NewRawFileToFileDecompressor creates a FileToFileDecompressor for files written by NewRawFileToFileCompressor.
+func NewRawFileToFileDecompressor(algorithm int) (FileToFileDecompressor, error) {
+	return core.NewRawFileToFileDecompressor(algorithm)
+}
+
+// Container records the algorithms of the data in a header, so decompressing detects them.
+type Container = core.Container
+
+// Error returned when data is decompressed with other algorithms than it was compressed with, or is not recognized at all
+type ErrFormatMismatch = core.ErrFormatMismatch
+
+// Errors returned when decompressed data does not have the checksum or length stored with it; check for them with errors.Is
+type ErrChecksumMismatch = algorithms.ErrChecksumMismatch
+type ErrLengthMismatch = algorithms.ErrLengthMismatch
+
+// Checksums a Container can store, for its Checksum field
+const (
+	ChecksumNone   = algorithms.ChecksumNone
+	ChecksumCRC32  = algorithms.ChecksumCRC32
+	ChecksumCRC32C = algorithms.ChecksumCRC32C
+)
+
+// Name, mode and mtime of the file a Container was compressed from (see Container.CompressWithMetadata)
+type FileMetadata = core.FileMetadata
+
+// NewContainer creates a Container that compresses with the given algorithms, in order; it is both a Compressor and a Decompressor.
+func NewContainer(stages ...int) (*Container, error) {
+	return core.NewContainer(stages...)
+}
+
+// NewContainerDecompressor creates a Container that decompresses data made with any algorithm, read from its header.
+func NewContainerDecompressor() *Container {
+	return core.NewContainerDecompressor()
+}
+
+// NewRLECompressorWithSymbolWidth creates a run-length Compressor that finds runs of `symbolWidth`-byte values (e.g. 2, 4 or 8 for 16, 32 or 64-bit data).
+// Its output can be decompressed by the Decompressor of the RLE algorithm.
+func NewRLECompressorWithSymbolWidth(symbolWidth int) Compressor {
+	return algorithms.NewRLECompressorWithSymbolWidth(symbolWidth)
+}
+
+// NewFilteredCompressor creates a Compressor that runs the data through a filter (such as delta or xor) and then compresses it with `algorithm`.
+func NewFilteredCompressor(filter int, algorithm int) (Compressor, error) {
+	return core.NewFilteredCompressor(filter, algorithm)
+}
+
+// NewFilteredDecompressor creates the Decompressor for the output of NewFilteredCompressor with the same filter and algorithm.
+func NewFilteredDecompressor(filter int, algorithm int) (Decompressor, error) {
+	return core.NewFilteredDecompressor(filter, algorithm)
+}
+
+// Pipeline chains several algorithms, recording its stages in a Container header so decompression can rebuild it.
+type Pipeline = core.Pipeline
+
+// NewPipeline creates a Pipeline from a spec such as "delta+rle+huffman"; it is both a Compressor and a Decompressor.
+func NewPipeline(spec string) (*Pipeline, error) {
+	return core.NewPipeline(spec)
+}
+
+// Error returned when data is decompressed without the dictionary it was compressed with
+type ErrDictionaryMismatch = algorithms.ErrDictionaryMismatch
+
+// NewCompressorWithDictionary creates a Compressor that uses a preset dictionary (for the lzss, zlib, lz4 and zstd algorithms).
+func NewCompressorWithDictionary(algorithm int, dictionary []byte) (Compressor, error) {
+	return core.NewCompressorWithDictionary(algorithm, dictionary)
+}
+
+// NewDecompressorWithDictionary creates the Decompressor for the output of NewCompressorWithDictionary with the same algorithm and dictionary.
+func NewDecompressorWithDictionary(algorithm int, dictionary []byte) (Decompressor, error) {
+	return core.NewDecompressorWithDictionary(algorithm, dictionary)
+}
+
+// TrainDictionary builds a dictionary of at most `size` bytes from samples of the data it will be used for.
+func TrainDictionary(samples [][]byte, size int) ([]byte, error) {
+	return algorithms.TrainDictionary(samples, size)
+}
+
+// SeekableWriter compresses blocks of a fixed size independently and ends with an index of them (see NewSeekableWriter).
+type SeekableWriter = core.SeekableWriter
+
+// SeekableReader is an io.ReaderAt and io.ReadSeeker over the uncompressed data of a SeekableWriter's output; it decompresses only the blocks it reads from.
+type SeekableReader = core.SeekableReader
+
+// NewSeekableWriter creates a SeekableWriter that writes to `w`, compressing blocks of `blockSize` bytes with the given algorithms, in order.
+// Close must be called to write the index.
+func NewSeekableWriter(w io.Writer, blockSize int, stages ...int) (*SeekableWriter, error) {
+	return core.NewSeekableWriter(w, blockSize, stages...)
+}
+
+// NewSeekableReader creates a SeekableReader for the `size` bytes of seekable data that `r` reads, such as an *os.File.
+func NewSeekableReader(r io.ReaderAt, size int64) (*SeekableReader, error) {
+	return core.NewSeekableReader(r, size)
+}
+
+// ArchiveEntry is a file or directory of an archive, with its path, mode, mtime, size and algorithm.
+type ArchiveEntry = core.ArchiveEntry
+
+// ArchiveWriter bundles files and directories, each compressed on its own, into an archive that ends with an index of them.
+type ArchiveWriter = core.ArchiveWriter
+
+// ArchiveReader lists the entries of an archive and reads or extracts them, reading only the data of the entries asked for.
+type ArchiveReader = core.ArchiveReader
+
+// NewArchiveWriter creates an ArchiveWriter that writes to `w`. Close must be called to write the index.
+func NewArchiveWriter(w io.Writer) (*ArchiveWriter, error) {
+	return core.NewArchiveWriter(w)
+}
+
+// NewArchiveReader creates an ArchiveReader for the `size` bytes of archive that `r` reads.
+func NewArchiveReader(r io.ReaderAt, size int64) (*ArchiveReader, error) {
+	return core.NewArchiveReader(r, size)
+}
+
+// OpenArchive opens the archive at `path`; Close must be called when done with it.
+func OpenArchive(path string) (*ArchiveReader, error) {
+	return core.OpenArchive(path)
+}